@@ -2,7 +2,6 @@ package algos
 
 import (
 	"crypto/rand"
-	"crypto/sha256"
 	"math/big"
 )
 
@@ -31,10 +30,3 @@ func GenerateSharedKey(privateKey, otherPublicKey, prime *big.Int) *big.Int {
 	sharedKey := new(big.Int).Exp(otherPublicKey, privateKey, prime)
 	return sharedKey
 }
-
-func HashSharedKey(sharedKey *big.Int) []byte {
-	hash := sha256.New()
-	hash.Write(sharedKey.Bytes())
-	hashedKey := hash.Sum(nil)
-	return hashedKey
-}
@@ -0,0 +1,284 @@
+package algos
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/bits"
+)
+
+const camelliaBlockSize = 16
+
+// константы Σ1..Σ6 из RFC 3713
+var camelliaSigma = [6]uint64{
+	0xA09E667F3BCC908B,
+	0xB67AE8584CAA73B2,
+	0xC6EF372FE94F82BE,
+	0x54FF53A5F1D36F1C,
+	0x10E527FADE682D1D,
+	0xB05688C2B3E6C1FD,
+}
+
+type Camellia struct {
+	kw        [4]uint64
+	k         [24]uint64
+	ke        [6]uint64
+	keyLength int
+}
+
+func init() {
+	RegisterCipher(CipherInfo{
+		Name:        "camellia",
+		DisplayName: "Camellia",
+		BlockSize:   camelliaBlockSize,
+		KeySizes:    []int{16, 24, 32},
+		New: func(params string) (Cipher, error) {
+			if params != "" {
+				return nil, fmt.Errorf("camellia does not take parameters: %q", params)
+			}
+			return NewCamellia()
+		},
+	})
+}
+
+func NewCamellia() (*Camellia, error) {
+	return &Camellia{}, nil
+}
+
+func (c *Camellia) BlockSize() int {
+	return camelliaBlockSize
+}
+
+func (c *Camellia) CipherKey(key []byte) error {
+	if len(key) != 16 && len(key) != 24 && len(key) != 32 {
+		return errors.New("invalid key size (must be 16, 24, or 32 bytes)")
+	}
+	c.keyLength = len(key)
+	c.generateSubKeys(key)
+	return nil
+}
+
+// rotl128 циклически сдвигает 128-битное значение (hi, lo) влево на n бит
+func rotl128(hi, lo uint64, n uint) (uint64, uint64) {
+	n %= 128
+	if n >= 64 {
+		hi, lo = lo, hi
+		n -= 64
+	}
+	if n == 0 {
+		return hi, lo
+	}
+	return hi<<n | lo>>(64-n), lo<<n | hi>>(64-n)
+}
+
+func (c *Camellia) generateSubKeys(key []byte) {
+	klHi := binary.BigEndian.Uint64(key[0:8])
+	klLo := binary.BigEndian.Uint64(key[8:16])
+	var krHi, krLo uint64
+	switch len(key) {
+	case 24:
+		krHi = binary.BigEndian.Uint64(key[16:24])
+		krLo = ^krHi
+	case 32:
+		krHi = binary.BigEndian.Uint64(key[16:24])
+		krLo = binary.BigEndian.Uint64(key[24:32])
+	}
+
+	d1, d2 := klHi^krHi, klLo^krLo
+	d2 ^= camelliaF(d1, camelliaSigma[0])
+	d1 ^= camelliaF(d2, camelliaSigma[1])
+	d1 ^= klHi
+	d2 ^= klLo
+	d2 ^= camelliaF(d1, camelliaSigma[2])
+	d1 ^= camelliaF(d2, camelliaSigma[3])
+	kaHi, kaLo := d1, d2
+
+	d1, d2 = kaHi^krHi, kaLo^krLo
+	d2 ^= camelliaF(d1, camelliaSigma[4])
+	d1 ^= camelliaF(d2, camelliaSigma[5])
+	kbHi, kbLo := d1, d2
+
+	// kl(n, true) — левая половина (KL <<< n), kl(n, false) — правая; аналогично для KR, KA, KB
+	kl := func(n uint, hi bool) uint64 { return half(rotl128(klHi, klLo, n))(hi) }
+	kr := func(n uint, hi bool) uint64 { return half(rotl128(krHi, krLo, n))(hi) }
+	ka := func(n uint, hi bool) uint64 { return half(rotl128(kaHi, kaLo, n))(hi) }
+	kb := func(n uint, hi bool) uint64 { return half(rotl128(kbHi, kbLo, n))(hi) }
+
+	if len(key) == 16 {
+		c.kw[0], c.kw[1] = kl(0, true), kl(0, false)
+		c.k[0], c.k[1] = ka(0, true), ka(0, false)
+		c.k[2], c.k[3] = kl(15, true), kl(15, false)
+		c.k[4], c.k[5] = ka(15, true), ka(15, false)
+		c.ke[0], c.ke[1] = ka(30, true), ka(30, false)
+		c.k[6], c.k[7] = kl(45, true), kl(45, false)
+		c.k[8], c.k[9] = ka(45, true), kl(60, false)
+		c.k[10], c.k[11] = ka(60, true), ka(60, false)
+		c.ke[2], c.ke[3] = kl(77, true), kl(77, false)
+		c.k[12], c.k[13] = kl(94, true), kl(94, false)
+		c.k[14], c.k[15] = ka(94, true), ka(94, false)
+		c.k[16], c.k[17] = kl(111, true), kl(111, false)
+		c.kw[2], c.kw[3] = ka(111, true), ka(111, false)
+		return
+	}
+
+	c.kw[0], c.kw[1] = kl(0, true), kl(0, false)
+	c.k[0], c.k[1] = kb(0, true), kb(0, false)
+	c.k[2], c.k[3] = kr(15, true), kr(15, false)
+	c.k[4], c.k[5] = ka(15, true), ka(15, false)
+	c.ke[0], c.ke[1] = kr(30, true), kr(30, false)
+	c.k[6], c.k[7] = kb(30, true), kb(30, false)
+	c.k[8], c.k[9] = kl(45, true), kl(45, false)
+	c.k[10], c.k[11] = ka(45, true), ka(45, false)
+	c.ke[2], c.ke[3] = kl(60, true), kl(60, false)
+	c.k[12], c.k[13] = kr(60, true), kr(60, false)
+	c.k[14], c.k[15] = kb(60, true), kb(60, false)
+	c.k[16], c.k[17] = kl(77, true), kl(77, false)
+	c.ke[4], c.ke[5] = ka(77, true), ka(77, false)
+	c.k[18], c.k[19] = kr(94, true), kr(94, false)
+	c.k[20], c.k[21] = ka(94, true), ka(94, false)
+	c.k[22], c.k[23] = kl(111, true), kl(111, false)
+	c.kw[2], c.kw[3] = kb(111, true), kb(111, false)
+}
+
+func half(hi, lo uint64) func(bool) uint64 {
+	return func(left bool) uint64 {
+		if left {
+			return hi
+		}
+		return lo
+	}
+}
+
+func camelliaF(in, key uint64) uint64 {
+	x := in ^ key
+	t1 := camelliaSBox1[byte(x>>56)]
+	t2 := camelliaSBox2(byte(x >> 48))
+	t3 := camelliaSBox3(byte(x >> 40))
+	t4 := camelliaSBox4(byte(x >> 32))
+	t5 := camelliaSBox2(byte(x >> 24))
+	t6 := camelliaSBox3(byte(x >> 16))
+	t7 := camelliaSBox4(byte(x >> 8))
+	t8 := camelliaSBox1[byte(x)]
+
+	y1 := t1 ^ t3 ^ t4 ^ t6 ^ t7 ^ t8
+	y2 := t1 ^ t2 ^ t4 ^ t5 ^ t7 ^ t8
+	y3 := t1 ^ t2 ^ t3 ^ t5 ^ t6 ^ t8
+	y4 := t2 ^ t3 ^ t4 ^ t5 ^ t6 ^ t7
+	y5 := t1 ^ t2 ^ t6 ^ t7 ^ t8
+	y6 := t2 ^ t3 ^ t5 ^ t7 ^ t8
+	y7 := t3 ^ t4 ^ t5 ^ t6 ^ t8
+	y8 := t1 ^ t4 ^ t5 ^ t6 ^ t7
+
+	return uint64(y1)<<56 | uint64(y2)<<48 | uint64(y3)<<40 | uint64(y4)<<32 |
+		uint64(y5)<<24 | uint64(y6)<<16 | uint64(y7)<<8 | uint64(y8)
+}
+
+func camelliaFL(in, key uint64) uint64 {
+	x1, x2 := uint32(in>>32), uint32(in)
+	k1, k2 := uint32(key>>32), uint32(key)
+	x2 ^= bits.RotateLeft32(x1&k1, 1)
+	x1 ^= x2 | k2
+	return uint64(x1)<<32 | uint64(x2)
+}
+
+func camelliaFLInv(in, key uint64) uint64 {
+	y1, y2 := uint32(in>>32), uint32(in)
+	k1, k2 := uint32(key>>32), uint32(key)
+	y1 ^= y2 | k2
+	y2 ^= bits.RotateLeft32(y1&k1, 1)
+	return uint64(y1)<<32 | uint64(y2)
+}
+
+func camelliaSBox2(x byte) byte { return bits.RotateLeft8(camelliaSBox1[x], 1) }
+func camelliaSBox3(x byte) byte { return bits.RotateLeft8(camelliaSBox1[x], 7) }
+func camelliaSBox4(x byte) byte { return camelliaSBox1[bits.RotateLeft8(x, 1)] }
+
+func (c *Camellia) Encrypt(data []byte) ([]byte, error) {
+	return c.EncryptBlock(data)
+}
+
+func (c *Camellia) Decrypt(data []byte) ([]byte, error) {
+	return c.DecryptBlock(data)
+}
+
+func (c *Camellia) EncryptBlock(data []byte) ([]byte, error) {
+	if len(data) != camelliaBlockSize {
+		return nil, errors.New("invalid block size: must be 16 bytes")
+	}
+	if c.keyLength == 0 {
+		return nil, errors.New("camellia key is not set")
+	}
+	return c.crypt(data, c.kw, c.k[:c.rounds()], c.ke[:c.flLayers()]), nil
+}
+
+func (c *Camellia) DecryptBlock(data []byte) ([]byte, error) {
+	if len(data) != camelliaBlockSize {
+		return nil, errors.New("invalid block size: must be 16 bytes")
+	}
+	if c.keyLength == 0 {
+		return nil, errors.New("camellia key is not set")
+	}
+
+	// расшифрование — то же преобразование с подключами в обратном порядке
+	rounds := c.rounds()
+	kw := [4]uint64{c.kw[2], c.kw[3], c.kw[0], c.kw[1]}
+	k := make([]uint64, rounds)
+	for i := range k {
+		k[i] = c.k[rounds-1-i]
+	}
+	ke := make([]uint64, c.flLayers())
+	for i := range ke {
+		ke[i] = c.ke[len(ke)-1-i]
+	}
+	return c.crypt(data, kw, k, ke), nil
+}
+
+func (c *Camellia) rounds() int {
+	if c.keyLength == 16 {
+		return 18
+	}
+	return 24
+}
+
+// flLayers — число подключей FL/FL^-1: по паре на каждые 6 раундов, кроме последних
+func (c *Camellia) flLayers() int {
+	return 2 * (c.rounds()/6 - 1)
+}
+
+func (c *Camellia) crypt(data []byte, kw [4]uint64, k, ke []uint64) []byte {
+	d1 := binary.BigEndian.Uint64(data[0:8]) ^ kw[0]
+	d2 := binary.BigEndian.Uint64(data[8:16]) ^ kw[1]
+
+	for i := 0; i < len(k); i += 2 {
+		if i > 0 && i%6 == 0 {
+			d1 = camelliaFL(d1, ke[i/3-2])
+			d2 = camelliaFLInv(d2, ke[i/3-1])
+		}
+		d2 ^= camelliaF(d1, k[i])
+		d1 ^= camelliaF(d2, k[i+1])
+	}
+
+	out := make([]byte, camelliaBlockSize)
+	binary.BigEndian.PutUint64(out[0:8], d2^kw[2])
+	binary.BigEndian.PutUint64(out[8:16], d1^kw[3])
+	return out
+}
+
+var camelliaSBox1 = [256]byte{
+	112, 130, 44, 236, 179, 39, 192, 229, 228, 133, 87, 53, 234, 12, 174, 65,
+	35, 239, 107, 147, 69, 25, 165, 33, 237, 14, 79, 78, 29, 101, 146, 189,
+	134, 184, 175, 143, 124, 235, 31, 206, 62, 48, 220, 95, 94, 197, 11, 26,
+	166, 225, 57, 202, 213, 71, 93, 61, 217, 1, 90, 214, 81, 86, 108, 77,
+	139, 13, 154, 102, 251, 204, 176, 45, 116, 18, 43, 32, 240, 177, 132, 153,
+	223, 76, 203, 194, 52, 126, 118, 5, 109, 183, 169, 49, 209, 23, 4, 215,
+	20, 88, 58, 97, 222, 27, 17, 28, 50, 15, 156, 22, 83, 24, 242, 34,
+	254, 68, 207, 178, 195, 181, 122, 145, 36, 8, 232, 168, 96, 252, 105, 80,
+	170, 208, 160, 125, 161, 137, 98, 151, 84, 91, 30, 149, 224, 255, 100, 210,
+	16, 196, 0, 72, 163, 247, 117, 219, 138, 3, 230, 218, 9, 63, 221, 148,
+	135, 92, 131, 2, 205, 74, 144, 51, 115, 103, 246, 243, 157, 127, 191, 226,
+	82, 155, 216, 38, 200, 55, 198, 59, 129, 150, 111, 75, 19, 190, 99, 46,
+	233, 121, 167, 140, 159, 110, 188, 142, 41, 245, 249, 182, 47, 253, 180, 89,
+	120, 152, 6, 106, 231, 70, 113, 186, 212, 37, 171, 66, 136, 162, 141, 250,
+	114, 7, 185, 85, 248, 238, 172, 10, 54, 73, 42, 104, 60, 56, 241, 164,
+	64, 40, 211, 123, 187, 201, 67, 193, 21, 227, 173, 244, 119, 199, 128, 158,
+}
@@ -2,11 +2,13 @@ package algos
 
 import (
 	"bytes"
-	"crypto/rand"
+	"crypto/subtle"
 	"encoding/binary"
-	"encoding/hex"
 	"errors"
-	"log"
+	"fmt"
+	"io"
+	"math/big"
+	"math/bits"
 	"sync"
 )
 
@@ -20,6 +22,8 @@ const (
 	OFB
 	CTR
 	RandomDelta
+	GCM
+	CFB8
 )
 
 type PaddingMode int
@@ -29,8 +33,39 @@ const (
 	ANSI_X_923
 	PKCS7
 	ISO_10126
+	NoPadding
+	ISO_7816_4
+	ZerosLength
 )
 
+func init() {
+	for _, m := range []ModeInfo{
+		{Name: "ECB", DisplayName: "ECB", Mode: ECB},
+		{Name: "CBC", DisplayName: "CBC", Mode: CBC},
+		{Name: "PCBC", DisplayName: "PCBC", Mode: PCBC},
+		{Name: "CFB", DisplayName: "CFB", Mode: CFB, Stream: true},
+		{Name: "CFB8", DisplayName: "CFB-8", Mode: CFB8, Stream: true},
+		{Name: "OFB", DisplayName: "OFB", Mode: OFB, Stream: true},
+		{Name: "CTR", DisplayName: "CTR", Mode: CTR, Stream: true},
+		{Name: "RandomDelta", DisplayName: "RandomDelta", Mode: RandomDelta},
+		{Name: "GCM", DisplayName: "GCM (AEAD)", Mode: GCM, BlockSizes: []int{gcmBlockSize}, Stream: true, AEAD: true},
+	} {
+		RegisterMode(m)
+	}
+
+	for _, p := range []PaddingInfo{
+		{Name: "Zeros", DisplayName: "Zeros", Padding: Zeros},
+		{Name: "ANSIX923", DisplayName: "ANSI X.923", Padding: ANSI_X_923},
+		{Name: "PKCS7", DisplayName: "PKCS7", Padding: PKCS7},
+		{Name: "ISO10126", DisplayName: "ISO 10126", Padding: ISO_10126},
+		{Name: "None", DisplayName: "None", Padding: NoPadding},
+		{Name: "ISO78164", DisplayName: "ISO/IEC 7816-4", Padding: ISO_7816_4},
+		{Name: "ZerosLength", DisplayName: "Zeros + length", Padding: ZerosLength},
+	} {
+		RegisterPadding(p)
+	}
+}
+
 type EncryptionContext struct {
 	Key            []byte
 	roundKeys      [][]byte
@@ -40,10 +75,15 @@ type EncryptionContext struct {
 	AdditionalArgs []interface{}
 	Cipher         Cipher
 	expanderKey    KeyExpander
+	random         io.Reader
+	delta          *big.Int // шаг RandomDelta текущего сообщения
+	Parallel       ParallelConfig
 	mu             sync.Mutex
 }
 
-func NewEncryptionContext(key []byte, mode EncryptionMode, padding PaddingMode, iv []byte, cipher Cipher, expanderKey KeyExpander, additionalArgs ...interface{}) *EncryptionContext {
+// NewEncryptionContext задаёт шифру ключ; ключ неподходящей длины — ошибка, а не контекст
+// с пустым расписанием ключей
+func NewEncryptionContext(key []byte, mode EncryptionMode, padding PaddingMode, iv []byte, cipher Cipher, expanderKey KeyExpander, additionalArgs ...interface{}) (*EncryptionContext, error) {
 
 	var roundKeys [][]byte
 	if rc5, ok := cipher.(*RC5); ok {
@@ -52,6 +92,9 @@ func NewEncryptionContext(key []byte, mode EncryptionMode, padding PaddingMode,
 	if tw, ok := cipher.(*Twofish); ok {
 		roundKeys = convertUint32ToByteSlices(tw.subKeys[:])
 	}
+	if len(iv) == 0 {
+		iv = make([]byte, cipher.BlockSize())
+	}
 	ctx := &EncryptionContext{
 		Cipher:         cipher,
 		Key:            key,
@@ -61,13 +104,14 @@ func NewEncryptionContext(key []byte, mode EncryptionMode, padding PaddingMode,
 		AdditionalArgs: additionalArgs,
 		expanderKey:    expanderKey,
 		roundKeys:      roundKeys,
+		Parallel:       DefaultParallelConfig,
 	}
 
 	if err := ctx.CipherKey(key); err != nil {
-		log.Printf("failed to set context cipher key: %v", err)
+		return nil, fmt.Errorf("failed to set context cipher key: %w", err)
 	}
 
-	return ctx
+	return ctx, nil
 }
 
 func convertUint64ToByteSlices(keys []uint64) [][]byte {
@@ -91,6 +135,7 @@ func convertUint32ToByteSlices(keys []uint32) [][]byte {
 
 type Cipher interface {
 	CipherKey(key []byte) error
+	BlockSize() int
 	Encrypt(inputBlock []byte) ([]byte, error)
 	Decrypt(inputBlock []byte) ([]byte, error)
 }
@@ -104,7 +149,10 @@ func (r *RC5) ExpandKey(key []byte) ([][]byte, error) {
 		return nil, errors.New("key can not be empty")
 	}
 
-	expandedKeys := r.keyExpansion(key)
+	if err := r.CipherKey(key); err != nil {
+		return nil, err
+	}
+	expandedKeys := r.keys
 
 	roundKeys := make([][]byte, len(expandedKeys))
 	for i, k := range expandedKeys {
@@ -119,9 +167,9 @@ func (tf *Twofish) ExpandKey(key []byte) ([][]byte, error) {
 		return nil, errors.New("key can not be empty")
 	}
 
-	// tf.key = key
-	// tf.keyLength = len(key)
-	// tf.generateSubKeys()
+	if err := tf.CipherKey(key); err != nil {
+		return nil, err
+	}
 
 	roundKeys := make([][]byte, len(tf.subKeys))
 	for i, k := range tf.subKeys {
@@ -132,6 +180,39 @@ func (tf *Twofish) ExpandKey(key []byte) ([][]byte, error) {
 	return roundKeys, nil
 }
 
+func (s *Serpent) ExpandKey(key []byte) ([][]byte, error) {
+	if len(key) < 1 {
+		return nil, errors.New("key can not be empty")
+	}
+
+	if err := s.CipherKey(key); err != nil {
+		return nil, err
+	}
+
+	roundKeys := make([][]byte, len(s.subKeys))
+	for i, k := range s.subKeys {
+		roundKeys[i] = make([]byte, 16)
+		for j, word := range k {
+			binary.LittleEndian.PutUint32(roundKeys[i][4*j:], word)
+		}
+	}
+
+	return roundKeys, nil
+}
+
+func (c *Camellia) ExpandKey(key []byte) ([][]byte, error) {
+	if len(key) < 1 {
+		return nil, errors.New("key can not be empty")
+	}
+
+	if err := c.CipherKey(key); err != nil {
+		return nil, err
+	}
+
+	subKeys := append(append(c.kw[:], c.k[:c.rounds()]...), c.ke[:c.flLayers()]...)
+	return convertUint64ToByteSlices(subKeys), nil
+}
+
 func (ctx *EncryptionContext) CipherKey(key []byte) error {
 	if ctx.expanderKey == nil {
 		return errors.New("invalid KeyExpander")
@@ -146,8 +227,12 @@ func (ctx *EncryptionContext) CipherKey(key []byte) error {
 	return nil
 }
 
-func (ctx *EncryptionContext) addPadding(input []byte, paddingMode PaddingMode) ([]byte, error) {
-	blockSize := len(ctx.IV)
+// addPadding дополняет последнюю порцию сообщения; messageLen — длина всего сообщения,
+// input при потоковом шифровании бывает только его хвостом
+func (ctx *EncryptionContext) addPadding(input []byte, paddingMode PaddingMode, messageLen int) ([]byte, error) {
+	blockSize := ctx.Cipher.BlockSize()
+	// набивка дописывается через append: ограничиваем ёмкость, чтобы не испортить массив вызывающего
+	input = input[:len(input):len(input)]
 	paddingNeeded := blockSize - len(input)
 	if paddingNeeded == 0 {
 		paddingNeeded = blockSize
@@ -161,22 +246,41 @@ func (ctx *EncryptionContext) addPadding(input []byte, paddingMode PaddingMode)
 	case ANSI_X_923:
 		return ansix923Padding(input, blockSize), nil //done
 	case ISO_10126:
-		return iso10126Padding(input, blockSize) //crash
+		return iso10126Padding(input, blockSize, ctx.random)
+	case ISO_7816_4:
+		return iso7816Padding(input, blockSize), nil
+	case ZerosLength:
+		return zeroLengthPadding(input, blockSize, messageLen), nil
+	case NoPadding:
+		return input, nil
 	default:
 		return nil, errors.New("error of applying padding mode")
 	}
 }
 
-func (ctx *EncryptionContext) removePadding(input []byte, paddingMode PaddingMode) ([]byte, error) {
+// ErrDecrypt — единственная ошибка неверной набивки или длины шифртекста: по ней нельзя
+// понять, что именно не сошлось
+var ErrDecrypt = errors.New("decryption failed")
+
+// removePadding снимает набивку с конца сообщения; offset — сколько байтов открытого
+// текста уже отдано до input (при потоковой расшифровке)
+func (ctx *EncryptionContext) removePadding(input []byte, paddingMode PaddingMode, offset int) ([]byte, error) {
+	blockSize := ctx.Cipher.BlockSize()
 	switch paddingMode {
 	case Zeros:
 		return zeroUnpadding(input), nil
 	case PKCS7:
-		return pkcs7Unpadding(input)
+		return pkcs7Unpadding(input, blockSize)
 	case ANSI_X_923:
-		return ansix923Unpadding(input)
+		return ansix923Unpadding(input, blockSize)
 	case ISO_10126:
-		return iso10126Unpadding(input)
+		return iso10126Unpadding(input, blockSize)
+	case ISO_7816_4:
+		return iso7816Unpadding(input, blockSize)
+	case ZerosLength:
+		return zeroLengthUnpadding(input, blockSize, offset)
+	case NoPadding:
+		return input, nil
 	default:
 		return nil, errors.New("error of removing padding mode")
 	}
@@ -202,15 +306,8 @@ func pkcs7Padding(input []byte, blockSize int) []byte {
 	return append(input, pad...)
 }
 
-func pkcs7Unpadding(input []byte) ([]byte, error) {
-	if len(input) == 0 {
-		return nil, errors.New("input is empty")
-	}
-	padSize := int(input[len(input)-1])
-	if padSize > len(input) {
-		return nil, errors.New("invalid padding size")
-	}
-	return input[:len(input)-padSize], nil
+func pkcs7Unpadding(input []byte, blockSize int) ([]byte, error) {
+	return unpadLastBlock(input, blockSize, func(padSize int) byte { return byte(padSize) })
 }
 
 func ansix923Padding(input []byte, blockSize int) []byte {
@@ -226,104 +323,262 @@ func ansix923Padding(input []byte, blockSize int) []byte {
 	return append(input, padding...)
 }
 
-func ansix923Unpadding(input []byte) ([]byte, error) {
-	if len(input) == 0 {
-		return nil, errors.New("input is empty")
-	}
-	blockSize := int(input[len(input)-1])
-	if blockSize > len(input) || blockSize == 0 {
-		return nil, errors.New("invalid padding size")
-	}
-	for _, v := range input[len(input)-blockSize : len(input)-1] {
-		if v != 0 {
-			return nil, errors.New("invalid ansi_X_923 padding")
-		}
-	}
-	return input[:len(input)-blockSize], nil
+func ansix923Unpadding(input []byte, blockSize int) ([]byte, error) {
+	return unpadLastBlock(input, blockSize, func(int) byte { return 0 })
 }
 
-func iso10126Padding(input []byte, blockSize int) ([]byte, error) {
+func iso10126Padding(input []byte, blockSize int, random io.Reader) ([]byte, error) {
 	paddingSize := blockSize - len(input)%blockSize
 	if paddingSize == 0 {
 		paddingSize = blockSize
 	}
-	padding := make([]byte, paddingSize-1)
-	_, err := rand.Read(padding)
+	padding, err := randomBytes(random, paddingSize-1)
 	if err != nil {
 		return nil, errors.New("error appliyng random padding iso10126")
 	}
 	return append(input, append(padding, byte(paddingSize))...), nil
 }
 
-func iso10126Unpadding(input []byte) ([]byte, error) {
-	if len(input) == 0 {
-		return nil, errors.New("input is empty")
+func iso10126Unpadding(input []byte, blockSize int) ([]byte, error) {
+	// байты набивки ISO 10126 случайные, проверяется только длина
+	return unpadLastBlock(input, blockSize, nil)
+}
+
+// iso7816Padding дописывает байт 0x80 и нули до границы блока (ISO/IEC 7816-4)
+func iso7816Padding(input []byte, blockSize int) []byte {
+	padSize := blockSize - len(input)%blockSize
+	padding := make([]byte, padSize)
+	padding[0] = 0x80
+	return append(input, padding...)
+}
+
+// iso7816Unpadding ищет с конца последнего блока маркер 0x80 после нулей. Блок
+// просматривается целиком при любом содержимом, ошибка — всегда ErrDecrypt.
+func iso7816Unpadding(input []byte, blockSize int) ([]byte, error) {
+	if blockSize <= 0 || len(input) == 0 || len(input)%blockSize != 0 {
+		return nil, ErrDecrypt
+	}
+	last := input[len(input)-blockSize:]
+
+	padSize, found, good := 0, 0, 0
+	for i := blockSize - 1; i >= 0; i-- {
+		isZero := subtle.ConstantTimeByteEq(last[i], 0)
+		isMarker := subtle.ConstantTimeByteEq(last[i], 0x80)
+		// первый ненулевой байт с конца должен быть маркером
+		marker := (1 - found) & isMarker
+		other := (1 - found) & (1 - isZero) & (1 - isMarker)
+		padSize = subtle.ConstantTimeSelect(marker, blockSize-i, padSize)
+		good |= marker
+		found |= marker | other
 	}
-	blockSize := int(input[len(input)-1])
-	if blockSize > len(input) || blockSize == 0 {
-		return nil, errors.New("invalid padding size")
+	if good != 1 {
+		return nil, ErrDecrypt
 	}
-	return input[:len(input)-blockSize], nil
+	return input[:len(input)-padSize], nil
+}
+
+// zeroLengthTrailer — размер поля с длиной открытого текста в конце набивки ZerosLength
+const zeroLengthTrailer = 8
+
+// zeroLengthPadding дописывает нули и длину открытого текста (8 байт, big-endian),
+// поэтому в отличие от Zeros нули в конце данных не теряются
+func zeroLengthPadding(input []byte, blockSize, messageLen int) []byte {
+	zeros := (blockSize - (len(input)+zeroLengthTrailer)%blockSize) % blockSize
+	padding := make([]byte, zeros+zeroLengthTrailer)
+	binary.BigEndian.PutUint64(padding[zeros:], uint64(messageLen))
+	return append(input, padding...)
+}
+
+// zeroLengthUnpadding проверяет записанную длину и нули перед ней без ветвлений по данным;
+// offset — сколько байтов сообщения стоит перед input
+func zeroLengthUnpadding(input []byte, blockSize, offset int) ([]byte, error) {
+	if blockSize <= 0 || len(input) < zeroLengthTrailer || len(input)%blockSize != 0 {
+		return nil, ErrDecrypt
+	}
+	body := len(input) - zeroLengthTrailer
+	length, beforeInput := bits.Sub64(binary.BigEndian.Uint64(input[body:]), uint64(offset), 0)
+
+	// нулей должно быть меньше блока: 0 <= body-length < blockSize
+	zeros, tooLong := bits.Sub64(uint64(body), length, 0)
+	_, fits := bits.Sub64(zeros, uint64(blockSize), 0)
+	good := int(fits &^ tooLong &^ beforeInput)
+	zerosCount := int(zeros & -uint64(good))
+
+	for d := 1; d < blockSize && d <= body; d++ {
+		inPadding := subtle.ConstantTimeLessOrEq(d, zerosCount)
+		good &= subtle.ConstantTimeSelect(inPadding, subtle.ConstantTimeByteEq(input[body-d], 0), 1)
+	}
+	if good != 1 {
+		return nil, ErrDecrypt
+	}
+	return input[:body-zerosCount], nil
+}
+
+// unpadLastBlock снимает набивку, длина которой записана в последнем байте, а fill задаёт
+// остальные её байты (nil — не проверять). Последний блок проверяется целиком без ветвлений
+// по его содержимому, и любая ошибка одинакова — ErrDecrypt, иначе расшифровка становится
+// оракулом набивки.
+func unpadLastBlock(input []byte, blockSize int, fill func(padSize int) byte) ([]byte, error) {
+	if blockSize <= 0 || blockSize > 255 || len(input) == 0 || len(input)%blockSize != 0 {
+		return nil, ErrDecrypt
+	}
+	last := input[len(input)-blockSize:]
+	padSize := int(last[blockSize-1])
+
+	good := subtle.ConstantTimeLessOrEq(1, padSize) & subtle.ConstantTimeLessOrEq(padSize, blockSize)
+	if fill != nil {
+		want := fill(padSize)
+		for i := 0; i < blockSize-1; i++ {
+			// i-й байт блока входит в набивку, если до конца блока от него не больше padSize байтов
+			inPadding := subtle.ConstantTimeLessOrEq(blockSize-i, padSize)
+			good &= subtle.ConstantTimeSelect(inPadding, subtle.ConstantTimeByteEq(last[i], want), 1)
+		}
+	}
+	if good != 1 {
+		return nil, ErrDecrypt
+	}
+	return input[:len(input)-padSize], nil
 }
 
 func (ctx *EncryptionContext) Encrypt(input []byte) ([]byte, error) {
+	return ctx.EncryptWithAAD(input, nil)
+}
+
+func (ctx *EncryptionContext) Decrypt(input []byte) ([]byte, error) {
+	return ctx.DecryptWithAAD(input, nil)
+}
+
+// EncryptWithAAD шифрует input; aad учитывается только в аутентифицированном режиме GCM
+func (ctx *EncryptionContext) EncryptWithAAD(input, aad []byte) ([]byte, error) {
 	ctx.mu.Lock()
 	defer ctx.mu.Unlock()
 
-	paddedData, err := ctx.addPadding(input, ctx.Padding)
+	if ctx.Mode == GCM {
+		nonce, err := randomBytes(ctx.random, gcmNonceSize)
+		if err != nil {
+			return nil, errors.New("failed to generate nonce")
+		}
+		encryptedData, err := encryptWithGCM(ctx, nonce, input, aad)
+		if err != nil {
+			return nil, err
+		}
+		return sealEnvelope(nonce, encryptedData), nil
+	}
+
+	iv, err := randomBytes(ctx.random, ctx.Cipher.BlockSize())
+	if err != nil {
+		return nil, errors.New("failed to generate IV")
+	}
+	ctx.startChain(iv)
+
+	paddedData, err := ctx.addPadding(input, ctx.Padding, len(input))
+	if err != nil {
+		return nil, err
+	}
+
+	encryptedData, err := ctx.encryptBlocks(paddedData)
+	if err != nil {
+		return nil, err
+	}
+
+	return sealEnvelope(iv, encryptedData), nil
+}
+
+// DecryptWithAAD расшифровывает конверт; в режиме GCM при несовпадении тега возвращает ErrAuthentication
+func (ctx *EncryptionContext) DecryptWithAAD(input, aad []byte) ([]byte, error) {
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+
+	iv, ciphertext, err := openEnvelope(input)
+	if err != nil {
+		return nil, err
+	}
+	if ctx.Mode == GCM {
+		return decryptWithGCM(ctx, iv, ciphertext, aad)
+	}
+	if len(iv) != ctx.Cipher.BlockSize() {
+		return nil, ErrInvalidEnvelope
+	}
+	ctx.startChain(iv)
+
+	return ctx.decryptFinal(ciphertext, 0)
+}
+
+// decryptFinal расшифровывает последнюю порцию сообщения и снимает набивку. Длина, не кратная
+// блоку, и неверная набивка неотличимы: обе дают ErrDecrypt
+func (ctx *EncryptionContext) decryptFinal(ciphertext []byte, offset int) ([]byte, error) {
+	if ctx.Padding != NoPadding && len(ciphertext)%ctx.Cipher.BlockSize() != 0 {
+		return nil, ErrDecrypt
+	}
+	decryptedData, err := ctx.decryptBlocks(ciphertext)
 	if err != nil {
 		return nil, err
 	}
 
+	return ctx.removePadding(decryptedData, ctx.Padding, offset)
+}
+
+// maxPaddingSize — сколько байтов в конце сообщения может занимать набивка
+func (ctx *EncryptionContext) maxPaddingSize() int {
+	if ctx.Padding == ZerosLength {
+		return ctx.Cipher.BlockSize() - 1 + zeroLengthTrailer
+	}
+	return ctx.Cipher.BlockSize()
+}
+
+// startChain задаёт IV нового сообщения и сбрасывает состояние, выведенное из прежнего IV
+func (ctx *EncryptionContext) startChain(iv []byte) {
+	ctx.IV = iv
+	ctx.delta = nil
+}
+
+// encryptBlocks шифрует данные, кратные размеру блока, продолжая сцепление с ctx.IV;
+// после вызова ctx.IV содержит состояние для следующей порции
+func (ctx *EncryptionContext) encryptBlocks(input []byte) ([]byte, error) {
 	switch ctx.Mode {
 	case CBC:
-		return encryptWithCBC(ctx, paddedData)
+		return encryptWithCBC(ctx, input)
 	case ECB:
-		return encryptWithECB(ctx, paddedData)
+		return encryptWithECB(ctx, input)
 	case PCBC:
-		return encryptWithPCBC(ctx, paddedData)
+		return encryptWithPCBC(ctx, input)
 	case CFB:
-		return encryptWithCFB(ctx, paddedData)
+		return encryptWithCFB(ctx, input)
+	case CFB8:
+		return encryptWithCFB8(ctx, input)
 	case OFB:
-		return encryptWithOFB(ctx, paddedData)
+		return encryptWithOFB(ctx, input)
 	case CTR:
-		return encryptWithCTR(ctx, paddedData)
+		return encryptWithCTR(ctx, input)
 	case RandomDelta:
-		return encryptWithRandomDelta(ctx, paddedData)
+		return encryptWithRandomDelta(ctx, input)
 	default:
 		return nil, errors.New("invalid cipher mode")
 	}
 }
 
-func (ctx *EncryptionContext) Decrypt(input []byte) ([]byte, error) {
-	ctx.mu.Lock()
-	defer ctx.mu.Unlock()
-	var decryptedData []byte
-	var err error
-
+// decryptBlocks — обратная к encryptBlocks операция; дополнение не снимается
+func (ctx *EncryptionContext) decryptBlocks(input []byte) ([]byte, error) {
 	switch ctx.Mode {
 	case CBC:
-		decryptedData, err = decryptWithCBC(ctx, input)
+		return decryptWithCBC(ctx, input)
 	case ECB:
-		decryptedData, err = decryptWithECB(ctx, input)
+		return decryptWithECB(ctx, input)
 	case PCBC:
-		decryptedData, err = decryptWithPCBC(ctx, input)
+		return decryptWithPCBC(ctx, input)
 	case CFB:
-		decryptedData, err = decryptWithCFB(ctx, input)
+		return decryptWithCFB(ctx, input)
+	case CFB8:
+		return decryptWithCFB8(ctx, input)
 	case OFB:
-		decryptedData, err = decryptWithOFB(ctx, input)
+		return decryptWithOFB(ctx, input)
 	case CTR:
-		decryptedData, err = decryptWithCTR(ctx, input)
+		return decryptWithCTR(ctx, input)
 	case RandomDelta:
-		decryptedData, err = decryptWithRandomDelta(ctx, input)
+		return decryptWithRandomDelta(ctx, input)
 	default:
 		return nil, errors.New("invalid cipher mode")
 	}
-	if err != nil {
-		return nil, err
-	}
-
-	return decryptedData, nil
 }
 
 func xorBlocks(block1, block2 []byte) []byte {
@@ -335,12 +590,10 @@ func xorBlocks(block1, block2 []byte) []byte {
 }
 
 func encryptWithCBC(ctx *EncryptionContext, input []byte) ([]byte, error) {
-	log.Printf("Данные перед шифрованием в CBC: %q\n", input)
-
-	blockSize := len(ctx.IV)
-	// if blockSize == 0 || len(input)%blockSize != 0 {
-	// 	return nil, errors.New("input must be a multiple of the block size")
-	// }
+	blockSize := ctx.Cipher.BlockSize()
+	if blockSize == 0 || len(input)%blockSize != 0 {
+		return nil, errors.New("input must be a multiple of the block size")
+	}
 
 	numBlocks := len(input) / blockSize
 	encrypted := make([]byte, len(input))
@@ -359,83 +612,91 @@ func encryptWithCBC(ctx *EncryptionContext, input []byte) ([]byte, error) {
 		copy(encrypted[i*blockSize:], cipherBlock)
 		previousBlock = cipherBlock
 	}
-	log.Printf("Зашифрованные данные (CBC): %q\n", encrypted)
+	ctx.IV = append([]byte(nil), previousBlock...)
 	return encrypted, nil
 }
 
 func decryptWithCBC(ctx *EncryptionContext, input []byte) ([]byte, error) {
-	blockSize := len(ctx.IV)
+	blockSize := ctx.Cipher.BlockSize()
 	if blockSize == 0 || len(input)%blockSize != 0 {
 		return nil, errors.New("input must be a multiple of the block size")
 	}
 
-	numBlocks := len(input) / blockSize
 	decrypted := make([]byte, len(input))
-	previousBlock := ctx.IV
-
-	for i := 0; i < numBlocks; i++ {
-		block := input[i*blockSize : (i+1)*blockSize]
+	iv := ctx.IV
 
+	err := ctx.processBlocks(decrypted, input, func(i int, dst, block []byte) error {
 		plainBlock, err := ctx.Cipher.Decrypt(block)
 		if err != nil {
-			return nil, err
+			return err
 		}
 
-		xoredBlock := xorBlocks(plainBlock, previousBlock)
-		copy(decrypted[i*blockSize:], xoredBlock)
-
-		previousBlock = block
+		previousBlock := iv
+		if i > 0 {
+			previousBlock = input[(i-1)*blockSize : i*blockSize]
+		}
+		copy(dst, xorBlocks(plainBlock, previousBlock))
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return ctx.removePadding(decrypted, ctx.Padding)
+	if len(input) > 0 {
+		ctx.IV = append([]byte(nil), input[len(input)-blockSize:]...)
+	}
+	return decrypted, nil
 }
 
 func encryptWithECB(ctx *EncryptionContext, input []byte) ([]byte, error) {
 	// log.Printf("Данные перед шифрованием в ECB: %q\n", input)
-	blockSize := len(ctx.IV)
+	blockSize := ctx.Cipher.BlockSize()
 	if blockSize == 0 || len(input)%blockSize != 0 {
 		return nil, errors.New("input must be a multiple of the block size")
 	}
 
-	numBlocks := len(input) / blockSize
 	encrypted := make([]byte, len(input))
 
-	for i := 0; i < numBlocks; i++ {
-		block := input[i*blockSize : (i+1)*blockSize]
+	err := ctx.processBlocks(encrypted, input, func(_ int, dst, block []byte) error {
 		cipherBlock, err := ctx.Cipher.Encrypt(block)
 		if err != nil {
-			return nil, err
+			return err
 		}
-		copy(encrypted[i*blockSize:], cipherBlock)
+		copy(dst, cipherBlock)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return encrypted, nil
 }
 
 func decryptWithECB(ctx *EncryptionContext, input []byte) ([]byte, error) {
-	blockSize := len(ctx.IV)
+	blockSize := ctx.Cipher.BlockSize()
 	if len(input)%blockSize != 0 {
 		return nil, errors.New("input length must be a multiple of the block size")
 	}
 
-	numBlocks := len(input) / blockSize
 	decrypted := make([]byte, len(input))
 
-	for i := 0; i < numBlocks; i++ {
-		block := input[i*blockSize : (i+1)*blockSize]
+	err := ctx.processBlocks(decrypted, input, func(_ int, dst, block []byte) error {
 		plainBlock, err := ctx.Cipher.Decrypt(block)
 		if err != nil {
-			return nil, err
+			return err
 		}
-		copy(decrypted[i*blockSize:], plainBlock)
+		copy(dst, plainBlock)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return ctx.removePadding(decrypted, ctx.Padding)
+	return decrypted, nil
 }
 
 func encryptWithPCBC(ctx *EncryptionContext, input []byte) ([]byte, error) {
-	log.Printf("Данные перед шифрованием в PCBC: %q\n", input)
-	blockSize := len(ctx.IV)
+	blockSize := ctx.Cipher.BlockSize()
 	if len(input)%blockSize != 0 {
 		return nil, errors.New("input length must be a multiple of the block size")
 	}
@@ -458,11 +719,12 @@ func encryptWithPCBC(ctx *EncryptionContext, input []byte) ([]byte, error) {
 		previousCipherBlock = xorBlocks(plainBlock, cipherBlock)
 	}
 
+	ctx.IV = previousCipherBlock
 	return encrypted, nil
 }
 
 func decryptWithPCBC(ctx *EncryptionContext, input []byte) ([]byte, error) {
-	blockSize := len(ctx.IV)
+	blockSize := ctx.Cipher.BlockSize()
 	if len(input)%blockSize != 0 {
 		return nil, errors.New("input length must be a multiple of the block size")
 	}
@@ -485,220 +747,229 @@ func decryptWithPCBC(ctx *EncryptionContext, input []byte) ([]byte, error) {
 		previousCipherBlock = xorBlocks(plainBlock, cipherBlock)
 	}
 
-	return ctx.removePadding(decrypted, ctx.Padding)
+	ctx.IV = previousCipherBlock
+	return decrypted, nil
 }
 
 func encryptWithCFB(ctx *EncryptionContext, input []byte) ([]byte, error) {
-	log.Printf("Данные перед шифрованием в CFB: %q\n", input)
-	blockSize := len(ctx.IV)
-	if len(input)%blockSize != 0 {
-		return nil, errors.New("input length must be a multiple of the block size")
-	}
+	blockSize := ctx.Cipher.BlockSize()
 
-	numBlocks := len(input) / blockSize
 	encrypted := make([]byte, len(input))
 	feedbackBlock := ctx.IV
 
-	for i := 0; i < numBlocks; i++ {
-		plainBlock := input[i*blockSize : (i+1)*blockSize]
+	// последний сегмент может быть неполным: он просто обрезает гамму
+	for offset := 0; offset < len(input); offset += blockSize {
+		end := min(offset+blockSize, len(input))
 
 		cipherFeedback, err := ctx.Cipher.Encrypt(feedbackBlock)
 		if err != nil {
 			return nil, err
 		}
 
-		cipherBlock := xorBlocks(plainBlock, cipherFeedback)
-		copy(encrypted[i*blockSize:], cipherBlock)
+		cipherBlock := xorBlocks(input[offset:end], cipherFeedback)
+		copy(encrypted[offset:], cipherBlock)
 
-		feedbackBlock = cipherFeedback
+		feedbackBlock = cipherBlock
 	}
 
+	ctx.IV = append([]byte(nil), feedbackBlock...)
 	return encrypted, nil
 }
 
 func decryptWithCFB(ctx *EncryptionContext, input []byte) ([]byte, error) {
-	blockSize := len(ctx.IV)
-	if len(input)%blockSize != 0 {
-		return nil, errors.New("input length must be a multiple of the block size")
-	}
+	blockSize := ctx.Cipher.BlockSize()
 
-	numBlocks := len(input) / blockSize
 	decrypted := make([]byte, len(input))
-	feedbackBlock := ctx.IV
+	iv := ctx.IV
 
-	for i := 0; i < numBlocks; i++ {
-		cipherBlock := input[i*blockSize : (i+1)*blockSize]
+	err := ctx.processBlocks(decrypted, input, func(i int, dst, cipherBlock []byte) error {
+		feedbackBlock := iv
+		if i > 0 {
+			feedbackBlock = input[(i-1)*blockSize : i*blockSize]
+		}
 
 		cipherFeedback, err := ctx.Cipher.Encrypt(feedbackBlock)
 		if err != nil {
-			return nil, err
+			return err
 		}
 
-		plainBlock := xorBlocks(cipherBlock, cipherFeedback)
-		copy(decrypted[i*blockSize:], plainBlock)
-
-		feedbackBlock = cipherBlock
-	}
-
-	return ctx.removePadding(decrypted, ctx.Padding)
-}
-
-func encryptWithOFB(ctx *EncryptionContext, input []byte) ([]byte, error) {
-	log.Printf("Данные перед шифрованием в OFB: %q\n", input)
-	blockSize := len(ctx.IV)
-	if len(input)%blockSize != 0 {
-		return nil, errors.New("input length must be a multiple of the block size")
-	}
-
-	_, err := ctx.addPadding(input, ctx.Padding)
+		copy(dst, xorBlocks(cipherBlock, cipherFeedback))
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	numBlocks := len(input) / blockSize
+	if len(input) >= blockSize {
+		ctx.IV = append([]byte(nil), input[len(input)-blockSize:]...)
+	}
+	return decrypted, nil
+}
+
+// CFB-8: сдвиговый регистр размером в блок, за шаг шифруется один байт
+func encryptWithCFB8(ctx *EncryptionContext, input []byte) ([]byte, error) {
 	encrypted := make([]byte, len(input))
-	feedbackBlock := ctx.IV
+	register := append([]byte(nil), ctx.IV...)
 
-	for i := 0; i < numBlocks; i++ {
-		feedbackBlock, err = ctx.Cipher.Encrypt(feedbackBlock)
+	for i := range input {
+		keystream, err := ctx.Cipher.Encrypt(register)
 		if err != nil {
 			return nil, err
 		}
 
-		plainBlock := input[i*blockSize : (i+1)*blockSize]
-		cipherBlock := xorBlocks(plainBlock, feedbackBlock)
-		copy(encrypted[i*blockSize:], cipherBlock)
-
+		encrypted[i] = input[i] ^ keystream[0]
+		register = append(register[1:], encrypted[i])
 	}
 
+	ctx.IV = register
 	return encrypted, nil
 }
 
-func decryptWithOFB(ctx *EncryptionContext, input []byte) ([]byte, error) {
-	blockSize := len(ctx.IV)
-	if len(input)%blockSize != 0 {
-		return nil, errors.New("input length must be a multiple of the block size")
-	}
-
-	numBlocks := len(input) / blockSize
+func decryptWithCFB8(ctx *EncryptionContext, input []byte) ([]byte, error) {
 	decrypted := make([]byte, len(input))
-	feedbackBlock := ctx.IV
+	register := append([]byte(nil), ctx.IV...)
 
-	for i := 0; i < numBlocks; i++ {
-		feedbackBlock, err := ctx.Cipher.Encrypt(feedbackBlock)
+	for i := range input {
+		keystream, err := ctx.Cipher.Encrypt(register)
 		if err != nil {
 			return nil, err
 		}
 
-		cipherBlock := input[i*blockSize : (i+1)*blockSize]
-		plainBlock := xorBlocks(cipherBlock, feedbackBlock)
-		copy(decrypted[i*blockSize:], plainBlock)
-
+		decrypted[i] = input[i] ^ keystream[0]
+		register = append(register[1:], input[i])
 	}
 
-	return ctx.removePadding(decrypted, ctx.Padding)
+	ctx.IV = register
+	return decrypted, nil
 }
 
-func encryptWithCTR(ctx *EncryptionContext, input []byte) ([]byte, error) {
-	log.Printf("Данные перед шифрованием в CTR: %q\n", input)
-	blockSize := len(ctx.IV)
-	if len(input)%blockSize != 0 {
-		return nil, errors.New("input length must be a multiple of the block size")
-	}
+func encryptWithOFB(ctx *EncryptionContext, input []byte) ([]byte, error) {
+	blockSize := ctx.Cipher.BlockSize()
 
-	numBlocks := len(input) / blockSize
 	encrypted := make([]byte, len(input))
+	feedbackBlock := ctx.IV
 
-	counter := append([]byte(nil), ctx.IV...)
-
-	for i := 0; i < numBlocks; i++ {
-		encryptedCounter, err := ctx.Cipher.Encrypt(counter)
+	for offset := 0; offset < len(input); offset += blockSize {
+		var err error
+		feedbackBlock, err = ctx.Cipher.Encrypt(feedbackBlock)
 		if err != nil {
 			return nil, err
 		}
 
-		plainBlock := input[i*blockSize : (i+1)*blockSize]
-		cipherBlock := xorBlocks(plainBlock, encryptedCounter)
-		copy(encrypted[i*blockSize:], cipherBlock)
-
-		counter = incrementCounter(counter)
+		end := min(offset+blockSize, len(input))
+		copy(encrypted[offset:], xorBlocks(input[offset:end], feedbackBlock))
 	}
 
+	ctx.IV = feedbackBlock
 	return encrypted, nil
 }
 
-func decryptWithCTR(ctx *EncryptionContext, input []byte) ([]byte, error) {
-	return encryptWithCTR(ctx, input)
+func decryptWithOFB(ctx *EncryptionContext, input []byte) ([]byte, error) {
+	return encryptWithOFB(ctx, input)
 }
 
-func incrementCounter(counter []byte) []byte {
-	for i := len(counter) - 1; i >= 0; i-- {
-		counter[i]++
-		if counter[i] != 0 {
-			break
+func encryptWithCTR(ctx *EncryptionContext, input []byte) ([]byte, error) {
+	blockSize := ctx.Cipher.BlockSize()
+
+	encrypted := make([]byte, len(input))
+	initialCounter := ctx.IV
+
+	err := ctx.processBlocks(encrypted, input, func(i int, dst, plainBlock []byte) error {
+		encryptedCounter, err := ctx.Cipher.Encrypt(addToCounter(initialCounter, i))
+		if err != nil {
+			return err
 		}
+
+		copy(dst, xorBlocks(plainBlock, encryptedCounter))
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
-	return counter
+
+	ctx.IV = addToCounter(initialCounter, (len(input)+blockSize-1)/blockSize)
+	return encrypted, nil
 }
 
+func decryptWithCTR(ctx *EncryptionContext, input []byte) ([]byte, error) {
+	return encryptWithCTR(ctx, input)
+}
+
+// RandomDelta: начальное значение R — случайный IV сообщения из заголовка конверта,
+// шаг delta выводится из R. Блок i перед шифрованием складывается по XOR с R + i*delta.
 func encryptWithRandomDelta(ctx *EncryptionContext, input []byte) ([]byte, error) {
-	log.Printf("Данные перед шифрованием в RandomDelta: %q\n", input)
-	blockSize := len(ctx.IV)
+	blockSize := ctx.Cipher.BlockSize()
 	if len(input)%blockSize != 0 {
 		return nil, errors.New("input length must be a multiple of the block size")
 	}
 
-	numBlocks := len(input) / blockSize
 	encrypted := make([]byte, len(input))
+	initial, delta := ctx.randomDeltaState()
 
-	deltaStr := "7e8f9d52b43c0818d5e8a09c72fbbacf"
-
-	delta, err := hex.DecodeString(deltaStr)
-	if err != nil {
-		return nil, err
-	}
-
-	for i := 0; i < numBlocks; i++ {
-		plainBlock := input[i*blockSize : (i+1)*blockSize]
-		blockWithDelta := xorBlocks(plainBlock, delta)
+	err := ctx.processBlocks(encrypted, input, func(i int, dst, plainBlock []byte) error {
+		blockWithDelta := xorBlocks(plainBlock, randomDeltaValue(initial, delta, i))
 
 		encryptedBlock, err := ctx.Cipher.Encrypt(blockWithDelta)
 		if err != nil {
-			return nil, err
+			return err
 		}
 
-		copy(encrypted[i*blockSize:], encryptedBlock)
+		copy(dst, encryptedBlock)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
+	ctx.IV = randomDeltaValue(initial, delta, len(input)/blockSize)
 	return encrypted, nil
 }
 
 func decryptWithRandomDelta(ctx *EncryptionContext, input []byte) ([]byte, error) {
-	blockSize := len(ctx.IV)
+	blockSize := ctx.Cipher.BlockSize()
 	if len(input)%blockSize != 0 {
 		return nil, errors.New("input length must be a multiple of the block size")
 	}
 
-	numBlocks := len(input) / blockSize
 	decrypted := make([]byte, len(input))
+	initial, delta := ctx.randomDeltaState()
 
-	deltaStr := "7e8f9d52b43c0818d5e8a09c72fbbacf"
+	err := ctx.processBlocks(decrypted, input, func(i int, dst, encryptedBlock []byte) error {
+		blockWithDelta, err := ctx.Cipher.Decrypt(encryptedBlock)
+		if err != nil {
+			return err
+		}
 
-	delta, err := hex.DecodeString(deltaStr)
+		copy(dst, xorBlocks(blockWithDelta, randomDeltaValue(initial, delta, i)))
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	for i := 0; i < numBlocks; i++ {
-		encryptedBlock := input[i*blockSize : (i+1)*blockSize]
-		blockWithDelta, err := ctx.Cipher.Decrypt(encryptedBlock)
-		if err != nil {
-			return nil, err
-		}
+	ctx.IV = randomDeltaValue(initial, delta, len(input)/blockSize)
+	return decrypted, nil
+}
 
-		plainBlock := xorBlocks(blockWithDelta, delta)
-		copy(decrypted[i*blockSize:], plainBlock)
+// randomDeltaState возвращает текущее значение и шаг. Шаг берётся из младшей половины
+// начального значения сообщения и принудительно делается нечётным, чтобы
+// последовательность R + i*delta не вырождалась и проходила все значения блока.
+func (ctx *EncryptionContext) randomDeltaState() ([]byte, *big.Int) {
+	if ctx.delta == nil {
+		half := ctx.IV[len(ctx.IV)/2:]
+		ctx.delta = new(big.Int).SetBytes(half)
+		ctx.delta.SetBit(ctx.delta, 0, 1)
 	}
+	return ctx.IV, ctx.delta
+}
 
-	return decrypted, nil
+// randomDeltaValue возвращает initial + i*delta по модулю 2^(8*len(initial))
+func randomDeltaValue(initial []byte, delta *big.Int, i int) []byte {
+	value := new(big.Int).Mul(delta, big.NewInt(int64(i)))
+	value.Add(value, new(big.Int).SetBytes(initial))
+
+	modulus := new(big.Int).Lsh(big.NewInt(1), uint(8*len(initial)))
+	value.Mod(value, modulus)
+
+	return value.FillBytes(make([]byte, len(initial)))
 }
@@ -0,0 +1,784 @@
+package algos
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Проверки соответствия: известные ответы из testdata, шифрование и расшифровка всех допустимых
+// сочетаний и эталонные конверты. Каждый вектор и каждое сочетание шифра, режима и набивки —
+// отдельный подтест, например go test ./algos -run 'TestRoundTrips/serpent_GCM'
+
+// modeVectorFile — набор векторов для режимов сцепления с фиксированными ключом и IV
+type modeVectorFile struct {
+	Source  string       `json:"source"`
+	Cipher  string       `json:"cipher"`
+	Vectors []modeVector `json:"vectors"`
+}
+
+type modeVector struct {
+	Name       string `json:"name"`
+	Mode       string `json:"mode"`
+	Key        string `json:"key"`
+	IV         string `json:"iv"`
+	Plaintext  string `json:"plaintext"`
+	Ciphertext string `json:"ciphertext"`
+}
+
+// gcmVectorFile — известные ответы GCM: шифртекст и тег отдельно, IV из 96 бит
+type gcmVectorFile struct {
+	Source  string      `json:"source"`
+	Cipher  string      `json:"cipher"`
+	Vectors []gcmVector `json:"vectors"`
+}
+
+type gcmVector struct {
+	Name       string `json:"name"`
+	Key        string `json:"key"`
+	IV         string `json:"iv"`
+	AAD        string `json:"aad"`
+	Plaintext  string `json:"plaintext"`
+	Ciphertext string `json:"ciphertext"`
+	Tag        string `json:"tag"`
+}
+
+// blockVectorFile — известные ответы для одного блока шифра
+type blockVectorFile struct {
+	Source  string        `json:"source"`
+	Vectors []blockVector `json:"vectors"`
+}
+
+type blockVector struct {
+	Name       string `json:"name"`
+	Algorithm  string `json:"algorithm"`
+	Key        string `json:"key"`
+	Plaintext  string `json:"plaintext"`
+	Ciphertext string `json:"ciphertext"`
+}
+
+// envelopeFile фиксирует формат сообщения на проводе: при одинаковых ключе и
+// случайных байтах каждое сочетание обязано дать ровно этот конверт
+type envelopeFile struct {
+	Source    string           `json:"source"`
+	Key       string           `json:"key"`
+	Random    string           `json:"random"`
+	AAD       string           `json:"aad"`
+	Plaintext string           `json:"plaintext"`
+	Vectors   []envelopeVector `json:"vectors"`
+}
+
+type envelopeVector struct {
+	Algorithm string `json:"algorithm"`
+	Mode      string `json:"mode"`
+	Padding   string `json:"padding"`
+	Envelope  string `json:"envelope"`
+}
+
+func TestModeVectors(t *testing.T) {
+	var file modeVectorFile
+	loadTestdataT(t, "testdata/sp800-38a.json", &file)
+	for _, v := range file.Vectors {
+		t.Run(v.Name, func(t *testing.T) {
+			checkConformance(t, checkModeVector(file.Cipher, v))
+		})
+	}
+}
+
+func TestGCMVectors(t *testing.T) {
+	var file gcmVectorFile
+	loadTestdataT(t, "testdata/gcm.json", &file)
+	for _, v := range file.Vectors {
+		t.Run(v.Name, func(t *testing.T) {
+			checkConformance(t, checkGCMVector(file.Cipher, v))
+		})
+	}
+}
+
+func TestBlockVectors(t *testing.T) {
+	for _, path := range []string{"testdata/rc5.json", "testdata/twofish.json", "testdata/serpent.json", "testdata/camellia.json"} {
+		var file blockVectorFile
+		loadTestdataT(t, path, &file)
+		for _, v := range file.Vectors {
+			t.Run(v.Name, func(t *testing.T) {
+				checkConformance(t, checkBlockVector(v))
+			})
+		}
+	}
+}
+
+func TestRegistrySizes(t *testing.T) {
+	for _, info := range Ciphers() {
+		variants := append([]CipherVariant{{Name: info.Name, BlockSize: info.BlockSize, KeySizes: info.KeySizes}}, info.Variants...)
+		for _, v := range variants {
+			t.Run(v.Name, func(t *testing.T) {
+				checkConformance(t, checkRegistrySizes(v))
+			})
+		}
+	}
+}
+
+func TestRoundTrips(t *testing.T) {
+	for _, cc := range acceptedCombinationsT(t) {
+		t.Run(cc.String(), func(t *testing.T) {
+			checkConformance(t, checkRoundTrip(cc))
+		})
+	}
+}
+
+func TestEnvelopeVectors(t *testing.T) {
+	var file envelopeFile
+	loadTestdataT(t, "testdata/envelopes.json", &file)
+	fields, err := decodeHex(file.Key, file.Random, file.AAD, file.Plaintext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, random, aad, plaintext := fields[0], fields[1], fields[2], fields[3]
+
+	covered := make(map[string]bool, len(file.Vectors))
+	for _, v := range file.Vectors {
+		name := v.Algorithm + " " + v.Mode + " " + v.Padding
+		covered[name] = true
+		t.Run(name, func(t *testing.T) {
+			checkConformance(t, checkEnvelopeVector(v, key, random, aad, plaintext))
+		})
+	}
+
+	// новое сочетание без эталона тоже ошибка: формат должен быть зафиксирован
+	for _, cc := range acceptedCombinationsT(t) {
+		if !covered[cc.String()] {
+			t.Errorf("no golden envelope for %s", cc)
+		}
+	}
+}
+
+// checkConformance проваливает подтест, если проверка вернула ошибку
+func checkConformance(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func loadTestdataT(t *testing.T, path string, v interface{}) {
+	t.Helper()
+	if err := loadTestdata(path, v); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func acceptedCombinationsT(t *testing.T) []conformanceCombination {
+	t.Helper()
+	combinations, err := acceptedCombinations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return combinations
+}
+
+func checkModeVector(cipherName string, v modeVector) error {
+	mode, ok := LookupMode(v.Mode)
+	if !ok {
+		return fmt.Errorf("unknown mode %q", v.Mode)
+	}
+	fields, err := decodeHex(v.Key, v.IV, v.Plaintext, v.Ciphertext)
+	if err != nil {
+		return err
+	}
+	key, iv, plaintext, ciphertext := fields[0], fields[1], fields[2], fields[3]
+
+	c, err := conformanceCipher(cipherName)
+	if err != nil {
+		return err
+	}
+	if err := c.CipherKey(key); err != nil {
+		return err
+	}
+	if len(iv) == 0 {
+		iv = make([]byte, c.BlockSize())
+	}
+
+	// последовательный и параллельный пути должны давать один и тот же ответ
+	for _, parallel := range []ParallelConfig{{}, {Threshold: 1, Workers: 4}} {
+		ctx := &EncryptionContext{Cipher: c, Mode: mode.Mode, Padding: NoPadding, Parallel: parallel}
+
+		ctx.startChain(iv)
+		got, err := ctx.encryptBlocks(plaintext)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(got, ciphertext) {
+			return fmt.Errorf("encrypt: got %x, want %x", got, ciphertext)
+		}
+
+		ctx.startChain(iv)
+		got, err = ctx.decryptBlocks(ciphertext)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(got, plaintext) {
+			return fmt.Errorf("decrypt: got %x, want %x", got, plaintext)
+		}
+
+		if !mode.Stream {
+			continue
+		}
+		// потоковый режим обязан шифровать любой префикс без набивки
+		for _, n := range []int{1, c.BlockSize() - 1, c.BlockSize() + 1, len(plaintext) - 1} {
+			if n <= 0 || n > len(plaintext) {
+				continue
+			}
+			ctx.startChain(iv)
+			got, err := ctx.encryptBlocks(plaintext[:n])
+			if err != nil {
+				return fmt.Errorf("encrypt %d bytes: %w", n, err)
+			}
+			if !bytes.Equal(got, ciphertext[:n]) {
+				return fmt.Errorf("encrypt %d bytes: got %x, want %x", n, got, ciphertext[:n])
+			}
+		}
+	}
+	return nil
+}
+
+// checkGCMVector прогоняет вектор через конверт целиком: обычное и потоковое шифрование
+// с IV вектора дают заголовок, шифртекст и тег, а оба пути расшифровки — открытый текст
+func checkGCMVector(cipherName string, v gcmVector) error {
+	fields, err := decodeHex(v.Key, v.IV, v.AAD, v.Plaintext, v.Ciphertext, v.Tag)
+	if err != nil {
+		return err
+	}
+	key, iv, aad, plaintext := fields[0], fields[1], fields[2], fields[3]
+	want := append(envelopeHeader(iv), append(fields[4], fields[5]...)...)
+
+	c, err := conformanceCipher(cipherName)
+	if err != nil {
+		return err
+	}
+	if err := c.CipherKey(key); err != nil {
+		return err
+	}
+	ctx := &EncryptionContext{Cipher: c, Mode: GCM, Padding: NoPadding}
+
+	ctx.random = bytes.NewReader(iv)
+	got, err := ctx.EncryptWithAAD(plaintext, aad)
+	if err != nil {
+		return fmt.Errorf("encrypt: %w", err)
+	}
+	if !bytes.Equal(got, want) {
+		return fmt.Errorf("encrypt: got %x, want %x", got, want)
+	}
+	ctx.random = bytes.NewReader(iv)
+	if got, err = sealStream(ctx, plaintext, aad, 7); err != nil {
+		return fmt.Errorf("stream encrypt: %w", err)
+	}
+	if !bytes.Equal(got, want) {
+		return fmt.Errorf("stream encrypt: got %x, want %x", got, want)
+	}
+
+	if got, err = ctx.DecryptWithAAD(want, aad); err != nil {
+		return fmt.Errorf("decrypt: %w", err)
+	}
+	if !bytes.Equal(got, plaintext) {
+		return fmt.Errorf("decrypt: got %x, want %x", got, plaintext)
+	}
+	if got, err = openStream(ctx, want, aad); err != nil {
+		return fmt.Errorf("stream decrypt: %w", err)
+	}
+	if !bytes.Equal(got, plaintext) {
+		return fmt.Errorf("stream decrypt: got %x, want %x", got, plaintext)
+	}
+	return nil
+}
+
+// TestGCMStdlib сверяет GCM с crypto/cipher на AES при длинах вокруг границ блока и разных AAD
+func TestGCMStdlib(t *testing.T) {
+	key, nonce := conformanceSeed[:16], conformanceSeed[16:16+gcmNonceSize]
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &aesCipher{}
+	if err := c.CipherKey(key); err != nil {
+		t.Fatal(err)
+	}
+	ctx := &EncryptionContext{Cipher: c, Mode: GCM, Padding: NoPadding}
+
+	message := make([]byte, 1000)
+	for i := range message {
+		message[i] = byte(i * 7)
+	}
+	lengths := []int{255, len(message)}
+	for n := 0; n <= 3*gcmBlockSize+1; n++ {
+		lengths = append(lengths, n)
+	}
+
+	for _, n := range lengths {
+		t.Run(fmt.Sprintf("%d bytes", n), func(t *testing.T) {
+			plaintext, aad := message[:n], conformanceSeed[:n%len(conformanceSeed)]
+			got, err := encryptWithGCM(ctx, nonce, plaintext, aad)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := aead.Seal(nil, nonce, plaintext, aad); !bytes.Equal(got, want) {
+				t.Fatalf("got %x, want %x", got, want)
+			}
+		})
+	}
+}
+
+// TestGCMRejectsTampering портит тег, шифртекст и AAD конверта GCM: и обычная, и потоковая
+// расшифровка обязаны вернуть ErrAuthentication, не отдав ни байта открытого текста
+func TestGCMRejectsTampering(t *testing.T) {
+	mode, _ := LookupMode("GCM")
+	padding, _ := LookupPadding("None")
+	cc := conformanceCombination{"serpent", mode, padding}
+	plaintext := bytes.Repeat([]byte("attack at dawn "), 20)
+
+	envelope, err := conformanceSeal(cc, plaintext, DefaultParallelConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, err := conformanceContext(cc, conformanceKey, conformanceSeed[:], DefaultParallelConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	flip := func(i int) []byte {
+		tampered := append([]byte(nil), envelope...)
+		tampered[i] ^= 0x80
+		return tampered
+	}
+	headerSize := 3 + gcmNonceSize
+
+	for _, tc := range []struct {
+		name     string
+		envelope []byte
+		aad      []byte
+	}{
+		{"tag first byte", flip(len(envelope) - gcmTagSize), conformanceAAD},
+		{"tag last byte", flip(len(envelope) - 1), conformanceAAD},
+		{"ciphertext first byte", flip(headerSize), conformanceAAD},
+		{"ciphertext last byte", flip(len(envelope) - gcmTagSize - 1), conformanceAAD},
+		{"nonce", flip(3), conformanceAAD},
+		{"truncated tag", envelope[:len(envelope)-1], conformanceAAD},
+		{"tag only", append(envelopeHeader(envelope[3:headerSize]), envelope[len(envelope)-gcmTagSize:]...), conformanceAAD},
+		{"other AAD", envelope, []byte("chat:mallory")},
+		{"AAD byte", envelope, append([]byte("Chat"), conformanceAAD[4:]...)},
+		{"missing AAD", envelope, nil},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Run("one-shot", func(t *testing.T) {
+				checkConformance(t, expectAuthFailure(func() ([]byte, error) {
+					return ctx.DecryptWithAAD(tc.envelope, tc.aad)
+				}))
+			})
+			t.Run("stream", func(t *testing.T) {
+				checkConformance(t, expectAuthFailure(func() ([]byte, error) {
+					return openStream(ctx, tc.envelope, tc.aad)
+				}))
+			})
+		})
+	}
+}
+
+func expectAuthFailure(open func() ([]byte, error)) error {
+	got, err := open()
+	if !errors.Is(err, ErrAuthentication) {
+		return fmt.Errorf("got error %v, want ErrAuthentication", err)
+	}
+	if len(got) > 0 {
+		return fmt.Errorf("%d bytes of plaintext released", len(got))
+	}
+	return nil
+}
+
+// openStream читает конверт через DecryptingReader; вместе с ошибкой возвращает всё,
+// что читатель успел отдать
+func openStream(ctx *EncryptionContext, envelope, aad []byte) ([]byte, error) {
+	reader, err := NewDecryptingReaderWithAAD(ctx, bytes.NewReader(envelope), aad)
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(reader)
+}
+
+func checkBlockVector(v blockVector) error {
+	fields, err := decodeHex(v.Key, v.Plaintext, v.Ciphertext)
+	if err != nil {
+		return err
+	}
+	key, plaintext, ciphertext := fields[0], fields[1], fields[2]
+
+	c, err := NewCipher(v.Algorithm)
+	if err != nil {
+		return err
+	}
+	if err := c.CipherKey(key); err != nil {
+		return err
+	}
+
+	got, err := c.Encrypt(plaintext)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(got, ciphertext) {
+		return fmt.Errorf("encrypt: got %x, want %x", got, ciphertext)
+	}
+	got, err = c.Decrypt(ciphertext)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(got, plaintext) {
+		return fmt.Errorf("decrypt: got %x, want %x", got, plaintext)
+	}
+	return nil
+}
+
+// conformanceCombination — сочетание, которое сервис разрешает для чата
+type conformanceCombination struct {
+	Algorithm string
+	Mode      ModeInfo
+	Padding   PaddingInfo
+}
+
+func (cc conformanceCombination) String() string {
+	return cc.Algorithm + " " + cc.Mode.Name + " " + cc.Padding.Name
+}
+
+// acceptedCombinations перебирает по реестру все шифры (с вариантами), режимы и набивки
+// по тем же правилам, что и проверка параметров чата в сервисе
+func acceptedCombinations() ([]conformanceCombination, error) {
+	var combinations []conformanceCombination
+	for _, info := range Ciphers() {
+		algorithms := []string{info.Name}
+		for _, variant := range info.Variants {
+			algorithms = append(algorithms, variant.Name)
+		}
+		for _, algorithm := range algorithms {
+			c, err := NewCipher(algorithm)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", algorithm, err)
+			}
+			for _, mode := range Modes() {
+				if !mode.SupportsBlockSize(c.BlockSize()) {
+					continue
+				}
+				for _, padding := range Paddings() {
+					if mode.AcceptsPadding(padding) {
+						combinations = append(combinations, conformanceCombination{algorithm, mode, padding})
+					}
+				}
+			}
+		}
+	}
+	return combinations, nil
+}
+
+var (
+	conformanceKey  = bytes.Repeat([]byte{0x4b, 0x79, 0x67, 0x72}, 8)
+	conformanceAAD  = []byte("chat:sender")
+	conformanceSeed = sha512.Sum512([]byte("Kygram conformance"))
+)
+
+// conformanceContext собирает контекст, который берёт «случайные» байты из random
+func conformanceContext(cc conformanceCombination, key []byte, random []byte, parallel ParallelConfig) (*EncryptionContext, error) {
+	c, err := NewCipher(cc.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+	// общий ключ наборов обрезается до длины, которую принимает шифр
+	keySize, err := CipherKeySize(cc.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+	key = key[:keySize]
+	expander, ok := c.(KeyExpander)
+	if !ok {
+		return nil, errors.New("cipher doesn't support key expansion")
+	}
+	if err := c.CipherKey(key); err != nil {
+		return nil, err
+	}
+
+	ctx, err := NewEncryptionContext(key, cc.Mode.Mode, cc.Padding.Padding, nil, c, expander)
+	if err != nil {
+		return nil, err
+	}
+	ctx.random = bytes.NewReader(random)
+	ctx.Parallel = parallel
+	return ctx, nil
+}
+
+// checkRegistrySizes сверяет размеры блока и ключа, которые реестр сообщает для шифра или его
+// варианта, с самим шифром: заявленные длины ключа принимаются, соседние отвергаются
+func checkRegistrySizes(v CipherVariant) error {
+	c, err := NewCipher(v.Name)
+	if err != nil {
+		return err
+	}
+	if c.BlockSize() != v.BlockSize {
+		return fmt.Errorf("block size is %d, registry says %d", c.BlockSize(), v.BlockSize)
+	}
+	accepted := make(map[int]bool, len(v.KeySizes))
+	for _, size := range v.KeySizes {
+		accepted[size] = true
+	}
+	for _, size := range v.KeySizes {
+		for _, n := range []int{size - 1, size, size + 1} {
+			err := c.CipherKey(make([]byte, n))
+			if accepted[n] && err != nil {
+				return fmt.Errorf("%d-byte key rejected: %w", n, err)
+			}
+			if !accepted[n] && err == nil {
+				return fmt.Errorf("%d-byte key accepted", n)
+			}
+		}
+	}
+	return nil
+}
+
+// checkRoundTrip шифрует префиксы одного сообщения разной длины вокруг границ блока и проверяет:
+// расшифровку, длину конверта, совпадение потокового и обычного шифрования, а также то,
+// что шифртекст префикса совпадает с началом шифртекста всего сообщения (при том же IV)
+func checkRoundTrip(cc conformanceCombination) error {
+	c, err := NewCipher(cc.Algorithm)
+	if err != nil {
+		return err
+	}
+	blockSize := c.BlockSize()
+
+	// двоичные данные с нулями (в том числе в конце префиксов); только набивка Zeros
+	// теряет нули в конце, для неё сообщение без нулевых байтов
+	message := make([]byte, 1000)
+	for i := range message {
+		message[i] = byte(i * 7)
+		if cc.Padding.Padding == Zeros {
+			message[i] = byte(i*7%255 + 1)
+		}
+	}
+	full, err := conformanceSeal(cc, message, DefaultParallelConfig)
+	if err != nil {
+		return fmt.Errorf("encrypt %d bytes: %w", len(message), err)
+	}
+	parallel, err := conformanceSeal(cc, message, ParallelConfig{Threshold: 1, Workers: 4})
+	if err != nil {
+		return fmt.Errorf("parallel encrypt: %w", err)
+	}
+	if !bytes.Equal(parallel, full) {
+		return errors.New("parallel and serial ciphertexts differ")
+	}
+
+	lengths := []int{5*blockSize + 3, len(message)}
+	for n := 0; n <= 2*blockSize+1; n++ {
+		lengths = append(lengths, n)
+	}
+	for _, n := range lengths {
+		if err := checkRoundTripLength(cc, message[:n], full, blockSize); err != nil {
+			return fmt.Errorf("%d bytes: %w", n, err)
+		}
+	}
+	return nil
+}
+
+func checkRoundTripLength(cc conformanceCombination, plaintext, full []byte, blockSize int) error {
+	n := len(plaintext)
+	envelope, err := conformanceSeal(cc, plaintext, DefaultParallelConfig)
+	if err != nil {
+		return fmt.Errorf("encrypt: %w", err)
+	}
+
+	ivSize, body := blockSize, n
+	switch {
+	case cc.Mode.Mode == GCM:
+		ivSize, body = gcmNonceSize, n+gcmTagSize
+	case cc.Padding.Padding == ZerosLength:
+		body = (n + zeroLengthTrailer + blockSize - 1) / blockSize * blockSize
+	case cc.Padding.Padding != NoPadding:
+		body = (n/blockSize + 1) * blockSize
+	}
+	headerSize := 3 + ivSize
+	if len(envelope) != headerSize+body {
+		return fmt.Errorf("envelope is %d bytes, want %d", len(envelope), headerSize+body)
+	}
+
+	prefix := n - n%blockSize
+	if cc.Mode.Stream {
+		prefix = n
+	}
+	if !bytes.Equal(envelope[:headerSize+prefix], full[:headerSize+prefix]) {
+		return errors.New("ciphertext is not a prefix of the longer message's ciphertext")
+	}
+
+	ctx, err := conformanceContext(cc, conformanceKey, conformanceSeed[:], DefaultParallelConfig)
+	if err != nil {
+		return err
+	}
+	streamed, err := sealStream(ctx, plaintext, conformanceAAD, 7)
+	if err != nil {
+		return fmt.Errorf("stream encrypt: %w", err)
+	}
+	if !bytes.Equal(streamed, envelope) {
+		return errors.New("stream and one-shot ciphertexts differ")
+	}
+
+	got, err := ctx.DecryptWithAAD(envelope, conformanceAAD)
+	if err != nil {
+		return fmt.Errorf("decrypt: %w", err)
+	}
+	if !bytes.Equal(got, plaintext) {
+		return fmt.Errorf("decrypt: got %x, want %x", got, plaintext)
+	}
+	reader, err := NewDecryptingReaderWithAAD(ctx, bytes.NewReader(envelope), conformanceAAD)
+	if err != nil {
+		return fmt.Errorf("stream decrypt: %w", err)
+	}
+	if got, err = io.ReadAll(reader); err != nil {
+		return fmt.Errorf("stream decrypt: %w", err)
+	}
+	if !bytes.Equal(got, plaintext) {
+		return fmt.Errorf("stream decrypt: got %x, want %x", got, plaintext)
+	}
+	return nil
+}
+
+func conformanceSeal(cc conformanceCombination, plaintext []byte, parallel ParallelConfig) ([]byte, error) {
+	ctx, err := conformanceContext(cc, conformanceKey, conformanceSeed[:], parallel)
+	if err != nil {
+		return nil, err
+	}
+	return ctx.EncryptWithAAD(plaintext, conformanceAAD)
+}
+
+// sealStream пишет plaintext в EncryptingWriter кусками по chunk байт
+func sealStream(ctx *EncryptionContext, plaintext, aad []byte, chunk int) ([]byte, error) {
+	var out bytes.Buffer
+	w, err := NewEncryptingWriterWithAAD(ctx, &out, aad)
+	if err != nil {
+		return nil, err
+	}
+	for len(plaintext) > 0 {
+		n := min(chunk, len(plaintext))
+		if _, err := w.Write(plaintext[:n]); err != nil {
+			return nil, err
+		}
+		plaintext = plaintext[n:]
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+func checkEnvelopeVector(v envelopeVector, key, random, aad, plaintext []byte) error {
+	mode, ok := LookupMode(v.Mode)
+	if !ok {
+		return fmt.Errorf("unknown mode %q", v.Mode)
+	}
+	padding, ok := LookupPadding(v.Padding)
+	if !ok {
+		return fmt.Errorf("unknown padding %q", v.Padding)
+	}
+	want, err := hex.DecodeString(v.Envelope)
+	if err != nil {
+		return fmt.Errorf("invalid hex %q: %w", v.Envelope, err)
+	}
+	cc := conformanceCombination{v.Algorithm, mode, padding}
+
+	ctx, err := conformanceContext(cc, key, random, DefaultParallelConfig)
+	if err != nil {
+		return err
+	}
+	got, err := ctx.EncryptWithAAD(plaintext, aad)
+	if err != nil {
+		return fmt.Errorf("encrypt: %w", err)
+	}
+	if !bytes.Equal(got, want) {
+		return fmt.Errorf("encrypt: got %x, want %x", got, want)
+	}
+
+	got, err = ctx.DecryptWithAAD(want, aad)
+	if err != nil {
+		return fmt.Errorf("decrypt: %w", err)
+	}
+	if !bytes.Equal(got, plaintext) {
+		return fmt.Errorf("decrypt: got %x, want %x", got, plaintext)
+	}
+	return nil
+}
+
+// conformanceCipher создаёт шифр для набора векторов. AES в мессенджере не используется
+// и есть здесь только затем, чтобы сверять режимы с опубликованными векторами NIST.
+func conformanceCipher(name string) (Cipher, error) {
+	if name == "aes" {
+		return &aesCipher{}, nil
+	}
+	return NewCipher(name)
+}
+
+type aesCipher struct {
+	block cipher.Block
+}
+
+func (a *aesCipher) CipherKey(key []byte) error {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return err
+	}
+	a.block = block
+	return nil
+}
+
+func (a *aesCipher) BlockSize() int {
+	return aes.BlockSize
+}
+
+func (a *aesCipher) Encrypt(block []byte) ([]byte, error) {
+	if a.block == nil || len(block) != aes.BlockSize {
+		return nil, errors.New("aes: key is not set or invalid block size")
+	}
+	out := make([]byte, aes.BlockSize)
+	a.block.Encrypt(out, block)
+	return out, nil
+}
+
+func (a *aesCipher) Decrypt(block []byte) ([]byte, error) {
+	if a.block == nil || len(block) != aes.BlockSize {
+		return nil, errors.New("aes: key is not set or invalid block size")
+	}
+	out := make([]byte, aes.BlockSize)
+	a.block.Decrypt(out, block)
+	return out, nil
+}
+
+func loadTestdata(path string, v interface{}) error {
+	data, err := os.ReadFile(filepath.FromSlash(path))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+func decodeHex(values ...string) ([][]byte, error) {
+	decoded := make([][]byte, len(values))
+	for i, value := range values {
+		b, err := hex.DecodeString(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid hex %q: %w", value, err)
+		}
+		decoded[i] = b
+	}
+	return decoded, nil
+}
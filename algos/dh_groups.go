@@ -0,0 +1,288 @@
+package algos
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+var ErrInvalidPublicKey = errors.New("invalid Diffie-Hellman public key")
+
+// DHGroup — параметры Диффи-Хеллмана: безопасное простое p = 2q+1 и генератор g подгруппы порядка q
+type DHGroup struct {
+	Prime     *big.Int
+	Generator *big.Int
+	order     *big.Int
+}
+
+// DHGroupInfo описывает группу, которую можно выбрать для чата. New для стандартных групп
+// возвращает их параметры, для Generated — создаёт новое безопасное простое.
+type DHGroupInfo struct {
+	Name        string
+	DisplayName string
+	Bits        int
+	Generated   bool
+	New         func() (*DHGroup, error)
+}
+
+// DefaultDHGroup используется для чатов, в которых группа не выбрана
+const DefaultDHGroup = "ffdhe2048"
+
+var dhGroups []DHGroupInfo
+
+func init() {
+	for _, g := range []struct {
+		name, displayName, prime string
+	}{
+		{"ffdhe2048", "ffdhe2048 (RFC 7919)", ffdhe2048},
+		{"ffdhe3072", "ffdhe3072 (RFC 7919)", ffdhe3072},
+		{"ffdhe4096", "ffdhe4096 (RFC 7919)", ffdhe4096},
+		{"modp2048", "MODP 2048, group 14 (RFC 3526)", rfc3526Group14},
+		{"modp3072", "MODP 3072, group 15 (RFC 3526)", rfc3526Group15},
+		{"modp4096", "MODP 4096, group 16 (RFC 3526)", rfc3526Group16},
+	} {
+		prime, ok := new(big.Int).SetString(g.prime, 16)
+		if !ok {
+			panic("algos: invalid prime of DH group " + g.name)
+		}
+		group := newDHGroup(prime, big.NewInt(2))
+		dhGroups = append(dhGroups, DHGroupInfo{
+			Name:        g.name,
+			DisplayName: g.displayName,
+			Bits:        prime.BitLen(),
+			New:         func() (*DHGroup, error) { return group, nil },
+		})
+	}
+
+	// генерация занимает от секунд до минут, поэтому это только опция
+	dhGroups = append(dhGroups, DHGroupInfo{
+		Name:        "generated2048",
+		DisplayName: "Generated 2048-bit safe prime",
+		Bits:        2048,
+		Generated:   true,
+		New:         func() (*DHGroup, error) { return GenerateDHGroup(2048) },
+	})
+}
+
+func newDHGroup(prime, generator *big.Int) *DHGroup {
+	order := new(big.Int).Rsh(prime, 1)
+	return &DHGroup{Prime: prime, Generator: generator, order: order}
+}
+
+// NewDHGroup восстанавливает группу по сохранённым p и g; p должно быть безопасным простым
+func NewDHGroup(prime, generator *big.Int) (*DHGroup, error) {
+	if prime.Sign() <= 0 || prime.Bit(0) == 0 || prime.BitLen() < 512 {
+		return nil, fmt.Errorf("invalid DH prime: %d bits", prime.BitLen())
+	}
+	group := newDHGroup(prime, generator)
+	if err := group.ValidatePublicKey(generator); err != nil {
+		return nil, fmt.Errorf("invalid DH generator: %w", err)
+	}
+	return group, nil
+}
+
+func LookupDHGroup(name string) (DHGroupInfo, bool) {
+	for _, info := range dhGroups {
+		if info.Name == name {
+			return info, true
+		}
+	}
+	return DHGroupInfo{}, false
+}
+
+func DHGroups() []DHGroupInfo {
+	return append([]DHGroupInfo(nil), dhGroups...)
+}
+
+// Order возвращает q = (p-1)/2 — порядок подгруппы, в которой лежат ключи
+func (g *DHGroup) Order() *big.Int {
+	return new(big.Int).Set(g.order)
+}
+
+// GeneratePrivateKey выбирает закрытый ключ из [2, q-1]
+func (g *DHGroup) GeneratePrivateKey() (*big.Int, error) {
+	k, err := rand.Int(rand.Reader, new(big.Int).Sub(g.order, big.NewInt(2)))
+	if err != nil {
+		return nil, err
+	}
+	return k.Add(k, big.NewInt(2)), nil
+}
+
+func (g *DHGroup) PublicKey(privateKey *big.Int) *big.Int {
+	return GeneratePublicKey(g.Generator, privateKey, g.Prime)
+}
+
+// ValidatePublicKey отвергает ключи вне [2, p-2] и вне подгруппы порядка q:
+// такие значения сводят общий секрет к малому множеству
+func (g *DHGroup) ValidatePublicKey(y *big.Int) error {
+	upper := new(big.Int).Sub(g.Prime, big.NewInt(2))
+	if y.Cmp(big.NewInt(2)) < 0 || y.Cmp(upper) > 0 {
+		return ErrInvalidPublicKey
+	}
+	if new(big.Int).Exp(y, g.order, g.Prime).Cmp(big.NewInt(1)) != 0 {
+		return ErrInvalidPublicKey
+	}
+	return nil
+}
+
+// SharedSecret проверяет ключ собеседника и вычисляет общий секрет
+func (g *DHGroup) SharedSecret(privateKey, otherPublicKey *big.Int) (*big.Int, error) {
+	if err := g.ValidatePublicKey(otherPublicKey); err != nil {
+		return nil, err
+	}
+	return GenerateSharedKey(privateKey, otherPublicKey, g.Prime), nil
+}
+
+// SecretBytes кодирует общий секрет группы в байты фиксированной длины модуля (RFC 7919, раздел 5.2)
+func (g *DHGroup) SecretBytes(secret *big.Int) []byte {
+	return secret.FillBytes(make([]byte, (g.Prime.BitLen()+7)/8))
+}
+
+// GenerateDHGroup создаёт группу на новом безопасном простом; генератор 4 = 2^2 —
+// квадратичный вычет, поэтому он всегда порождает подгруппу порядка q
+func GenerateDHGroup(bits int) (*DHGroup, error) {
+	prime, err := GenerateSafePrime(bits)
+	if err != nil {
+		return nil, err
+	}
+	return newDHGroup(prime, big.NewInt(4)), nil
+}
+
+// GenerateSafePrime ищет простое p = 2q+1 с простым q. Кандидаты перебираются подряд
+// от случайной точки и отсеиваются малыми простыми сразу для q и для p.
+func GenerateSafePrime(bits int) (*big.Int, error) {
+	if bits < 64 {
+		return nil, fmt.Errorf("safe prime is too small: %d bits", bits)
+	}
+	const window = 1 << 16
+
+	residues := make([]uint64, len(sievePrimes))
+	q, p := new(big.Int), new(big.Int)
+	for {
+		start, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), uint(bits-1)))
+		if err != nil {
+			return nil, err
+		}
+		start.SetBit(start, bits-2, 1).SetBit(start, bits-3, 1).SetBit(start, 0, 1)
+		for i, s := range sievePrimes {
+			residues[i] = new(big.Int).Mod(start, big.NewInt(int64(s))).Uint64()
+		}
+
+	candidates:
+		for delta := uint64(0); delta < window; delta += 2 {
+			for i, s := range sievePrimes {
+				r := (residues[i] + delta) % s
+				if r == 0 || (2*r+1)%s == 0 {
+					continue candidates
+				}
+			}
+			q.Add(start, new(big.Int).SetUint64(delta))
+			p.Lsh(q, 1).SetBit(p, 0, 1)
+			if p.BitLen() != bits {
+				break
+			}
+			if q.ProbablyPrime(20) && p.ProbablyPrime(20) {
+				return p, nil
+			}
+		}
+	}
+}
+
+// sievePrimes — нечётные простые меньше 2000 для отсева кандидатов
+var sievePrimes = func() []uint64 {
+	const limit = 2000
+	var primes []uint64
+	composite := make([]bool, limit)
+	for i := 3; i < limit; i += 2 {
+		if composite[i] {
+			continue
+		}
+		primes = append(primes, uint64(i))
+		for j := i * i; j < limit; j += 2 * i {
+			composite[j] = true
+		}
+	}
+	return primes
+}()
+
+// Простые RFC 3526 (группы 14–16) и RFC 7919 (ffdhe); везде p = 2q+1, генератор 2
+const (
+	rfc3526Group14 = "FFFFFFFFFFFFFFFFC90FDAA22168C234C4C6628B80DC1CD129024E088A67CC74" +
+		"020BBEA63B139B22514A08798E3404DDEF9519B3CD3A431B302B0A6DF25F1437" +
+		"4FE1356D6D51C245E485B576625E7EC6F44C42E9A637ED6B0BFF5CB6F406B7ED" +
+		"EE386BFB5A899FA5AE9F24117C4B1FE649286651ECE45B3DC2007CB8A163BF05" +
+		"98DA48361C55D39A69163FA8FD24CF5F83655D23DCA3AD961C62F356208552BB" +
+		"9ED529077096966D670C354E4ABC9804F1746C08CA18217C32905E462E36CE3B" +
+		"E39E772C180E86039B2783A2EC07A28FB5C55DF06F4C52C9DE2BCBF695581718" +
+		"3995497CEA956AE515D2261898FA051015728E5A8AACAA68FFFFFFFFFFFFFFFF"
+
+	rfc3526Group15 = "FFFFFFFFFFFFFFFFC90FDAA22168C234C4C6628B80DC1CD129024E088A67CC74" +
+		"020BBEA63B139B22514A08798E3404DDEF9519B3CD3A431B302B0A6DF25F1437" +
+		"4FE1356D6D51C245E485B576625E7EC6F44C42E9A637ED6B0BFF5CB6F406B7ED" +
+		"EE386BFB5A899FA5AE9F24117C4B1FE649286651ECE45B3DC2007CB8A163BF05" +
+		"98DA48361C55D39A69163FA8FD24CF5F83655D23DCA3AD961C62F356208552BB" +
+		"9ED529077096966D670C354E4ABC9804F1746C08CA18217C32905E462E36CE3B" +
+		"E39E772C180E86039B2783A2EC07A28FB5C55DF06F4C52C9DE2BCBF695581718" +
+		"3995497CEA956AE515D2261898FA051015728E5A8AAAC42DAD33170D04507A33" +
+		"A85521ABDF1CBA64ECFB850458DBEF0A8AEA71575D060C7DB3970F85A6E1E4C7" +
+		"ABF5AE8CDB0933D71E8C94E04A25619DCEE3D2261AD2EE6BF12FFA06D98A0864" +
+		"D87602733EC86A64521F2B18177B200CBBE117577A615D6C770988C0BAD946E2" +
+		"08E24FA074E5AB3143DB5BFCE0FD108E4B82D120A93AD2CAFFFFFFFFFFFFFFFF"
+
+	rfc3526Group16 = "FFFFFFFFFFFFFFFFC90FDAA22168C234C4C6628B80DC1CD129024E088A67CC74" +
+		"020BBEA63B139B22514A08798E3404DDEF9519B3CD3A431B302B0A6DF25F1437" +
+		"4FE1356D6D51C245E485B576625E7EC6F44C42E9A637ED6B0BFF5CB6F406B7ED" +
+		"EE386BFB5A899FA5AE9F24117C4B1FE649286651ECE45B3DC2007CB8A163BF05" +
+		"98DA48361C55D39A69163FA8FD24CF5F83655D23DCA3AD961C62F356208552BB" +
+		"9ED529077096966D670C354E4ABC9804F1746C08CA18217C32905E462E36CE3B" +
+		"E39E772C180E86039B2783A2EC07A28FB5C55DF06F4C52C9DE2BCBF695581718" +
+		"3995497CEA956AE515D2261898FA051015728E5A8AAAC42DAD33170D04507A33" +
+		"A85521ABDF1CBA64ECFB850458DBEF0A8AEA71575D060C7DB3970F85A6E1E4C7" +
+		"ABF5AE8CDB0933D71E8C94E04A25619DCEE3D2261AD2EE6BF12FFA06D98A0864" +
+		"D87602733EC86A64521F2B18177B200CBBE117577A615D6C770988C0BAD946E2" +
+		"08E24FA074E5AB3143DB5BFCE0FD108E4B82D120A92108011A723C12A787E6D7" +
+		"88719A10BDBA5B2699C327186AF4E23C1A946834B6150BDA2583E9CA2AD44CE8" +
+		"DBBBC2DB04DE8EF92E8EFC141FBECAA6287C59474E6BC05D99B2964FA090C3A2" +
+		"233BA186515BE7ED1F612970CEE2D7AFB81BDD762170481CD0069127D5B05AA9" +
+		"93B4EA988D8FDDC186FFB7DC90A6C08F4DF435C934063199FFFFFFFFFFFFFFFF"
+
+	ffdhe2048 = "FFFFFFFFFFFFFFFFADF85458A2BB4A9AAFDC5620273D3CF1D8B9C583CE2D3695" +
+		"A9E13641146433FBCC939DCE249B3EF97D2FE363630C75D8F681B202AEC4617A" +
+		"D3DF1ED5D5FD65612433F51F5F066ED0856365553DED1AF3B557135E7F57C935" +
+		"984F0C70E0E68B77E2A689DAF3EFE8721DF158A136ADE73530ACCA4F483A797A" +
+		"BC0AB182B324FB61D108A94BB2C8E3FBB96ADAB760D7F4681D4F42A3DE394DF4" +
+		"AE56EDE76372BB190B07A7C8EE0A6D709E02FCE1CDF7E2ECC03404CD28342F61" +
+		"9172FE9CE98583FF8E4F1232EEF28183C3FE3B1B4C6FAD733BB5FCBC2EC22005" +
+		"C58EF1837D1683B2C6F34A26C1B2EFFA886B423861285C97FFFFFFFFFFFFFFFF"
+
+	ffdhe3072 = "FFFFFFFFFFFFFFFFADF85458A2BB4A9AAFDC5620273D3CF1D8B9C583CE2D3695" +
+		"A9E13641146433FBCC939DCE249B3EF97D2FE363630C75D8F681B202AEC4617A" +
+		"D3DF1ED5D5FD65612433F51F5F066ED0856365553DED1AF3B557135E7F57C935" +
+		"984F0C70E0E68B77E2A689DAF3EFE8721DF158A136ADE73530ACCA4F483A797A" +
+		"BC0AB182B324FB61D108A94BB2C8E3FBB96ADAB760D7F4681D4F42A3DE394DF4" +
+		"AE56EDE76372BB190B07A7C8EE0A6D709E02FCE1CDF7E2ECC03404CD28342F61" +
+		"9172FE9CE98583FF8E4F1232EEF28183C3FE3B1B4C6FAD733BB5FCBC2EC22005" +
+		"C58EF1837D1683B2C6F34A26C1B2EFFA886B4238611FCFDCDE355B3B6519035B" +
+		"BC34F4DEF99C023861B46FC9D6E6C9077AD91D2691F7F7EE598CB0FAC186D91C" +
+		"AEFE130985139270B4130C93BC437944F4FD4452E2D74DD364F2E21E71F54BFF" +
+		"5CAE82AB9C9DF69EE86D2BC522363A0DABC521979B0DEADA1DBF9A42D5C4484E" +
+		"0ABCD06BFA53DDEF3C1B20EE3FD59D7C25E41D2B66C62E37FFFFFFFFFFFFFFFF"
+
+	ffdhe4096 = "FFFFFFFFFFFFFFFFADF85458A2BB4A9AAFDC5620273D3CF1D8B9C583CE2D3695" +
+		"A9E13641146433FBCC939DCE249B3EF97D2FE363630C75D8F681B202AEC4617A" +
+		"D3DF1ED5D5FD65612433F51F5F066ED0856365553DED1AF3B557135E7F57C935" +
+		"984F0C70E0E68B77E2A689DAF3EFE8721DF158A136ADE73530ACCA4F483A797A" +
+		"BC0AB182B324FB61D108A94BB2C8E3FBB96ADAB760D7F4681D4F42A3DE394DF4" +
+		"AE56EDE76372BB190B07A7C8EE0A6D709E02FCE1CDF7E2ECC03404CD28342F61" +
+		"9172FE9CE98583FF8E4F1232EEF28183C3FE3B1B4C6FAD733BB5FCBC2EC22005" +
+		"C58EF1837D1683B2C6F34A26C1B2EFFA886B4238611FCFDCDE355B3B6519035B" +
+		"BC34F4DEF99C023861B46FC9D6E6C9077AD91D2691F7F7EE598CB0FAC186D91C" +
+		"AEFE130985139270B4130C93BC437944F4FD4452E2D74DD364F2E21E71F54BFF" +
+		"5CAE82AB9C9DF69EE86D2BC522363A0DABC521979B0DEADA1DBF9A42D5C4484E" +
+		"0ABCD06BFA53DDEF3C1B20EE3FD59D7C25E41D2B669E1EF16E6F52C3164DF4FB" +
+		"7930E9E4E58857B6AC7D5F42D69F6D187763CF1D5503400487F55BA57E31CC7A" +
+		"7135C886EFB4318AED6A1E012D9E6832A907600A918130C46DC778F971AD0038" +
+		"092999A333CB8B7A1A1DB93D7140003C2A4ECEA9F98D0ACC0A8291CDCEC97DCF" +
+		"8EC9B55A7F88A46B4DB5A851F44182E1C68A007E5E655F6AFFFFFFFFFFFFFFFF"
+)
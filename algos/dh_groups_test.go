@@ -0,0 +1,139 @@
+package algos
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func TestStandardDHGroups(t *testing.T) {
+	for _, info := range DHGroups() {
+		if info.Generated {
+			continue
+		}
+		t.Run(info.Name, func(t *testing.T) {
+			group, err := info.New()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if group.Prime.BitLen() != info.Bits || !strings.HasSuffix(info.Name, big.NewInt(int64(info.Bits)).String()) {
+				t.Fatalf("%s has a %d-bit prime", info.Name, group.Prime.BitLen())
+			}
+			// у простых RFC 3526 и RFC 7919 по 64 единичных бита в начале и в конце
+			mask := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 64), big.NewInt(1))
+			if new(big.Int).And(group.Prime, mask).Cmp(mask) != 0 || new(big.Int).Rsh(group.Prime, uint(info.Bits-64)).Cmp(mask) != 0 {
+				t.Fatal("prime does not have the RFC form")
+			}
+			if !group.Prime.ProbablyPrime(1) || !group.Order().ProbablyPrime(1) {
+				t.Fatal("prime is not a safe prime")
+			}
+			if err := group.ValidatePublicKey(group.Generator); err != nil {
+				t.Fatalf("generator is outside the prime-order subgroup: %v", err)
+			}
+		})
+	}
+}
+
+func TestDHRejectsSmallSubgroupKeys(t *testing.T) {
+	info, _ := LookupDHGroup("ffdhe2048")
+	group, err := info.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := group.Prime
+	pMinus := func(n int64) *big.Int { return new(big.Int).Sub(p, big.NewInt(n)) }
+
+	for name, y := range map[string]*big.Int{
+		"zero":     big.NewInt(0),
+		"one":      big.NewInt(1), // подгруппа порядка 1
+		"p-1":      pMinus(1),     // подгруппа порядка 2
+		"p":        new(big.Int).Set(p),
+		"p+1":      new(big.Int).Add(p, big.NewInt(1)),
+		"negative": big.NewInt(-2),
+		// -4 — квадратичный невычет при p ≡ 3 (mod 4): порядок 2q, а не q
+		"non-residue": pMinus(4),
+	} {
+		t.Run(name, func(t *testing.T) {
+			if err := group.ValidatePublicKey(y); !errors.Is(err, ErrInvalidPublicKey) {
+				t.Fatalf("accepted: %v", err)
+			}
+			if err := group.CheckPublicKey(y.String()); !errors.Is(err, ErrInvalidPublicKey) {
+				t.Fatalf("encoded key accepted: %v", err)
+			}
+			private, err := group.GeneratePrivateKey()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := group.SharedSecret(private, y); !errors.Is(err, ErrInvalidPublicKey) {
+				t.Fatalf("shared secret computed: %v", err)
+			}
+		})
+	}
+	if err := group.CheckPublicKey("not a number"); !errors.Is(err, ErrInvalidPublicKey) {
+		t.Fatalf("malformed key accepted: %v", err)
+	}
+}
+
+func TestDHAgreement(t *testing.T) {
+	info, _ := LookupDHGroup("modp2048")
+	group, err := info.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	alice, err := group.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	bob, err := group.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ab, err := alice.SharedSecret(bob.PublicKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ba, err := bob.SharedSecret(alice.PublicKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(ab, ba) {
+		t.Fatal("parties disagree")
+	}
+	if len(ab) != 256 {
+		t.Fatalf("secret has %d bytes, want the length of the modulus", len(ab))
+	}
+	if _, err := alice.SharedSecret("1"); !errors.Is(err, ErrInvalidPublicKey) {
+		t.Fatalf("key of order 1 accepted: %v", err)
+	}
+}
+
+func TestGeneratedDHGroup(t *testing.T) {
+	prime, err := GenerateSafePrime(512)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if prime.BitLen() != 512 || !prime.ProbablyPrime(20) || !new(big.Int).Rsh(prime, 1).ProbablyPrime(20) {
+		t.Fatal("generated prime is not a 512-bit safe prime")
+	}
+
+	group, err := NewDHGroup(prime, big.NewInt(4))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := group.ValidatePublicKey(group.PublicKey(big.NewInt(12345))); err != nil {
+		t.Fatalf("public key of the group rejected: %v", err)
+	}
+
+	// сохранённые параметры проверяются при восстановлении
+	if _, err := NewDHGroup(new(big.Int).Add(prime, big.NewInt(1)), big.NewInt(4)); err == nil {
+		t.Fatal("even prime accepted")
+	}
+	if _, err := NewDHGroup(big.NewInt(23), big.NewInt(4)); err == nil {
+		t.Fatal("tiny prime accepted")
+	}
+	if _, err := NewDHGroup(prime, new(big.Int).Sub(prime, big.NewInt(1))); err == nil {
+		t.Fatal("generator of order 2 accepted")
+	}
+}
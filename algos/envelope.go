@@ -0,0 +1,84 @@
+package algos
+
+import (
+	"crypto/rand"
+	"errors"
+	"io"
+)
+
+// Формат конверта шифртекста:
+//
+//	[0]       envelopeMagic
+//	[1]       envelopeVersion
+//	[2]       длина IV (n)
+//	[3:3+n]   IV
+//	[3+n:]    шифртекст
+const (
+	envelopeMagic      byte = 0x4B
+	envelopeVersion    byte = 0x01
+	envelopeHeaderSize      = 3
+)
+
+var ErrInvalidEnvelope = errors.New("invalid ciphertext envelope")
+
+func sealEnvelope(iv, ciphertext []byte) []byte {
+	out := make([]byte, 0, envelopeHeaderSize+len(iv)+len(ciphertext))
+	out = append(out, envelopeHeader(iv)...)
+	return append(out, ciphertext...)
+}
+
+func envelopeHeader(iv []byte) []byte {
+	header := make([]byte, 0, envelopeHeaderSize+len(iv))
+	header = append(header, envelopeMagic, envelopeVersion, byte(len(iv)))
+	return append(header, iv...)
+}
+
+func openEnvelope(envelope []byte) (iv, ciphertext []byte, err error) {
+	if len(envelope) < envelopeHeaderSize {
+		return nil, nil, ErrInvalidEnvelope
+	}
+	if envelope[0] != envelopeMagic || envelope[1] != envelopeVersion {
+		return nil, nil, ErrInvalidEnvelope
+	}
+	ivLen := int(envelope[2])
+	if len(envelope) < envelopeHeaderSize+ivLen {
+		return nil, nil, ErrInvalidEnvelope
+	}
+	iv = envelope[envelopeHeaderSize : envelopeHeaderSize+ivLen]
+	ciphertext = envelope[envelopeHeaderSize+ivLen:]
+	return iv, ciphertext, nil
+}
+
+// readEnvelopeHeader читает заголовок конверта из потока и возвращает IV
+func readEnvelopeHeader(r io.Reader) ([]byte, error) {
+	header := make([]byte, envelopeHeaderSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil, ErrInvalidEnvelope
+		}
+		return nil, err
+	}
+	if header[0] != envelopeMagic || header[1] != envelopeVersion {
+		return nil, ErrInvalidEnvelope
+	}
+
+	iv := make([]byte, int(header[2]))
+	if _, err := io.ReadFull(r, iv); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil, ErrInvalidEnvelope
+		}
+		return nil, err
+	}
+	return iv, nil
+}
+
+func randomBytes(r io.Reader, n int) ([]byte, error) {
+	if r == nil {
+		r = rand.Reader
+	}
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
@@ -0,0 +1,226 @@
+package algos
+
+import (
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+)
+
+const (
+	gcmBlockSize = 16
+	gcmNonceSize = 12
+	gcmTagSize   = 16
+)
+
+var ErrAuthentication = errors.New("message authentication failed")
+
+// gfElement — элемент GF(2^128) в представлении GCM (старший бит первым)
+type gfElement struct {
+	hi, lo uint64
+}
+
+func gfFromBytes(b []byte) gfElement {
+	return gfElement{
+		hi: binary.BigEndian.Uint64(b[0:8]),
+		lo: binary.BigEndian.Uint64(b[8:16]),
+	}
+}
+
+func (x gfElement) bytes() []byte {
+	out := make([]byte, gcmBlockSize)
+	binary.BigEndian.PutUint64(out[0:8], x.hi)
+	binary.BigEndian.PutUint64(out[8:16], x.lo)
+	return out
+}
+
+// gfMul — умножение в GF(2^128) по модулю x^128 + x^7 + x^2 + x + 1 (NIST SP 800-38D, алгоритм 1)
+func gfMul(x, y gfElement) gfElement {
+	var z gfElement
+	v := y
+	for i := 0; i < 128; i++ {
+		var bit uint64
+		if i < 64 {
+			bit = (x.hi >> (63 - i)) & 1
+		} else {
+			bit = (x.lo >> (127 - i)) & 1
+		}
+		mask := -bit
+		z.hi ^= v.hi & mask
+		z.lo ^= v.lo & mask
+
+		lsb := v.lo & 1
+		v.lo = (v.lo >> 1) | (v.hi << 63)
+		v.hi >>= 1
+		v.hi ^= 0xe100000000000000 & -lsb
+	}
+	return z
+}
+
+type ghash struct {
+	h gfElement
+	y gfElement
+}
+
+func (g *ghash) update(data []byte) {
+	for len(data) > 0 {
+		var block [gcmBlockSize]byte
+		n := copy(block[:], data)
+		data = data[n:]
+
+		x := gfFromBytes(block[:])
+		g.y.hi ^= x.hi
+		g.y.lo ^= x.lo
+		g.y = gfMul(g.y, g.h)
+	}
+}
+
+func (g *ghash) sum(aadLen, ctLen int) []byte {
+	var lengths [gcmBlockSize]byte
+	binary.BigEndian.PutUint64(lengths[0:8], uint64(aadLen)*8)
+	binary.BigEndian.PutUint64(lengths[8:16], uint64(ctLen)*8)
+	g.update(lengths[:])
+	return g.y.bytes()
+}
+
+func inc32(counter []byte) {
+	c := binary.BigEndian.Uint32(counter[gcmBlockSize-4:])
+	binary.BigEndian.PutUint32(counter[gcmBlockSize-4:], c+1)
+}
+
+// gcmStream хранит состояние GCM между порциями данных: счётчик и накопленный GHASH.
+// Все порции, кроме последней, должны быть кратны размеру блока.
+type gcmStream struct {
+	ctx     *EncryptionContext
+	ghash   *ghash
+	j0      []byte
+	counter []byte
+	aadLen  int
+	ctLen   int
+}
+
+func newGCMStream(ctx *EncryptionContext, nonce, aad []byte) (*gcmStream, error) {
+	if ctx.Cipher.BlockSize() != gcmBlockSize {
+		return nil, errors.New("GCM requires a cipher with a 128-bit block")
+	}
+	if len(nonce) != gcmNonceSize {
+		return nil, errors.New("invalid GCM nonce size")
+	}
+
+	h, err := ctx.Cipher.Encrypt(make([]byte, gcmBlockSize))
+	if err != nil {
+		return nil, err
+	}
+
+	j0 := make([]byte, gcmBlockSize)
+	copy(j0, nonce)
+	j0[gcmBlockSize-1] = 1
+
+	g := &ghash{h: gfFromBytes(h)}
+	g.update(aad)
+
+	return &gcmStream{
+		ctx:     ctx,
+		ghash:   g,
+		j0:      j0,
+		counter: append([]byte(nil), j0...),
+		aadLen:  len(aad),
+	}, nil
+}
+
+func (s *gcmStream) encrypt(plaintext []byte) ([]byte, error) {
+	ciphertext, err := s.xorKeyStream(plaintext)
+	if err != nil {
+		return nil, err
+	}
+	s.ghash.update(ciphertext)
+	s.ctLen += len(ciphertext)
+	return ciphertext, nil
+}
+
+func (s *gcmStream) decrypt(ciphertext []byte) ([]byte, error) {
+	s.authenticate(ciphertext)
+	return s.xorKeyStream(ciphertext)
+}
+
+// authenticate учитывает шифртекст в GHASH, не расшифровывая его; счётчик не сдвигается
+func (s *gcmStream) authenticate(ciphertext []byte) {
+	s.ghash.update(ciphertext)
+	s.ctLen += len(ciphertext)
+}
+
+func (s *gcmStream) xorKeyStream(input []byte) ([]byte, error) {
+	output := make([]byte, len(input))
+
+	for offset := 0; offset < len(input); offset += gcmBlockSize {
+		inc32(s.counter)
+		keystream, err := s.ctx.Cipher.Encrypt(s.counter)
+		if err != nil {
+			return nil, err
+		}
+
+		end := offset + gcmBlockSize
+		if end > len(input) {
+			end = len(input)
+		}
+		for i := offset; i < end; i++ {
+			output[i] = input[i] ^ keystream[i-offset]
+		}
+	}
+	return output, nil
+}
+
+func (s *gcmStream) tag() ([]byte, error) {
+	sum := s.ghash.sum(s.aadLen, s.ctLen)
+
+	encJ0, err := s.ctx.Cipher.Encrypt(s.j0)
+	if err != nil {
+		return nil, err
+	}
+	return xorBlocks(sum, encJ0), nil
+}
+
+// encryptWithGCM возвращает шифртекст с добавленным в конец тегом аутентификации
+func encryptWithGCM(ctx *EncryptionContext, nonce, input, aad []byte) ([]byte, error) {
+	s, err := newGCMStream(ctx, nonce, aad)
+	if err != nil {
+		return nil, err
+	}
+
+	ciphertext, err := s.encrypt(input)
+	if err != nil {
+		return nil, err
+	}
+
+	tag, err := s.tag()
+	if err != nil {
+		return nil, err
+	}
+
+	return append(ciphertext, tag...), nil
+}
+
+func decryptWithGCM(ctx *EncryptionContext, nonce, input, aad []byte) ([]byte, error) {
+	if len(input) < gcmTagSize {
+		return nil, ErrAuthentication
+	}
+
+	s, err := newGCMStream(ctx, nonce, aad)
+	if err != nil {
+		return nil, err
+	}
+
+	ciphertext := input[:len(input)-gcmTagSize]
+	tag := input[len(input)-gcmTagSize:]
+
+	// тег проверяется до расшифрования, поэтому открытый текст не вычисляется зря
+	s.authenticate(ciphertext)
+	expectedTag, err := s.tag()
+	if err != nil {
+		return nil, err
+	}
+	if subtle.ConstantTimeCompare(tag, expectedTag) != 1 {
+		return nil, ErrAuthentication
+	}
+
+	return s.xorKeyStream(ciphertext)
+}
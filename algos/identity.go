@@ -0,0 +1,173 @@
+package algos
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+const (
+	identityRegistrationLabel = "Kygram identity key v1"
+	identityReplacementLabel  = "Kygram identity key replacement v1"
+	messageSignatureLabel     = "Kygram message signature v1"
+	sessionKeyLabel           = "Kygram session key v1"
+	senderKeyLabel            = "Kygram sender key v1"
+)
+
+var ErrInvalidSignature = errors.New("invalid signature")
+
+// IdentityKey — долговременный ключ подписи пользователя (Ed25519). Открытый ключ
+// передаётся в base64, как и ключи X25519.
+type IdentityKey struct {
+	private ed25519.PrivateKey
+}
+
+func GenerateIdentityKey() (*IdentityKey, error) {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate identity key: %w", err)
+	}
+	return &IdentityKey{private: private}, nil
+}
+
+// NewIdentityKey восстанавливает ключ по 32-байтному зерну, в котором он хранится
+func NewIdentityKey(seed []byte) (*IdentityKey, error) {
+	if len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf("identity key seed must be %d bytes, got %d", ed25519.SeedSize, len(seed))
+	}
+	return &IdentityKey{private: ed25519.NewKeyFromSeed(seed)}, nil
+}
+
+func (k *IdentityKey) Seed() []byte {
+	return k.private.Seed()
+}
+
+func (k *IdentityKey) PublicKey() string {
+	return base64.StdEncoding.EncodeToString(k.private.Public().(ed25519.PublicKey))
+}
+
+func (k *IdentityKey) Sign(payload []byte) []byte {
+	return ed25519.Sign(k.private, payload)
+}
+
+// CheckIdentityKey проверяет формат открытого ключа подписи
+func CheckIdentityKey(publicKey string) error {
+	_, err := parseIdentityKey(publicKey)
+	return err
+}
+
+func VerifySignature(publicKey string, payload, signature []byte) error {
+	key, err := parseIdentityKey(publicKey)
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(key, payload, signature) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+func parseIdentityKey(encoded string) (ed25519.PublicKey, error) {
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(raw) != ed25519.PublicKeySize {
+		return nil, ErrInvalidPublicKey
+	}
+	return ed25519.PublicKey(raw), nil
+}
+
+// IdentityRegistrationPayload — то, что подписывает пользователь при регистрации ключа,
+// доказывая владение закрытым ключом
+func IdentityRegistrationPayload(userID, publicKey string) []byte {
+	return lengthPrefixed([]string{identityRegistrationLabel, userID, publicKey})
+}
+
+// IdentityReplacementPayload — то, что подписывает прежний ключ личности, разрешая заменить
+// его ключом publicKey
+func IdentityReplacementPayload(userID, previousKey, publicKey string) []byte {
+	return lengthPrefixed([]string{identityReplacementLabel, userID, previousKey, publicKey})
+}
+
+const (
+	ClaimPrekey     = "prekey"
+	ClaimMessage    = "message"
+	ClaimSessionKey = "session_key"
+	ClaimSenderKey  = "sender_key"
+)
+
+// SessionKeyPayload — то, что владелец подписывает ключом личности, публикуя ключ подключения
+// устройства: отправители оборачивают цепочки только для подписанных ключей, иначе сервер
+// подставил бы свой ключ
+func SessionKeyPayload(chatID, userID, deviceID, keyID, publicKey string) []byte {
+	return lengthPrefixed([]string{sessionKeyLabel, chatID, userID, deviceID, keyID, publicKey})
+}
+
+// SenderKeyPayload — то, что отправитель подписывает, раздавая цепочку: одноразовый открытый
+// ключ шага храповика, по которому получатели вычисляют попарный ключ обёртки, и одноразовый
+// ключ X25519 копий цепочки для истории (historyKey)
+func SenderKeyPayload(chatID, senderID, keyID, ratchetKey, historyKey string) []byte {
+	return lengthPrefixed([]string{senderKeyLabel, chatID, senderID, keyID, ratchetKey, historyKey})
+}
+
+// SignatureClaim — что утверждает подпись под данными: вид данных, от чьего они имени и, для
+// сообщения, чат и номер
+type SignatureClaim struct {
+	Kind     string
+	UserID   string
+	ChatID   string
+	Sequence uint64
+}
+
+// ParseSignedPayload разбирает данные, которые сервер просит подписать клиента. Клиент так
+// проверяет, что подписывает своё, а регистрацию и замену ключа личности не подписывает по
+// чужой просьбе вовсе.
+func ParseSignedPayload(payload []byte) (SignatureClaim, error) {
+	fields, err := splitLengthPrefixed(payload)
+	if err != nil || len(fields) == 0 {
+		return SignatureClaim{}, fmt.Errorf("invalid signed payload: %v", err)
+	}
+	switch {
+	case fields[0] == signedPrekeyLabel && len(fields) == 4:
+		return SignatureClaim{Kind: ClaimPrekey, UserID: fields[1]}, nil
+	case fields[0] == sessionKeyLabel && len(fields) == 6:
+		return SignatureClaim{Kind: ClaimSessionKey, UserID: fields[2], ChatID: fields[1]}, nil
+	case fields[0] == senderKeyLabel && len(fields) == 6:
+		return SignatureClaim{Kind: ClaimSenderKey, UserID: fields[2], ChatID: fields[1]}, nil
+	case fields[0] == messageSignatureLabel && len(fields) == 9:
+		sequence, err := strconv.ParseUint(fields[3], 10, 64)
+		if err != nil {
+			return SignatureClaim{}, fmt.Errorf("invalid message sequence: %w", err)
+		}
+		return SignatureClaim{Kind: ClaimMessage, UserID: fields[2], ChatID: fields[1], Sequence: sequence}, nil
+	}
+	return SignatureClaim{}, errors.New("unsupported signed payload")
+}
+
+// SignedEnvelope — подписываемые поля сообщения. Номер Sequence растёт у каждого отправителя
+// в пределах чата, поэтому получатель замечает повтор старого сообщения.
+type SignedEnvelope struct {
+	ChatID      string
+	SenderID    string
+	Sequence    uint64
+	Timestamp   int64 // миллисекунды Unix
+	MessageType string
+	FileName    string
+	ChunkIndex  int32
+	Ciphertext  []byte
+}
+
+func (e SignedEnvelope) Payload() []byte {
+	return lengthPrefixed([]string{
+		messageSignatureLabel,
+		e.ChatID,
+		e.SenderID,
+		strconv.FormatUint(e.Sequence, 10),
+		strconv.FormatInt(e.Timestamp, 10),
+		e.MessageType,
+		e.FileName,
+		strconv.FormatInt(int64(e.ChunkIndex), 10),
+		string(e.Ciphertext),
+	})
+}
@@ -0,0 +1,125 @@
+package algos
+
+import (
+	"errors"
+	"testing"
+)
+
+func testEnvelope() SignedEnvelope {
+	return SignedEnvelope{
+		ChatID:      "chat",
+		SenderID:    "alice",
+		Sequence:    7,
+		Timestamp:   1700000000000,
+		MessageType: "text",
+		Ciphertext:  []byte("ciphertext"),
+	}
+}
+
+func TestIdentityKeySignsEnvelope(t *testing.T) {
+	key, err := GenerateIdentityKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	envelope := testEnvelope()
+	signature := key.Sign(envelope.Payload())
+	if err := VerifySignature(key.PublicKey(), envelope.Payload(), signature); err != nil {
+		t.Fatalf("valid signature rejected: %v", err)
+	}
+
+	// ключ, восстановленный из зерна, тот же
+	restored, err := NewIdentityKey(key.Seed())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored.PublicKey() != key.PublicKey() {
+		t.Fatal("key restored from the seed differs")
+	}
+	if _, err := NewIdentityKey(key.Seed()[:16]); err == nil {
+		t.Fatal("short seed accepted")
+	}
+}
+
+func TestTamperedEnvelopeRejected(t *testing.T) {
+	key, err := GenerateIdentityKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature := key.Sign(testEnvelope().Payload())
+
+	for name, tamper := range map[string]func(*SignedEnvelope){
+		"chat":        func(e *SignedEnvelope) { e.ChatID = "other chat" },
+		"sender":      func(e *SignedEnvelope) { e.SenderID = "mallory" },
+		"sequence":    func(e *SignedEnvelope) { e.Sequence++ },
+		"timestamp":   func(e *SignedEnvelope) { e.Timestamp++ },
+		"type":        func(e *SignedEnvelope) { e.MessageType = "file" },
+		"file name":   func(e *SignedEnvelope) { e.FileName = "a.txt" },
+		"chunk":       func(e *SignedEnvelope) { e.ChunkIndex = 1 },
+		"ciphertext":  func(e *SignedEnvelope) { e.Ciphertext = []byte("Ciphertext") },
+		"field split": func(e *SignedEnvelope) { e.MessageType, e.FileName = "tex", "t" },
+	} {
+		t.Run(name, func(t *testing.T) {
+			envelope := testEnvelope()
+			tamper(&envelope)
+			if err := VerifySignature(key.PublicKey(), envelope.Payload(), signature); !errors.Is(err, ErrInvalidSignature) {
+				t.Fatalf("tampered envelope: %v", err)
+			}
+		})
+	}
+
+	broken := append([]byte(nil), signature...)
+	broken[0] ^= 1
+	if err := VerifySignature(key.PublicKey(), testEnvelope().Payload(), broken); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("tampered signature: %v", err)
+	}
+}
+
+func TestSignatureWrongKey(t *testing.T) {
+	alice, err := GenerateIdentityKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	mallory, err := GenerateIdentityKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload := testEnvelope().Payload()
+	if err := VerifySignature(alice.PublicKey(), payload, mallory.Sign(payload)); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("signature by another key: %v", err)
+	}
+	if err := VerifySignature("not a key", payload, alice.Sign(payload)); !errors.Is(err, ErrInvalidPublicKey) {
+		t.Fatalf("malformed public key: %v", err)
+	}
+	if err := VerifySignature(alice.PublicKey(), payload, nil); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("empty signature: %v", err)
+	}
+}
+
+// TestSignedPayloadsDoNotCollide проверяет, что подпись под данными одного вида не подходит
+// к другому: метки назначения у всех разные
+func TestSignedPayloadsDoNotCollide(t *testing.T) {
+	key, err := GenerateIdentityKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessionKey := SessionKeyPayload("chat", "alice", "phone", "key", "public")
+	senderKey := SenderKeyPayload("chat", "alice", "phone", "key", "public")
+	if err := VerifySignature(key.PublicKey(), senderKey, key.Sign(sessionKey)); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("session key signature accepted for a sender key: %v", err)
+	}
+
+	for payload, want := range map[string]SignatureClaim{
+		string(sessionKey):                                {Kind: ClaimSessionKey, UserID: "alice", ChatID: "chat"},
+		string(senderKey):                                 {Kind: ClaimSenderKey, UserID: "alice", ChatID: "chat"},
+		string(testEnvelope().Payload()):                  {Kind: ClaimMessage, UserID: "alice", ChatID: "chat", Sequence: 7},
+		string(SignedPrekeyPayload("alice", 1, "public")): {Kind: ClaimPrekey, UserID: "alice"},
+	} {
+		claim, err := ParseSignedPayload([]byte(payload))
+		if err != nil || claim != want {
+			t.Fatalf("claim %+v, want %+v: %v", claim, want, err)
+		}
+	}
+	if _, err := ParseSignedPayload(IdentityRegistrationPayload("alice", key.PublicKey())); err == nil {
+		t.Fatal("identity registration offered for signing")
+	}
+}
@@ -0,0 +1,119 @@
+package algos
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+const (
+	// AuthKeySize — длина ключа аутентификации (HMAC-SHA-256)
+	AuthKeySize = 32
+
+	// KDFSaltSize — длина случайной соли чата
+	KDFSaltSize = 32
+
+	kdfLabel = "Kygram chat keys v1"
+
+	// SenderKeyPurpose — назначение попарных ключей, которыми цепочка отправителя
+	// оборачивается для получателя
+	SenderKeyPurpose = "sender key"
+)
+
+// ChatKeys — ключи, выведенные из общего секрета: отдельно для шифрования и для аутентификации
+type ChatKeys struct {
+	Encryption     []byte
+	Authentication []byte
+}
+
+// KeyDerivation задаёт контекст HKDF-SHA-256: соль и идентификатор чата,
+// а также длину ключа шифрования для шифра чата
+type KeyDerivation struct {
+	Salt    []byte
+	ChatID  string
+	KeySize int
+}
+
+// NewKeyDerivation собирает контекст вывода ключей для алгоритма чата
+func NewKeyDerivation(salt []byte, chatID, algorithm string) (KeyDerivation, error) {
+	keySize, err := CipherKeySize(algorithm)
+	if err != nil {
+		return KeyDerivation{}, err
+	}
+	return KeyDerivation{Salt: salt, ChatID: chatID, KeySize: keySize}, nil
+}
+
+// Derive выводит ключи из общего секрета, привязывая их к назначению purpose, чату
+// и участникам; порядок участников не важен, поэтому обе стороны получают одинаковые ключи
+func (d KeyDerivation) Derive(secret []byte, purpose string, participants ...string) (*ChatKeys, error) {
+	if len(secret) == 0 {
+		return nil, errors.New("empty shared secret")
+	}
+	if purpose == "" {
+		return nil, errors.New("empty key purpose")
+	}
+	if d.KeySize < 1 {
+		return nil, fmt.Errorf("invalid key size: %d", d.KeySize)
+	}
+
+	prk := hkdf.Extract(sha256.New, secret, d.Salt)
+
+	keys := &ChatKeys{
+		Encryption:     make([]byte, d.KeySize),
+		Authentication: make([]byte, AuthKeySize),
+	}
+	if _, err := io.ReadFull(hkdf.Expand(sha256.New, prk, d.info("encryption", purpose, participants)), keys.Encryption); err != nil {
+		return nil, fmt.Errorf("failed to derive encryption key: %w", err)
+	}
+	if _, err := io.ReadFull(hkdf.Expand(sha256.New, prk, d.info("authentication", purpose, participants)), keys.Authentication); err != nil {
+		return nil, fmt.Errorf("failed to derive authentication key: %w", err)
+	}
+	return keys, nil
+}
+
+// info кодирует вид ключа (шифрование или аутентификация), чат, назначение и отсортированный
+// список участников; каждое поле предваряется длиной, чтобы разные наборы не давали одинаковую
+// строку. Назначение стоит на своём месте и участником не считается.
+func (d KeyDerivation) info(kind, purpose string, participants []string) []byte {
+	sorted := append([]string(nil), participants...)
+	sort.Strings(sorted)
+
+	return lengthPrefixed(append([]string{kdfLabel, kind, d.ChatID, purpose}, sorted...))
+}
+
+func lengthPrefixed(fields []string) []byte {
+	var out []byte
+	for _, field := range fields {
+		out = binary.BigEndian.AppendUint32(out, uint32(len(field)))
+		out = append(out, field...)
+	}
+	return out
+}
+
+// splitLengthPrefixed разбирает строку, собранную lengthPrefixed
+func splitLengthPrefixed(data []byte) ([]string, error) {
+	var fields []string
+	for len(data) > 0 {
+		if len(data) < 4 {
+			return nil, errors.New("truncated field length")
+		}
+		n := binary.BigEndian.Uint32(data)
+		data = data[4:]
+		if uint64(n) > uint64(len(data)) {
+			return nil, errors.New("truncated field")
+		}
+		fields = append(fields, string(data[:n]))
+		data = data[n:]
+	}
+	return fields, nil
+}
+
+// NewKDFSalt создаёт случайную соль для нового чата
+func NewKDFSalt() ([]byte, error) {
+	return randomBytes(nil, KDFSaltSize)
+}
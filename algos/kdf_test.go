@@ -0,0 +1,165 @@
+package algos
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+)
+
+// hkdfSHA256 — HKDF по RFC 5869 на HMAC-SHA-256, написанный отдельно от golang.org/x/crypto/hkdf
+func hkdfSHA256(ikm, salt, info []byte, length int) (prk, okm []byte) {
+	if salt == nil {
+		salt = make([]byte, sha256.Size)
+	}
+	mac := hmac.New(sha256.New, salt)
+	mac.Write(ikm)
+	prk = mac.Sum(nil)
+
+	var block []byte
+	for i := byte(1); len(okm) < length; i++ {
+		mac := hmac.New(sha256.New, prk)
+		mac.Write(block)
+		mac.Write(info)
+		mac.Write([]byte{i})
+		block = mac.Sum(nil)
+		okm = append(okm, block...)
+	}
+	return prk, okm[:length]
+}
+
+func unhex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func byteRange(from, to int) []byte {
+	var b []byte
+	for i := from; i <= to; i++ {
+		b = append(b, byte(i))
+	}
+	return b
+}
+
+// TestHKDFVectors сверяет HKDF с тестовыми векторами RFC 5869 (приложение A, SHA-256)
+func TestHKDFVectors(t *testing.T) {
+	for _, tc := range []struct {
+		name            string
+		ikm, salt, info []byte
+		prk, okm        string
+	}{
+		{
+			name: "A.1 basic",
+			ikm:  bytes.Repeat([]byte{0x0b}, 22),
+			salt: byteRange(0x00, 0x0c),
+			info: byteRange(0xf0, 0xf9),
+			prk:  "077709362c2e32df0ddc3f0dc47bba6390b6c73bb50f9c3122ec844ad7c2b3e5",
+			okm:  "3cb25f25faacd57a90434f64d0362f2a2d2d0a90cf1a5a4c5db02d56ecc4c5bf34007208d5b887185865",
+		},
+		{
+			name: "A.2 long inputs",
+			ikm:  byteRange(0x00, 0x4f),
+			salt: byteRange(0x60, 0xaf),
+			info: byteRange(0xb0, 0xff),
+			prk:  "06a6b88c5853361a06104c9ceb35b45cef760014904671014a193f40c15fc244",
+			okm: "b11e398dc80327a1c8e7f78c596a49344f012eda2d4efad8a050cc4c19afa97c" +
+				"59045a99cac7827271cb41c65e590e09da3275600c2f09b8367793a9aca3db71" +
+				"cc30c58179ec3e87c14c01d5c1f3434f1d87",
+		},
+		{
+			name: "A.3 empty salt and info",
+			ikm:  bytes.Repeat([]byte{0x0b}, 22),
+			prk:  "19ef24a32c717b167f33a91d6f648bdf96596776afdb6377ac434c1c293ccb04",
+			okm:  "8da4e775a563c18f715f802a063c5a31b8a11f5c5ee1879ec3454e5f3c738d2d9d201395faa4b61a96c8",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			wantPRK, wantOKM := unhex(t, tc.prk), unhex(t, tc.okm)
+			prk, okm := hkdfSHA256(tc.ikm, tc.salt, tc.info, len(wantOKM))
+			if !bytes.Equal(prk, wantPRK) {
+				t.Fatalf("PRK %x, want %x", prk, wantPRK)
+			}
+			if !bytes.Equal(okm, wantOKM) {
+				t.Fatalf("OKM %x, want %x", okm, wantOKM)
+			}
+		})
+	}
+}
+
+// TestDeriveMatchesHKDF проверяет, что Derive — это HKDF-SHA-256 с солью чата и info из
+// метки, вида ключа, чата, назначения и отсортированных участников
+func TestDeriveMatchesHKDF(t *testing.T) {
+	secret := bytes.Repeat([]byte{0x0b}, 32)
+	kdf, err := NewKeyDerivation(byteRange(0x00, 0x1f), "chat", "rc5-32/12/16")
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys, err := kdf.Derive(secret, SenderKeyPurpose, "bob", "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys.Encryption) != 16 || len(keys.Authentication) != AuthKeySize {
+		t.Fatalf("key sizes %d and %d", len(keys.Encryption), len(keys.Authentication))
+	}
+
+	for kind, got := range map[string][]byte{"encryption": keys.Encryption, "authentication": keys.Authentication} {
+		info := lengthPrefixed([]string{kdfLabel, kind, "chat", SenderKeyPurpose, "alice", "bob"})
+		if _, want := hkdfSHA256(secret, kdf.Salt, info, len(got)); !bytes.Equal(got, want) {
+			t.Fatalf("%s key %x, want %x", kind, got, want)
+		}
+	}
+}
+
+func TestDeriveSeparatesContexts(t *testing.T) {
+	secret := bytes.Repeat([]byte{7}, 32)
+	kdf, err := NewKeyDerivation([]byte("salt"), "chat", "serpent")
+	if err != nil {
+		t.Fatal(err)
+	}
+	derive := func(kdf KeyDerivation, purpose string, participants ...string) []byte {
+		t.Helper()
+		keys, err := kdf.Derive(secret, purpose, participants...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return keys.Encryption
+	}
+
+	base := derive(kdf, SenderKeyPurpose, "alice", "bob")
+	if !bytes.Equal(base, derive(kdf, SenderKeyPurpose, "bob", "alice")) {
+		t.Fatal("participant order changes the key")
+	}
+	otherChat, otherSalt := kdf, kdf
+	otherChat.ChatID, otherSalt.Salt = "other chat", []byte("other salt")
+	for name, key := range map[string][]byte{
+		"purpose":      derive(kdf, "epoch:1", "alice", "bob"),
+		"participants": derive(kdf, SenderKeyPurpose, "alice", "carol"),
+		"chat":         derive(otherChat, SenderKeyPurpose, "alice", "bob"),
+		"salt":         derive(otherSalt, SenderKeyPurpose, "alice", "bob"),
+		// назначение не смешивается с участниками
+		"purpose as participant": derive(kdf, "alice", SenderKeyPurpose, "bob"),
+	} {
+		if bytes.Equal(key, base) {
+			t.Fatalf("changing the %s keeps the key", name)
+		}
+	}
+
+	keys, err := kdf.Derive(secret, SenderKeyPurpose)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(keys.Encryption, keys.Authentication[:len(keys.Encryption)]) {
+		t.Fatal("encryption and authentication keys coincide")
+	}
+	if _, err := kdf.Derive(nil, SenderKeyPurpose); err == nil {
+		t.Fatal("empty secret accepted")
+	}
+	if _, err := kdf.Derive(secret, ""); err == nil {
+		t.Fatal("empty purpose accepted")
+	}
+}
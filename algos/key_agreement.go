@@ -0,0 +1,175 @@
+package algos
+
+import (
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+)
+
+// KeyAgreement — алгоритм согласования ключей чата. Открытые ключи передаются и хранятся
+// в текстовом виде: десятичное число для классического Диффи-Хеллмана, base64 для X25519.
+type KeyAgreement interface {
+	GenerateKey() (AgreementKey, error)
+	// CheckPublicKey проверяет открытый ключ собеседника до того, как он попадёт в session_keys
+	CheckPublicKey(encoded string) error
+}
+
+// AgreementKey — закрытый ключ согласования
+type AgreementKey interface {
+	PublicKey() string
+	// SharedSecret проверяет ключ собеседника и возвращает общий секрет фиксированной длины
+	SharedSecret(peerPublicKey string) ([]byte, error)
+}
+
+const (
+	KeyAgreementDH     = "dh"
+	KeyAgreementX25519 = "x25519"
+
+	// DefaultKeyAgreement используется для чатов, в которых алгоритм не выбран
+	DefaultKeyAgreement = KeyAgreementDH
+)
+
+type KeyAgreementInfo struct {
+	Name        string
+	DisplayName string
+}
+
+var keyAgreements = []KeyAgreementInfo{
+	{Name: KeyAgreementDH, DisplayName: "Diffie-Hellman (finite field)"},
+	{Name: KeyAgreementX25519, DisplayName: "X25519"},
+}
+
+func LookupKeyAgreement(name string) (KeyAgreementInfo, bool) {
+	for _, info := range keyAgreements {
+		if info.Name == name {
+			return info, true
+		}
+	}
+	return KeyAgreementInfo{}, false
+}
+
+func KeyAgreements() []KeyAgreementInfo {
+	return append([]KeyAgreementInfo(nil), keyAgreements...)
+}
+
+// NewKeyAgreement восстанавливает алгоритм согласования чата по сохранённым параметрам:
+// стандартную группу Диффи-Хеллмана — по имени, сгенерированную — по простому и генератору.
+// Пустое имя алгоритма означает Диффи-Хеллмана.
+func NewKeyAgreement(name, dhGroup, prime, generator string) (KeyAgreement, error) {
+	switch name {
+	case KeyAgreementX25519:
+		return X25519{}, nil
+	case KeyAgreementDH, "":
+	default:
+		return nil, fmt.Errorf("unsupported key agreement: %s", name)
+	}
+
+	if info, ok := LookupDHGroup(dhGroup); ok && !info.Generated {
+		return info.New()
+	}
+	p, ok := new(big.Int).SetString(prime, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid prime of group %q", dhGroup)
+	}
+	g, ok := new(big.Int).SetString(generator, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid generator of group %q", dhGroup)
+	}
+	return NewDHGroup(p, g)
+}
+
+func (g *DHGroup) GenerateKey() (AgreementKey, error) {
+	privateKey, err := g.GeneratePrivateKey()
+	if err != nil {
+		return nil, err
+	}
+	return &dhKey{group: g, private: privateKey}, nil
+}
+
+func (g *DHGroup) CheckPublicKey(encoded string) error {
+	_, err := g.parsePublicKey(encoded)
+	return err
+}
+
+func (g *DHGroup) parsePublicKey(encoded string) (*big.Int, error) {
+	y, ok := new(big.Int).SetString(encoded, 10)
+	if !ok {
+		return nil, ErrInvalidPublicKey
+	}
+	if err := g.ValidatePublicKey(y); err != nil {
+		return nil, err
+	}
+	return y, nil
+}
+
+type dhKey struct {
+	group   *DHGroup
+	private *big.Int
+}
+
+func (k *dhKey) PublicKey() string {
+	return k.group.PublicKey(k.private).String()
+}
+
+func (k *dhKey) SharedSecret(peerPublicKey string) ([]byte, error) {
+	y, err := k.group.parsePublicKey(peerPublicKey)
+	if err != nil {
+		return nil, err
+	}
+	return k.group.SecretBytes(GenerateSharedKey(k.private, y, k.group.Prime)), nil
+}
+
+// X25519 — согласование на кривой Curve25519 (RFC 7748): ключи по 32 байта, генерация мгновенная
+type X25519 struct{}
+
+func (X25519) GenerateKey() (AgreementKey, error) {
+	privateKey, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return x25519Key{privateKey}, nil
+}
+
+// CheckPublicKey кроме длины отвергает точки малого порядка: с ними общий секрет нулевой
+func (x X25519) CheckPublicKey(encoded string) error {
+	key, err := x.GenerateKey()
+	if err != nil {
+		return err
+	}
+	_, err = key.SharedSecret(encoded)
+	return err
+}
+
+func parseX25519PublicKey(encoded string) (*ecdh.PublicKey, error) {
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidPublicKey
+	}
+	publicKey, err := ecdh.X25519().NewPublicKey(raw)
+	if err != nil {
+		return nil, ErrInvalidPublicKey
+	}
+	return publicKey, nil
+}
+
+type x25519Key struct {
+	private *ecdh.PrivateKey
+}
+
+func (k x25519Key) PublicKey() string {
+	return base64.StdEncoding.EncodeToString(k.private.PublicKey().Bytes())
+}
+
+func (k x25519Key) SharedSecret(peerPublicKey string) ([]byte, error) {
+	publicKey, err := parseX25519PublicKey(peerPublicKey)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := k.private.ECDH(publicKey)
+	if err != nil {
+		return nil, ErrInvalidPublicKey
+	}
+	return secret, nil
+}
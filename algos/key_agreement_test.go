@@ -0,0 +1,104 @@
+package algos
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"encoding/base64"
+	"errors"
+	"testing"
+)
+
+func testX25519Key(t *testing.T, private string) x25519Key {
+	t.Helper()
+	key, err := ecdh.X25519().NewPrivateKey(unhex(t, private))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return x25519Key{key}
+}
+
+func b64(t *testing.T, s string) string {
+	t.Helper()
+	return base64.StdEncoding.EncodeToString(unhex(t, s))
+}
+
+// TestX25519Vectors сверяет ключи и общий секрет с примером RFC 7748, раздел 6.1
+func TestX25519Vectors(t *testing.T) {
+	alice := testX25519Key(t, "77076d0a7318a57d3c16c17251b26645df4c2f87ebc0992ab177fba51db92c2a")
+	bob := testX25519Key(t, "5dab087e624a8a4b79e17f8b83800ee66f3bb1292618b6fd1c2f8b27ff88e0eb")
+	alicePublic := b64(t, "8520f0098930a754748b7ddcb43ef75a0dbf3a0d26381af4eba4a98eaa9b4e6a")
+	bobPublic := b64(t, "de9edb7d7b7dc1b4d35b61c2ece435373f8343c85b78674dadfc7e146f882b4f")
+	shared := unhex(t, "4a5d9d5ba4ce2de1728e3bf480350f25e07e21c947d19e3376f09b3c1e161742")
+
+	if alice.PublicKey() != alicePublic || bob.PublicKey() != bobPublic {
+		t.Fatal("public keys differ from RFC 7748")
+	}
+	for name, got := range map[string]func() ([]byte, error){
+		"alice": func() ([]byte, error) { return alice.SharedSecret(bobPublic) },
+		"bob":   func() ([]byte, error) { return bob.SharedSecret(alicePublic) },
+	} {
+		secret, err := got()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(secret, shared) {
+			t.Fatalf("%s computed %x, want %x", name, secret, shared)
+		}
+	}
+}
+
+func TestX25519Agreement(t *testing.T) {
+	alice, err := X25519{}.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	bob, err := X25519{}.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ab, err := alice.SharedSecret(bob.PublicKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ba, err := bob.SharedSecret(alice.PublicKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(ab, ba) || len(ab) != 32 {
+		t.Fatal("parties disagree")
+	}
+	if err := (X25519{}).CheckPublicKey(bob.PublicKey()); err != nil {
+		t.Fatalf("valid key rejected: %v", err)
+	}
+}
+
+// TestX25519RejectsLowOrderPoints проверяет точки малого порядка (дают нулевой секрет)
+// и ключи неправильного вида
+func TestX25519RejectsLowOrderPoints(t *testing.T) {
+	key, err := X25519{}.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, encoded := range map[string]string{
+		"zero":       b64(t, "0000000000000000000000000000000000000000000000000000000000000000"),
+		"one":        b64(t, "0100000000000000000000000000000000000000000000000000000000000000"),
+		"order 8":    b64(t, "e0eb7a7c3b41b8ae1656e3faf19fc46ada098deb9c32b1fd866205165f49b800"),
+		"order 8'":   b64(t, "5f9c95bca3508c24b1d0b1559c83ef5b04445cc4581c8e86d8224eddd09f1157"),
+		"p-1":        b64(t, "ecffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff7f"),
+		"p":          b64(t, "edffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff7f"),
+		"p+1":        b64(t, "eeffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff7f"),
+		"short":      b64(t, "0900000000000000000000000000000000000000000000000000000000000000"[2:]),
+		"long":       b64(t, "090000000000000000000000000000000000000000000000000000000000000000"),
+		"not base64": "not base64!",
+		"empty":      "",
+	} {
+		t.Run(name, func(t *testing.T) {
+			if err := (X25519{}).CheckPublicKey(encoded); !errors.Is(err, ErrInvalidPublicKey) {
+				t.Fatalf("key accepted: %v", err)
+			}
+			if _, err := key.SharedSecret(encoded); !errors.Is(err, ErrInvalidPublicKey) {
+				t.Fatalf("shared secret computed: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package algos
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"fmt"
+	"strconv"
+)
+
+// WrapKey шифрует ключ для передачи по попарному каналу: шифр чата в режиме CTR на ключе
+// шифрования, затем HMAC-SHA-256 на ключе аутентификации по binding и конверту (encrypt-then-MAC).
+// binding связывает обёртку, например, с чатом, отправителем, получателем и идентификатором ключа.
+func WrapKey(algorithm string, keys *ChatKeys, key []byte, binding ...string) ([]byte, error) {
+	ctx, err := keyWrapContext(algorithm, keys)
+	if err != nil {
+		return nil, err
+	}
+	envelope, err := ctx.Encrypt(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to wrap key: %w", err)
+	}
+	return append(envelope, keyWrapTag(keys, binding, envelope)...), nil
+}
+
+// UnwrapKey проверяет тег и расшифровывает ключ; любая ошибка сводится к ErrDecrypt
+func UnwrapKey(algorithm string, keys *ChatKeys, wrapped []byte, binding ...string) ([]byte, error) {
+	if len(wrapped) < sha256.Size {
+		return nil, ErrDecrypt
+	}
+	envelope, tag := wrapped[:len(wrapped)-sha256.Size], wrapped[len(wrapped)-sha256.Size:]
+	if !hmac.Equal(tag, keyWrapTag(keys, binding, envelope)) {
+		return nil, ErrDecrypt
+	}
+
+	ctx, err := keyWrapContext(algorithm, keys)
+	if err != nil {
+		return nil, err
+	}
+	key, err := ctx.Decrypt(envelope)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return key, nil
+}
+
+// WrapEpochSecret оборачивает случайный секрет эпохи для участника userID: ключи обёртки
+// выводятся из общего секрета с одноразовым ключом ephemeralKey, которым оборачивает сервер
+func WrapEpochSecret(kdf KeyDerivation, algorithm string, shared, secret []byte, userID, ephemeralKey string, epoch uint32) ([]byte, error) {
+	keys, err := kdf.Derive(shared, epochSecretLabel, userID)
+	if err != nil {
+		return nil, err
+	}
+	return WrapKey(algorithm, keys, secret, epochSecretBinding(kdf.ChatID, userID, ephemeralKey, epoch)...)
+}
+
+// UnwrapEpochSecret — обратное к WrapEpochSecret на стороне участника
+func UnwrapEpochSecret(kdf KeyDerivation, algorithm string, shared, wrapped []byte, userID, ephemeralKey string, epoch uint32) ([]byte, error) {
+	keys, err := kdf.Derive(shared, epochSecretLabel, userID)
+	if err != nil {
+		return nil, err
+	}
+	return UnwrapKey(algorithm, keys, wrapped, epochSecretBinding(kdf.ChatID, userID, ephemeralKey, epoch)...)
+}
+
+const epochSecretLabel = "epoch secret"
+
+func epochSecretBinding(chatID, userID, ephemeralKey string, epoch uint32) []string {
+	return []string{epochSecretLabel, chatID, userID, strconv.FormatUint(uint64(epoch), 10), ephemeralKey}
+}
+
+func keyWrapContext(algorithm string, keys *ChatKeys) (*EncryptionContext, error) {
+	cipher, err := NewCipher(algorithm)
+	if err != nil {
+		return nil, err
+	}
+	expander, ok := cipher.(KeyExpander)
+	if !ok {
+		return nil, fmt.Errorf("cipher doesn't support key expansion")
+	}
+	if err := cipher.CipherKey(keys.Encryption); err != nil {
+		return nil, err
+	}
+	return NewEncryptionContext(keys.Encryption, CTR, NoPadding, nil, cipher, expander)
+}
+
+func keyWrapTag(keys *ChatKeys, binding []string, envelope []byte) []byte {
+	mac := hmac.New(sha256.New, keys.Authentication)
+	mac.Write(lengthPrefixed(binding))
+	mac.Write(envelope)
+	return mac.Sum(nil)
+}
@@ -0,0 +1,140 @@
+package algos
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func testWrapKeys(t *testing.T, algorithm string, secret byte) (KeyDerivation, *ChatKeys) {
+	t.Helper()
+	kdf, err := NewKeyDerivation([]byte("salt"), "chat", algorithm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys, err := kdf.Derive(bytes.Repeat([]byte{secret}, 32), SenderKeyPurpose, "alice", "bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return kdf, keys
+}
+
+func TestKeyWrapRoundTrip(t *testing.T) {
+	for _, algorithm := range []string{"rc5-32/12/16", "twofish", "serpent", "camellia"} {
+		t.Run(algorithm, func(t *testing.T) {
+			_, keys := testWrapKeys(t, algorithm, 1)
+			key := testChainKey(t)
+			wrapped, err := WrapKey(algorithm, keys, key, "chat", "alice", "bob")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if bytes.Contains(wrapped, key) {
+				t.Fatal("wrapped key contains the key")
+			}
+			got, err := UnwrapKey(algorithm, keys, wrapped, "chat", "alice", "bob")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, key) {
+				t.Fatal("unwrapped a different key")
+			}
+		})
+	}
+}
+
+func TestKeyWrapRejectsTampering(t *testing.T) {
+	const algorithm = "twofish"
+	_, keys := testWrapKeys(t, algorithm, 1)
+	_, otherKeys := testWrapKeys(t, algorithm, 2)
+	binding := []string{"chat", "alice", "bob"}
+	wrapped, err := WrapKey(algorithm, keys, testChainKey(t), binding...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	flip := func(i int) []byte {
+		tampered := append([]byte(nil), wrapped...)
+		tampered[i] ^= 1
+		return tampered
+	}
+	swapped := *keys
+	swapped.Encryption = otherKeys.Encryption
+
+	for _, tc := range []struct {
+		name    string
+		keys    *ChatKeys
+		wrapped []byte
+		binding []string
+	}{
+		{"envelope byte", keys, flip(0), binding},
+		{"last envelope byte", keys, flip(len(wrapped) - 33), binding},
+		{"tag byte", keys, flip(len(wrapped) - 1), binding},
+		{"truncated", keys, wrapped[:len(wrapped)-1], binding},
+		{"tag only", keys, wrapped[len(wrapped)-32:], binding},
+		{"empty", keys, nil, binding},
+		{"other recipient", keys, wrapped, []string{"chat", "alice", "carol"}},
+		// длины в binding не дают сдвинуть границу между полями
+		{"shifted binding", keys, wrapped, []string{"chat", "aliceb", "ob"}},
+		{"no binding", keys, wrapped, nil},
+		{"other keys", otherKeys, wrapped, binding},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := UnwrapKey(algorithm, tc.keys, tc.wrapped, tc.binding...); !errors.Is(err, ErrDecrypt) {
+				t.Fatalf("tampered wrapping accepted: %v", err)
+			}
+		})
+	}
+
+	// тег не зависит от ключа шифрования, но расшифровка чужим ключом даёт не тот ключ
+	original, err := UnwrapKey(algorithm, keys, wrapped, binding...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key, err := UnwrapKey(algorithm, &swapped, wrapped, binding...); err == nil && bytes.Equal(key, original) {
+		t.Fatal("wrong encryption key recovered the key")
+	}
+}
+
+func TestEpochSecretWrap(t *testing.T) {
+	const algorithm = "serpent"
+	kdf, _ := testWrapKeys(t, algorithm, 1)
+	shared := bytes.Repeat([]byte{3}, 32)
+	secret := testChainKey(t)
+
+	wrapped, err := WrapEpochSecret(kdf, algorithm, shared, secret, "alice", "ephemeral", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := UnwrapEpochSecret(kdf, algorithm, shared, wrapped, "alice", "ephemeral", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, secret) {
+		t.Fatal("unwrapped a different epoch secret")
+	}
+
+	otherChat := kdf
+	otherChat.ChatID = "other chat"
+	for name, unwrap := range map[string]func() ([]byte, error){
+		"other user": func() ([]byte, error) {
+			return UnwrapEpochSecret(kdf, algorithm, shared, wrapped, "bob", "ephemeral", 2)
+		},
+		"other epoch": func() ([]byte, error) {
+			return UnwrapEpochSecret(kdf, algorithm, shared, wrapped, "alice", "ephemeral", 3)
+		},
+		"other ephemeral key": func() ([]byte, error) {
+			return UnwrapEpochSecret(kdf, algorithm, shared, wrapped, "alice", "replayed", 2)
+		},
+		"other chat": func() ([]byte, error) {
+			return UnwrapEpochSecret(otherChat, algorithm, shared, wrapped, "alice", "ephemeral", 2)
+		},
+		"other shared secret": func() ([]byte, error) {
+			return UnwrapEpochSecret(kdf, algorithm, bytes.Repeat([]byte{4}, 32), wrapped, "alice", "ephemeral", 2)
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := unwrap(); !errors.Is(err, ErrDecrypt) {
+				t.Fatalf("epoch secret unwrapped: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package algos
+
+import "fmt"
+
+// MessageAAD привязывает шифртекст сообщения к чату и отправителю (используется в режиме GCM)
+func MessageAAD(chatID, senderID string) []byte {
+	return []byte(chatID + ":" + senderID)
+}
+
+// NewMessageContext собирает контекст шифрования сообщений чата по именам шифра, режима
+// и набивки из реестра; так сообщения шифруют клиенты, у которых нет серверного ChatService
+func NewMessageContext(algorithm, mode, padding string, key []byte) (*EncryptionContext, error) {
+	cipher, err := NewCipher(algorithm)
+	if err != nil {
+		return nil, err
+	}
+	modeInfo, ok := LookupMode(mode)
+	if !ok {
+		return nil, fmt.Errorf("unsupported mode: %s", mode)
+	}
+	paddingInfo, ok := LookupPadding(padding)
+	if !ok {
+		return nil, fmt.Errorf("unsupported padding: %s", padding)
+	}
+	if !modeInfo.AcceptsPadding(paddingInfo) {
+		return nil, fmt.Errorf("mode %s doesn't support padding %s", mode, padding)
+	}
+	expander, ok := cipher.(KeyExpander)
+	if !ok {
+		return nil, fmt.Errorf("cipher %s doesn't support key expansion", algorithm)
+	}
+	return NewEncryptionContext(key, modeInfo.Mode, paddingInfo.Padding, nil, cipher, expander)
+}
@@ -0,0 +1,82 @@
+package algos
+
+import (
+	"runtime"
+	"sync"
+)
+
+// ParallelConfig задаёт, когда независимые блоки обрабатываются пулом горутин
+type ParallelConfig struct {
+	Threshold int // минимальный размер данных в байтах; 0 — всегда последовательно
+	Workers   int // размер пула; 0 — по числу процессоров
+}
+
+// DefaultParallelConfig копируется в каждый новый EncryptionContext
+var DefaultParallelConfig = ParallelConfig{Threshold: 64 * 1024}
+
+func (pc ParallelConfig) workers() int {
+	if pc.Workers > 0 {
+		return pc.Workers
+	}
+	return runtime.GOMAXPROCS(0)
+}
+
+// processBlocks вызывает fn для каждого блока src (i — номер блока, dst — место под результат);
+// последний блок может быть неполным. Блоки должны быть независимы: выше порога они
+// делятся на непрерывные диапазоны между горутинами, поэтому результат совпадает
+// с последовательным проходом.
+func (ctx *EncryptionContext) processBlocks(dst, src []byte, fn func(i int, dst, src []byte) error) error {
+	blockSize := ctx.Cipher.BlockSize()
+	numBlocks := (len(src) + blockSize - 1) / blockSize
+
+	workers := ctx.Parallel.workers()
+	if ctx.Parallel.Threshold <= 0 || len(src) < ctx.Parallel.Threshold || workers < 2 || numBlocks < 2 {
+		return processBlockRange(dst, src, blockSize, 0, numBlocks, fn)
+	}
+	if workers > numBlocks {
+		workers = numBlocks
+	}
+
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+	)
+	perWorker := (numBlocks + workers - 1) / workers
+	for start := 0; start < numBlocks; start += perWorker {
+		end := min(start+perWorker, numBlocks)
+		wg.Add(1)
+		go func(start, end int) {
+			defer wg.Done()
+			if err := processBlockRange(dst, src, blockSize, start, end, fn); err != nil {
+				errOnce.Do(func() { firstErr = err })
+			}
+		}(start, end)
+	}
+	wg.Wait()
+
+	return firstErr
+}
+
+func processBlockRange(dst, src []byte, blockSize, start, end int, fn func(i int, dst, src []byte) error) error {
+	for i := start; i < end; i++ {
+		blockEnd := min((i+1)*blockSize, len(src))
+		if err := fn(i, dst[i*blockSize:blockEnd], src[i*blockSize:blockEnd]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addToCounter возвращает counter + n как big-endian число по модулю 2^(8*len(counter)),
+// поэтому счётчик любого блока CTR вычисляется без прохода по предыдущим
+func addToCounter(counter []byte, n int) []byte {
+	result := append([]byte(nil), counter...)
+	carry := uint64(n)
+	for i := len(result) - 1; i >= 0 && carry > 0; i-- {
+		sum := uint64(result[i]) + carry&0xff
+		result[i] = byte(sum)
+		carry = carry>>8 + sum>>8
+	}
+	return result
+}
@@ -0,0 +1,123 @@
+package algos
+
+import (
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+)
+
+const signedPrekeyLabel = "Kygram signed prekey v1"
+
+// HistoryDeviceID — устройство получателя у копии цепочки для истории: она обёрнута по его
+// подписанному предварительному ключу и читается без подключения. Настоящее устройство так
+// называться не может.
+const HistoryDeviceID = "history"
+
+// Prekey — предварительный ключ X25519, который пользователь публикует заранее, чтобы с ним
+// можно было согласовать ключ, пока он не в сети (X3DH). Подписанный ключ живёт долго,
+// одноразовые выдаются сервером по одному на каждый запрос набора.
+type Prekey struct {
+	ID      uint32
+	private *ecdh.PrivateKey
+}
+
+func GeneratePrekey(id uint32) (*Prekey, error) {
+	private, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate prekey: %w", err)
+	}
+	return &Prekey{ID: id, private: private}, nil
+}
+
+// NewPrekey восстанавливает ключ по закрытой части из хранилища клиента
+func NewPrekey(id uint32, private []byte) (*Prekey, error) {
+	key, err := ecdh.X25519().NewPrivateKey(private)
+	if err != nil {
+		return nil, fmt.Errorf("invalid prekey %d: %w", id, err)
+	}
+	return &Prekey{ID: id, private: key}, nil
+}
+
+func (p *Prekey) PrivateBytes() []byte {
+	return p.private.Bytes()
+}
+
+func (p *Prekey) PublicKey() string {
+	return base64.StdEncoding.EncodeToString(p.private.PublicKey().Bytes())
+}
+
+func (p *Prekey) SharedSecret(peerPublicKey string) ([]byte, error) {
+	return x25519Key{p.private}.SharedSecret(peerPublicKey)
+}
+
+// SignedPrekeyPayload — то, что владелец подписывает ключом личности, публикуя подписанный ключ
+func SignedPrekeyPayload(userID string, id uint32, publicKey string) []byte {
+	return lengthPrefixed([]string{signedPrekeyLabel, userID, strconv.FormatUint(uint64(id), 10), publicKey})
+}
+
+// PrekeyBundle — набор ключей получателя, выданный отправителю. OneTimePrekeyID равен нулю,
+// если одноразовые ключи у получателя закончились.
+type PrekeyBundle struct {
+	UserID                string
+	IdentityKey           string
+	SignedPrekeyID        uint32
+	SignedPrekey          string
+	SignedPrekeySignature []byte
+	OneTimePrekeyID       uint32
+	OneTimePrekey         string
+}
+
+// Verify проверяет подпись подписанного ключа ключом личности получателя
+func (b *PrekeyBundle) Verify() error {
+	return VerifySignature(b.IdentityKey, SignedPrekeyPayload(b.UserID, b.SignedPrekeyID, b.SignedPrekey), b.SignedPrekeySignature)
+}
+
+// X3DHInitiate вычисляет общий секрет отправителя по одноразовому ключу ephemeral и набору
+// получателя: DH(EK, SPK) || DH(EK, OPK). Ключи личности — ключи подписи Ed25519, поэтому
+// в согласовании они не участвуют: ни DH(IK_A, SPK_B), ни DH(EK, IK_B) здесь нет, а для
+// них пришлось бы переводить ключи Ed25519 в X25519 (как в XEdDSA) и держать один ключ для
+// подписи и согласования. Их роль выполняют подписи:
+//   - получателя подтверждает подпись SPK его ключом личности (Verify), без неё секрет не
+//     вычисляется;
+//   - отправителя вместо DH(IK_A, SPK_B) подтверждает подпись EK его ключом личности
+//     (SenderKeyPayload), которую получатель проверяет до разворачивания цепочки, и подписи
+//     его сообщений.
+//
+// Плата за это — отрицаемость: подпись доказывает третьему лицу, что EK выпустил отправитель,
+// тогда как в исходном X3DH секрет мог вычислить и сам получатель.
+func X3DHInitiate(ephemeral AgreementKey, bundle *PrekeyBundle) ([]byte, error) {
+	if err := bundle.Verify(); err != nil {
+		return nil, fmt.Errorf("signed prekey of %s: %w", bundle.UserID, err)
+	}
+	secret, err := ephemeral.SharedSecret(bundle.SignedPrekey)
+	if err != nil {
+		return nil, err
+	}
+	if bundle.OneTimePrekeyID == 0 {
+		return secret, nil
+	}
+	oneTime, err := ephemeral.SharedSecret(bundle.OneTimePrekey)
+	if err != nil {
+		return nil, err
+	}
+	return append(secret, oneTime...), nil
+}
+
+// X3DHRespond вычисляет тот же секрет на стороне получателя; oneTime равен nil, если
+// отправителю одноразовый ключ не достался
+func X3DHRespond(signed, oneTime *Prekey, ephemeralPublicKey string) ([]byte, error) {
+	secret, err := signed.SharedSecret(ephemeralPublicKey)
+	if err != nil {
+		return nil, err
+	}
+	if oneTime == nil {
+		return secret, nil
+	}
+	second, err := oneTime.SharedSecret(ephemeralPublicKey)
+	if err != nil {
+		return nil, err
+	}
+	return append(secret, second...), nil
+}
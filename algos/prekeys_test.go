@@ -0,0 +1,136 @@
+package algos
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// testBundle публикует подписанный и одноразовый предварительные ключи получателя
+func testBundle(t *testing.T) (*PrekeyBundle, *IdentityKey, *Prekey, *Prekey) {
+	t.Helper()
+	identity, err := GenerateIdentityKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed, err := GeneratePrekey(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	oneTime, err := GeneratePrekey(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bundle := &PrekeyBundle{
+		UserID:                "bob",
+		IdentityKey:           identity.PublicKey(),
+		SignedPrekeyID:        signed.ID,
+		SignedPrekey:          signed.PublicKey(),
+		SignedPrekeySignature: identity.Sign(SignedPrekeyPayload("bob", signed.ID, signed.PublicKey())),
+		OneTimePrekeyID:       oneTime.ID,
+		OneTimePrekey:         oneTime.PublicKey(),
+	}
+	return bundle, identity, signed, oneTime
+}
+
+func TestX3DHAgreement(t *testing.T) {
+	bundle, _, signed, oneTime := testBundle(t)
+	ephemeral, err := X25519{}.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	initiator, err := X3DHInitiate(ephemeral, bundle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	responder, err := X3DHRespond(signed, oneTime, ephemeral.PublicKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(initiator, responder) {
+		t.Fatal("initiator and responder disagree")
+	}
+	if len(initiator) != 64 {
+		t.Fatalf("secret with a one-time prekey has %d bytes, want DH(EK, SPK) || DH(EK, OPK)", len(initiator))
+	}
+
+	// чужой одноразовый ключ даёт другой секрет
+	another, err := GeneratePrekey(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wrong, err := X3DHRespond(signed, another, ephemeral.PublicKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(initiator, wrong) {
+		t.Fatal("secret does not depend on the one-time prekey")
+	}
+}
+
+func TestX3DHWithoutOneTimePrekey(t *testing.T) {
+	bundle, _, signed, oneTime := testBundle(t)
+	bundle.OneTimePrekeyID, bundle.OneTimePrekey = 0, ""
+	ephemeral, err := X25519{}.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	initiator, err := X3DHInitiate(ephemeral, bundle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	responder, err := X3DHRespond(signed, nil, ephemeral.PublicKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(initiator, responder) {
+		t.Fatal("initiator and responder disagree without a one-time prekey")
+	}
+	// получатель, решивший, что одноразовый ключ был, секрета не получит
+	withOneTime, err := X3DHRespond(signed, oneTime, ephemeral.PublicKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(initiator, withOneTime) {
+		t.Fatal("one-time prekey ignored by the responder")
+	}
+}
+
+func TestX3DHRejectsBadSignedPrekey(t *testing.T) {
+	ephemeral, err := X25519{}.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	mallory, err := GenerateIdentityKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	forged, err := GeneratePrekey(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, tamper := range map[string]func(*PrekeyBundle){
+		"broken signature":  func(b *PrekeyBundle) { b.SignedPrekeySignature[0] ^= 1 },
+		"missing signature": func(b *PrekeyBundle) { b.SignedPrekeySignature = nil },
+		// сервер подставил свой ключ, оставив подпись настоящего
+		"substituted key": func(b *PrekeyBundle) { b.SignedPrekey = forged.PublicKey() },
+		"substituted id":  func(b *PrekeyBundle) { b.SignedPrekeyID++ },
+		"other user":      func(b *PrekeyBundle) { b.UserID = "carol" },
+		// подписано не ключом личности получателя
+		"foreign signer": func(b *PrekeyBundle) {
+			b.SignedPrekey = forged.PublicKey()
+			b.SignedPrekeySignature = mallory.Sign(SignedPrekeyPayload(b.UserID, b.SignedPrekeyID, b.SignedPrekey))
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			bundle, _, _, _ := testBundle(t)
+			tamper(bundle)
+			if _, err := X3DHInitiate(ephemeral, bundle); !errors.Is(err, ErrInvalidSignature) {
+				t.Fatalf("bundle accepted: %v", err)
+			}
+		})
+	}
+}
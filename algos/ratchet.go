@@ -0,0 +1,181 @@
+package algos
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+const (
+	// MaxSkip — на сколько сообщений номер может опередить цепочку получателя
+	MaxSkip = 1000
+
+	// MaxSkippedKeys — общий предел кэша ключей пропущенных сообщений; старые ключи вытесняются
+	MaxSkippedKeys = 2000
+
+	messageKeyLabel = "Kygram message key v1"
+)
+
+var (
+	// ErrMessageKeyUnavailable — ключ сообщения уже использован, вытеснен из кэша или цепочка ушла дальше
+	ErrMessageKeyUnavailable = errors.New("message key is no longer available")
+	ErrTooManySkipped        = errors.New("too many skipped messages")
+)
+
+// SendingChain — симметричный храповик отправителя (KDF_CK из спецификации Double Ratchet):
+// ключ сообщения выводится из ключа цепочки, после чего ключ цепочки заменяется следующим,
+// поэтому по текущему состоянию прежние ключи сообщений не восстановить
+type SendingChain struct {
+	chainKey []byte
+	index    uint32
+	keySize  int
+}
+
+func NewSendingChain(chainKey []byte, keySize int) *SendingChain {
+	return &SendingChain{chainKey: append([]byte(nil), chainKey...), keySize: keySize}
+}
+
+// Next возвращает номер и ключ очередного сообщения
+func (c *SendingChain) Next() (uint32, []byte, error) {
+	messageKey, err := chainMessageKey(c.chainKey, c.keySize)
+	if err != nil {
+		return 0, nil, err
+	}
+	index := c.index
+	c.chainKey = nextChainKey(c.chainKey)
+	c.index++
+	return index, messageKey, nil
+}
+
+// Index — номер следующего сообщения цепочки
+func (c *SendingChain) Index() uint32 {
+	return c.index
+}
+
+// ReceivingChain — цепочка получателя; ключи сообщений, пришедших не по порядку,
+// ждут в общем кэше SkippedKeys
+type ReceivingChain struct {
+	id       string
+	chainKey []byte
+	index    uint32
+	keySize  int
+	skipped  *SkippedKeys
+}
+
+// NewReceivingChain создаёт цепочку с идентификатором id, уникальным в пределах кэша skipped
+func NewReceivingChain(id string, chainKey []byte, keySize int, skipped *SkippedKeys) *ReceivingChain {
+	return &ReceivingChain{id: id, chainKey: append([]byte(nil), chainKey...), keySize: keySize, skipped: skipped}
+}
+
+// MessageKey возвращает ключ сообщения с номером index; каждый ключ выдаётся один раз
+func (c *ReceivingChain) MessageKey(index uint32) ([]byte, error) {
+	if index < c.index {
+		if key, ok := c.skipped.Take(c.id, index); ok {
+			return key, nil
+		}
+		return nil, ErrMessageKeyUnavailable
+	}
+	if index-c.index > MaxSkip {
+		return nil, fmt.Errorf("%w: %d messages ahead of the chain", ErrTooManySkipped, index-c.index)
+	}
+
+	for c.index < index {
+		key, err := chainMessageKey(c.chainKey, c.keySize)
+		if err != nil {
+			return nil, err
+		}
+		c.skipped.Put(c.id, c.index, key)
+		c.chainKey = nextChainKey(c.chainKey)
+		c.index++
+	}
+
+	key, err := chainMessageKey(c.chainKey, c.keySize)
+	if err != nil {
+		return nil, err
+	}
+	c.chainKey = nextChainKey(c.chainKey)
+	c.index++
+	return key, nil
+}
+
+type skippedKeyID struct {
+	chain string
+	index uint32
+}
+
+// SkippedKeys — ограниченный кэш ключей пропущенных сообщений; при переполнении
+// вытесняются самые старые
+type SkippedKeys struct {
+	keys  map[skippedKeyID][]byte
+	order []skippedKeyID
+	limit int
+}
+
+func NewSkippedKeys(limit int) *SkippedKeys {
+	return &SkippedKeys{keys: make(map[skippedKeyID][]byte), limit: limit}
+}
+
+func (s *SkippedKeys) Len() int {
+	return len(s.keys)
+}
+
+func (s *SkippedKeys) Put(chain string, index uint32, key []byte) {
+	id := skippedKeyID{chain, index}
+	s.keys[id] = key
+	s.order = append(s.order, id)
+	for len(s.keys) > s.limit {
+		delete(s.keys, s.order[0])
+		s.order = s.order[1:]
+	}
+	// order хранит и уже выданные ключи; чистим его, когда он заметно длиннее кэша
+	if len(s.order) > 2*s.limit {
+		live := s.order[:0]
+		for _, id := range s.order {
+			if _, ok := s.keys[id]; ok {
+				live = append(live, id)
+			}
+		}
+		s.order = live
+	}
+}
+
+// Take выдаёт ключ и удаляет его из кэша
+func (s *SkippedKeys) Take(chain string, index uint32) ([]byte, bool) {
+	id := skippedKeyID{chain, index}
+	key, ok := s.keys[id]
+	if ok {
+		delete(s.keys, id)
+	}
+	return key, ok
+}
+
+// Forget удаляет ключи цепочки, например при её замене после шага храповика Диффи-Хеллмана
+func (s *SkippedKeys) Forget(chain string) {
+	for id := range s.keys {
+		if id.chain == chain {
+			delete(s.keys, id)
+		}
+	}
+}
+
+func nextChainKey(chainKey []byte) []byte {
+	mac := hmac.New(sha256.New, chainKey)
+	mac.Write([]byte{0x02})
+	return mac.Sum(nil)
+}
+
+// chainMessageKey растягивает HMAC(ck, 0x01) до длины ключа шифра чата
+func chainMessageKey(chainKey []byte, keySize int) ([]byte, error) {
+	mac := hmac.New(sha256.New, chainKey)
+	mac.Write([]byte{0x01})
+
+	key := make([]byte, keySize)
+	if _, err := io.ReadFull(hkdf.Expand(sha256.New, mac.Sum(nil), []byte(messageKeyLabel)), key); err != nil {
+		return nil, fmt.Errorf("failed to derive message key: %w", err)
+	}
+	return key, nil
+}
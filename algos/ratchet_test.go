@@ -0,0 +1,251 @@
+package algos
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+const ratchetTestKeySize = 32
+
+func testChainKey(t *testing.T) []byte {
+	t.Helper()
+	key, err := randomBytes(nil, AuthKeySize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+// sendKeys выдаёт n ключей сообщений цепочки отправителя по порядку
+func sendKeys(t *testing.T, chain *SendingChain, n int) [][]byte {
+	t.Helper()
+	keys := make([][]byte, n)
+	for i := range keys {
+		index, key, err := chain.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if index != uint32(i) {
+			t.Fatalf("message %d got index %d", i, index)
+		}
+		keys[i] = key
+	}
+	return keys
+}
+
+func TestSendingChainAdvances(t *testing.T) {
+	chainKey := testChainKey(t)
+	sender := NewSendingChain(chainKey, ratchetTestKeySize)
+	keys := sendKeys(t, sender, 10)
+	if sender.Index() != 10 {
+		t.Fatalf("index after 10 messages: %d", sender.Index())
+	}
+
+	seen := make(map[string]bool)
+	for i, key := range keys {
+		if len(key) != ratchetTestKeySize {
+			t.Fatalf("key %d has %d bytes", i, len(key))
+		}
+		if seen[string(key)] {
+			t.Fatalf("key %d repeats an earlier key", i)
+		}
+		seen[string(key)] = true
+	}
+
+	receiver := NewReceivingChain("chain", chainKey, ratchetTestKeySize, NewSkippedKeys(MaxSkippedKeys))
+	for i, want := range keys {
+		got, err := receiver.MessageKey(uint32(i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Fatalf("receiver derived a different key for message %d", i)
+		}
+	}
+}
+
+func TestReceivingChainOutOfOrder(t *testing.T) {
+	chainKey := testChainKey(t)
+	keys := sendKeys(t, NewSendingChain(chainKey, ratchetTestKeySize), 6)
+
+	skipped := NewSkippedKeys(MaxSkippedKeys)
+	receiver := NewReceivingChain("chain", chainKey, ratchetTestKeySize, skipped)
+	for _, index := range []uint32{4, 1, 0, 5, 3, 2} {
+		got, err := receiver.MessageKey(index)
+		if err != nil {
+			t.Fatalf("message %d: %v", index, err)
+		}
+		if !bytes.Equal(got, keys[index]) {
+			t.Fatalf("wrong key for message %d", index)
+		}
+	}
+	if skipped.Len() != 0 {
+		t.Fatalf("%d skipped keys left after every message was read", skipped.Len())
+	}
+
+	// каждый ключ выдаётся один раз
+	for _, index := range []uint32{1, 5} {
+		if _, err := receiver.MessageKey(index); !errors.Is(err, ErrMessageKeyUnavailable) {
+			t.Fatalf("message %d read twice: %v", index, err)
+		}
+	}
+}
+
+func TestReceivingChainMaxSkip(t *testing.T) {
+	chainKey := testChainKey(t)
+
+	receiver := NewReceivingChain("chain", chainKey, ratchetTestKeySize, NewSkippedKeys(MaxSkippedKeys))
+	if _, err := receiver.MessageKey(MaxSkip + 1); !errors.Is(err, ErrTooManySkipped) {
+		t.Fatalf("skipping %d messages: %v", MaxSkip+1, err)
+	}
+	// отказ не сдвигает цепочку
+	if _, err := receiver.MessageKey(MaxSkip); err != nil {
+		t.Fatalf("skipping %d messages: %v", MaxSkip, err)
+	}
+}
+
+func TestSkippedKeysCap(t *testing.T) {
+	chainKey := testChainKey(t)
+	keys := sendKeys(t, NewSendingChain(chainKey, ratchetTestKeySize), 6)
+
+	skipped := NewSkippedKeys(3)
+	receiver := NewReceivingChain("chain", chainKey, ratchetTestKeySize, skipped)
+	if _, err := receiver.MessageKey(5); err != nil {
+		t.Fatal(err)
+	}
+	if skipped.Len() != 3 {
+		t.Fatalf("cache holds %d keys, limit is 3", skipped.Len())
+	}
+
+	// вытеснены самые старые ключи
+	for _, index := range []uint32{0, 1} {
+		if _, err := receiver.MessageKey(index); !errors.Is(err, ErrMessageKeyUnavailable) {
+			t.Fatalf("evicted message %d: %v", index, err)
+		}
+	}
+	for _, index := range []uint32{2, 3, 4} {
+		got, err := receiver.MessageKey(index)
+		if err != nil || !bytes.Equal(got, keys[index]) {
+			t.Fatalf("cached message %d: %v", index, err)
+		}
+	}
+}
+
+func TestChainKeyCannotRecoverEarlierKeys(t *testing.T) {
+	sender := NewSendingChain(testChainKey(t), ratchetTestKeySize)
+	earlier := sendKeys(t, sender, 5)
+
+	// состояние, которое увидел бы укравший цепочку после пятого сообщения
+	later := sendKeys(t, NewSendingChain(sender.chainKey, ratchetTestKeySize), MaxSkip)
+	for _, key := range later {
+		for i, old := range earlier {
+			if bytes.Equal(key, old) {
+				t.Fatalf("current chain key derives message key %d", i)
+			}
+		}
+	}
+}
+
+// ratchetStep — шаг храповика Диффи-Хеллмана: отправитель берёт новый одноразовый ключ
+// и новую случайную цепочку и оборачивает её для получателя
+func ratchetStep(t *testing.T, kdf KeyDerivation, recipient AgreementKey, binding string) (ratchetKey string, wrapped, chainKey []byte) {
+	t.Helper()
+	ephemeral, err := X25519{}.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret, err := ephemeral.SharedSecret(recipient.PublicKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys, err := kdf.Derive(secret, SenderKeyPurpose, "alice", "bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	chainKey = testChainKey(t)
+	if wrapped, err = WrapKey("twofish", keys, chainKey, binding); err != nil {
+		t.Fatal(err)
+	}
+	return ephemeral.PublicKey(), wrapped, chainKey
+}
+
+func receiveStep(t *testing.T, kdf KeyDerivation, recipient AgreementKey, ratchetKey string, wrapped []byte, binding string) []byte {
+	t.Helper()
+	secret, err := recipient.SharedSecret(ratchetKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys, err := kdf.Derive(secret, SenderKeyPurpose, "bob", "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	chainKey, err := UnwrapKey("twofish", keys, wrapped, binding)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return chainKey
+}
+
+func TestDHRatchetStep(t *testing.T) {
+	kdf, err := NewKeyDerivation([]byte("salt"), "chat", "twofish")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bob, err := X25519{}.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	skipped := NewSkippedKeys(MaxSkippedKeys)
+
+	ratchetKey1, wrapped1, chainKey1 := ratchetStep(t, kdf, bob, "chain-1")
+	received1 := receiveStep(t, kdf, bob, ratchetKey1, wrapped1, "chain-1")
+	if !bytes.Equal(received1, chainKey1) {
+		t.Fatal("recipient unwrapped a different first chain")
+	}
+	sender1 := NewSendingChain(chainKey1, kdf.KeySize)
+	old := sendKeys(t, sender1, 3)
+	receiver1 := NewReceivingChain("alice/chain-1", received1, kdf.KeySize, skipped)
+	if _, err := receiver1.MessageKey(2); err != nil {
+		t.Fatal(err)
+	}
+
+	ratchetKey2, wrapped2, chainKey2 := ratchetStep(t, kdf, bob, "chain-2")
+	if ratchetKey2 == ratchetKey1 {
+		t.Fatal("ratchet step reused the one-time key")
+	}
+	received2 := receiveStep(t, kdf, bob, ratchetKey2, wrapped2, "chain-2")
+	if !bytes.Equal(received2, chainKey2) {
+		t.Fatal("recipient unwrapped a different second chain")
+	}
+
+	// обёртку одного шага не развернуть как другой
+	secret, err := bob.SharedSecret(ratchetKey2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys, err := kdf.Derive(secret, SenderKeyPurpose, "alice", "bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := UnwrapKey("twofish", keys, wrapped2, "chain-1"); !errors.Is(err, ErrDecrypt) {
+		t.Fatalf("second chain unwrapped with the first chain's binding: %v", err)
+	}
+
+	// старая цепочка не выводит ключи новой: новая начинается со случайного ключа
+	next := sendKeys(t, NewSendingChain(chainKey2, kdf.KeySize), 3)
+	continued := sendKeys(t, NewSendingChain(sender1.chainKey, kdf.KeySize), MaxSkip)
+	for _, key := range append(old, continued...) {
+		for i, newKey := range next {
+			if bytes.Equal(key, newKey) {
+				t.Fatalf("old chain derives message key %d of the new chain", i)
+			}
+		}
+	}
+
+	// ключи пропущенных сообщений заменённой цепочки забываются
+	skipped.Forget("alice/chain-1")
+	if _, err := receiver1.MessageKey(0); !errors.Is(err, ErrMessageKeyUnavailable) {
+		t.Fatalf("skipped key of a replaced chain survived: %v", err)
+	}
+}
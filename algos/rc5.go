@@ -4,35 +4,110 @@ import (
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 )
 
 const (
 	nRounds = 16
+
+	defaultRC5WordSize  = 64
+	defaultRC5KeyLength = 16
 )
 
 type RC5 struct {
-	keys  []uint64
-	words uint
+	keys      []uint64
+	words     uint
+	rounds    int
+	keyLength int
+	mask      uint64
+	keyed     bool // без ключа расписание нулевое, и шифрование было бы обратимо без ключа
+}
+
+func init() {
+	RegisterCipher(CipherInfo{
+		Name:        "rc5",
+		DisplayName: "RC5",
+		BlockSize:   2 * defaultRC5WordSize / 8,
+		KeySizes:    []int{defaultRC5KeyLength},
+		Variants: []CipherVariant{
+			{Name: "rc5-32/12/16", BlockSize: 8, KeySizes: []int{16}},
+			{Name: "rc5-64/24/32", BlockSize: 16, KeySizes: []int{32}},
+		},
+		New: func(params string) (Cipher, error) {
+			if params == "" {
+				return NewRC5()
+			}
+			return NewRC5FromSpec(params)
+		},
+	})
 }
 
 func NewRC5() (*RC5, error) {
+	return NewRC5WithParams(defaultRC5WordSize, nRounds, defaultRC5KeyLength)
+}
+
+// NewRC5WithParams создаёт RC5-w/r/b: размер слова в битах, число раундов и длину ключа в байтах
+func NewRC5WithParams(wordSize, rounds, keyLength int) (*RC5, error) {
+	if wordSize != 16 && wordSize != 32 && wordSize != 64 {
+		return nil, fmt.Errorf("unsupported RC5 word size: %d (must be 16, 32 or 64)", wordSize)
+	}
+	if rounds < 1 || rounds > 255 {
+		return nil, fmt.Errorf("invalid RC5 round count: %d (must be 1..255)", rounds)
+	}
+	if keyLength < 1 || keyLength > 255 {
+		return nil, fmt.Errorf("invalid RC5 key length: %d (must be 1..255 bytes)", keyLength)
+	}
+
 	r := &RC5{
-		words: 64,
-		keys:  make([]uint64, 2*(nRounds+1)),
+		words:     uint(wordSize),
+		rounds:    rounds,
+		keyLength: keyLength,
+		keys:      make([]uint64, 2*(rounds+1)),
+		mask:      ^uint64(0) >> (64 - wordSize),
 	}
 	return r, nil
 }
 
+// NewRC5FromSpec разбирает параметры вида "32/12/16" (w/r/b)
+func NewRC5FromSpec(spec string) (*RC5, error) {
+	parts := strings.Split(spec, "/")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("invalid RC5 parameters %q: expected w/r/b", spec)
+	}
+
+	var params [3]int
+	for i, part := range parts {
+		value, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid RC5 parameters %q: %w", spec, err)
+		}
+		params[i] = value
+	}
+
+	return NewRC5WithParams(params[0], params[1], params[2])
+}
+
+// KeySize возвращает длину ключа b в байтах
+func (r *RC5) KeySize() int {
+	return r.keyLength
+}
+
+// CipherKey принимает ключ ровно той длины b, что задана в параметрах варианта
 func (r *RC5) CipherKey(key []byte) error {
-	if len(key) < int(r.words/8) {
-		return fmt.Errorf("ключ слишком короткий: требуется минимум %d байт", r.words/8)
+	if len(key) != r.keyLength {
+		return fmt.Errorf("invalid RC5 key length: %d bytes (must be %d)", len(key), r.keyLength)
 	}
 	r.keys = r.keyExpansion(key)
+	r.keyed = true
 	return nil
 }
 
+func (r *RC5) BlockSize() int {
+	return int(2 * r.words / 8)
+}
+
 func (r *RC5) keyExpansion(key []byte) []uint64 {
-	//fmt.Println("Расширяем ключ хули")
 	var Pw, Qw uint64
 	switch r.words {
 	case 16:
@@ -48,34 +123,35 @@ func (r *RC5) keyExpansion(key []byte) []uint64 {
 		panic("unsupported word size")
 	}
 
-	c := (len(key) + int((r.words)/8) - 1) / int(r.words/8)
+	u := int(r.words / 8)
+	c := max((len(key)+u-1)/u, 1)
 	L := make([]uint64, c)
 	for i := len(key) - 1; i >= 0; i-- {
-		L[i/int(r.words/8)] = (L[i/int(r.words/8)] << 8) + uint64(key[i])
+		L[i/u] = ((L[i/u] << 8) + uint64(key[i])) & r.mask
 	}
 
-	s := make([]uint64, 2*(nRounds+1))
+	t := 2 * (r.rounds + 1)
+	s := make([]uint64, t)
 	s[0] = Pw
 	for i := 1; i < len(s); i++ {
-		s[i] = s[i-1] + Qw
+		s[i] = (s[i-1] + Qw) & r.mask
 	}
 
 	i, j := 0, 0
 	A, B := uint64(0), uint64(0)
-	for k := 0; k < 3*(max(2*(nRounds+1), c)); k++ {
-		A = rotateLeft(s[i]+A+B, 3, r.words)
+	for k := 0; k < 3*max(t, c); k++ {
+		A = rotateLeft((s[i]+A+B)&r.mask, 3, r.words)
 		s[i] = A
-		sum := L[j] + A + B
-		B = rotateLeft(sum, sum%uint64(r.words), r.words)
+		sum := (L[j] + A + B) & r.mask
+		B = rotateLeft(sum, (A+B)%uint64(r.words), r.words)
 		L[j] = B
-		i = (i + 1) % int(2*(nRounds+1))
-		j = (j + 1) % int(c)
+		i = (i + 1) % t
+		j = (j + 1) % c
 	}
 	return s
 }
 
 func (rc5 *RC5) Encrypt(data []byte) ([]byte, error) {
-	//log.Println("Encrypt вызывается из RC5")
 	return rc5.EncryptBlock(data)
 }
 
@@ -88,43 +164,23 @@ func (rc5 *RC5) EncryptBlock(block []byte) ([]byte, error) {
 	if len(block) != int(wordSizeBytes*2) {
 		return nil, fmt.Errorf("block size must be %d bytes", wordSizeBytes*2)
 	}
-
-	var A, B uint64
-	switch rc5.words {
-	case 16:
-		A = uint64(binary.LittleEndian.Uint16(block[0:2]))
-		B = uint64(binary.LittleEndian.Uint16(block[2:4]))
-	case 32:
-		A = uint64(binary.LittleEndian.Uint32(block[0:4]))
-		B = uint64(binary.LittleEndian.Uint32(block[4:8]))
-	case 64:
-		A = binary.LittleEndian.Uint64(block[0:8])
-		B = binary.LittleEndian.Uint64(block[8:16])
-	default:
-		return nil, errors.New("unsupported word size")
+	if !rc5.keyed {
+		return nil, errors.New("rc5 key is not set")
 	}
 
-	A += rc5.keys[0]
-	B += rc5.keys[1]
-	for i := 1; i <= nRounds; i++ {
-		A = rotateLeft(A^B, B%uint64(rc5.words), uint(rc5.words)) + rc5.keys[2*i]
-		B = rotateLeft(B^A, A%uint64(rc5.words), uint(rc5.words)) + rc5.keys[2*i+1]
+	A, B, err := rc5.loadWords(block)
+	if err != nil {
+		return nil, err
 	}
 
-	encryptedBlock := make([]byte, wordSizeBytes*2)
-	switch rc5.words {
-	case 16:
-		binary.LittleEndian.PutUint16(encryptedBlock[0:2], uint16(A))
-		binary.LittleEndian.PutUint16(encryptedBlock[2:4], uint16(B))
-	case 32:
-		binary.LittleEndian.PutUint32(encryptedBlock[0:4], uint32(A))
-		binary.LittleEndian.PutUint32(encryptedBlock[4:8], uint32(B))
-	case 64:
-		binary.LittleEndian.PutUint64(encryptedBlock[0:8], A)
-		binary.LittleEndian.PutUint64(encryptedBlock[8:16], B)
+	A = (A + rc5.keys[0]) & rc5.mask
+	B = (B + rc5.keys[1]) & rc5.mask
+	for i := 1; i <= rc5.rounds; i++ {
+		A = (rotateLeft(A^B, B%uint64(rc5.words), rc5.words) + rc5.keys[2*i]) & rc5.mask
+		B = (rotateLeft(B^A, A%uint64(rc5.words), rc5.words) + rc5.keys[2*i+1]) & rc5.mask
 	}
 
-	return encryptedBlock, nil
+	return rc5.storeWords(A, B), nil
 }
 
 func (rc5 *RC5) DecryptBlock(block []byte) ([]byte, error) {
@@ -132,49 +188,60 @@ func (rc5 *RC5) DecryptBlock(block []byte) ([]byte, error) {
 	if len(block) != int(wordSizeBytes*2) {
 		return nil, fmt.Errorf("block size must be %d bytes", wordSizeBytes*2)
 	}
+	if !rc5.keyed {
+		return nil, errors.New("rc5 key is not set")
+	}
 
-	var A, B uint64
+	A, B, err := rc5.loadWords(block)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := rc5.rounds; i >= 1; i-- {
+		B = rotateRight((B-rc5.keys[2*i+1])&rc5.mask, A%uint64(rc5.words), rc5.words) ^ A
+		A = rotateRight((A-rc5.keys[2*i])&rc5.mask, B%uint64(rc5.words), rc5.words) ^ B
+	}
+	B = (B - rc5.keys[1]) & rc5.mask
+	A = (A - rc5.keys[0]) & rc5.mask
+
+	return rc5.storeWords(A, B), nil
+}
+
+func (rc5 *RC5) loadWords(block []byte) (uint64, uint64, error) {
 	switch rc5.words {
 	case 16:
-		A = uint64(binary.LittleEndian.Uint16(block[0:2]))
-		B = uint64(binary.LittleEndian.Uint16(block[2:4]))
+		return uint64(binary.LittleEndian.Uint16(block[0:2])), uint64(binary.LittleEndian.Uint16(block[2:4])), nil
 	case 32:
-		A = uint64(binary.LittleEndian.Uint32(block[0:4]))
-		B = uint64(binary.LittleEndian.Uint32(block[4:8]))
+		return uint64(binary.LittleEndian.Uint32(block[0:4])), uint64(binary.LittleEndian.Uint32(block[4:8])), nil
 	case 64:
-		A = binary.LittleEndian.Uint64(block[0:8])
-		B = binary.LittleEndian.Uint64(block[8:16])
+		return binary.LittleEndian.Uint64(block[0:8]), binary.LittleEndian.Uint64(block[8:16]), nil
 	default:
-		return nil, errors.New("unsupported word size")
-	}
-
-	for i := nRounds; i >= 1; i-- {
-		B = rotateRight(B-rc5.keys[2*i+1], A%uint64(rc5.words), rc5.words) ^ A
-		A = rotateRight(A-rc5.keys[2*i], B%uint64(rc5.words), rc5.words) ^ B
+		return 0, 0, errors.New("unsupported word size")
 	}
-	B -= rc5.keys[1]
-	A -= rc5.keys[0]
+}
 
-	decryptedBlock := make([]byte, wordSizeBytes*2)
+func (rc5 *RC5) storeWords(A, B uint64) []byte {
+	out := make([]byte, 2*rc5.words/8)
 	switch rc5.words {
 	case 16:
-		binary.LittleEndian.PutUint16(decryptedBlock[0:2], uint16(A))
-		binary.LittleEndian.PutUint16(decryptedBlock[2:4], uint16(B))
+		binary.LittleEndian.PutUint16(out[0:2], uint16(A))
+		binary.LittleEndian.PutUint16(out[2:4], uint16(B))
 	case 32:
-		binary.LittleEndian.PutUint32(decryptedBlock[0:4], uint32(A))
-		binary.LittleEndian.PutUint32(decryptedBlock[4:8], uint32(B))
+		binary.LittleEndian.PutUint32(out[0:4], uint32(A))
+		binary.LittleEndian.PutUint32(out[4:8], uint32(B))
 	case 64:
-		binary.LittleEndian.PutUint64(decryptedBlock[0:8], A)
-		binary.LittleEndian.PutUint64(decryptedBlock[8:16], B)
+		binary.LittleEndian.PutUint64(out[0:8], A)
+		binary.LittleEndian.PutUint64(out[8:16], B)
 	}
-
-	return decryptedBlock, nil
+	return out
 }
 
 func rotateLeft(x, y uint64, w uint) uint64 {
-	return ((x << (y % uint64(w))) | (x >> (uint64(w) - (y % uint64(w))))) & ((1 << w) - 1)
+	mask := ^uint64(0) >> (64 - w)
+	return ((x << (y % uint64(w))) | (x >> (uint64(w) - (y % uint64(w))))) & mask
 }
 
 func rotateRight(x, y uint64, w uint) uint64 {
-	return ((x >> (y % uint64(w))) | (x << (uint64(w) - (y % uint64(w))))) & ((1 << w) - 1)
+	mask := ^uint64(0) >> (64 - w)
+	return ((x >> (y % uint64(w))) | (x << (uint64(w) - (y % uint64(w))))) & mask
 }
@@ -0,0 +1,49 @@
+package algos
+
+import "testing"
+
+// TestRC5RejectsWrongKeyLength проверяет, что ключ не той длины не даёт контекста с нулевым
+// расписанием ключей, которым шифровали бы и расшифровывали любым неверным ключом
+func TestRC5RejectsWrongKeyLength(t *testing.T) {
+	if _, err := NewMessageContext("rc5-32/12/16", "CBC", "PKCS7", []byte("short")); err == nil {
+		t.Fatal("NewMessageContext accepted a 5-byte key for rc5-32/12/16")
+	}
+
+	c, err := NewRC5WithParams(32, 12, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewEncryptionContext([]byte("another wrong key"), CBC, PKCS7, nil, c, c); err == nil {
+		t.Fatal("NewEncryptionContext accepted a 17-byte key for rc5-32/12/16")
+	}
+	if err := c.CipherKey(make([]byte, 8)); err == nil {
+		t.Fatal("CipherKey accepted an 8-byte key")
+	}
+}
+
+// TestRC5RequiresKey проверяет, что без заданного ключа блоки не шифруются и не расшифровываются
+func TestRC5RequiresKey(t *testing.T) {
+	c, err := NewRC5WithParams(32, 12, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block := make([]byte, c.BlockSize())
+	if _, err := c.EncryptBlock(block); err == nil {
+		t.Fatal("EncryptBlock worked without a key")
+	}
+	if _, err := c.DecryptBlock(block); err == nil {
+		t.Fatal("DecryptBlock worked without a key")
+	}
+
+	if err := c.CipherKey(make([]byte, 16)); err != nil {
+		t.Fatal(err)
+	}
+	ct, err := c.EncryptBlock(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pt, err := c.DecryptBlock(ct)
+	if err != nil || string(pt) != string(block) {
+		t.Fatalf("round trip failed: %v", err)
+	}
+}
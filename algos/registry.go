@@ -0,0 +1,196 @@
+package algos
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// CipherFactory создаёт шифр; params — часть имени алгоритма после дефиса ("rc5-32/12/16" -> "32/12/16")
+type CipherFactory func(params string) (Cipher, error)
+
+// CipherInfo описывает шифр; BlockSize и KeySizes относятся к алгоритму без параметров
+type CipherInfo struct {
+	Name        string
+	DisplayName string
+	BlockSize   int
+	KeySizes    []int
+	Variants    []CipherVariant
+	New         CipherFactory
+}
+
+// CipherVariant — вариант шифра со своими размерами блока и ключа, например "rc5-32/12/16"
+type CipherVariant struct {
+	Name      string
+	BlockSize int
+	KeySizes  []int
+}
+
+type ModeInfo struct {
+	Name        string
+	DisplayName string
+	Mode        EncryptionMode
+	BlockSizes  []int // пустой список — режим работает с любым размером блока
+	Stream      bool  // потоковый режим: данные любой длины, набивка не обязательна
+	AEAD        bool  // аутентифицированный режим: набивка не применяется вовсе
+}
+
+type PaddingInfo struct {
+	Name        string
+	DisplayName string
+	Padding     PaddingMode
+}
+
+var registry = struct {
+	mu       sync.RWMutex
+	ciphers  map[string]CipherInfo
+	modes    map[string]ModeInfo
+	paddings map[string]PaddingInfo
+}{
+	ciphers:  make(map[string]CipherInfo),
+	modes:    make(map[string]ModeInfo),
+	paddings: make(map[string]PaddingInfo),
+}
+
+func RegisterCipher(info CipherInfo) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	if _, exists := registry.ciphers[info.Name]; exists {
+		panic("algos: cipher registered twice: " + info.Name)
+	}
+	registry.ciphers[info.Name] = info
+}
+
+func RegisterMode(info ModeInfo) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	if _, exists := registry.modes[info.Name]; exists {
+		panic("algos: mode registered twice: " + info.Name)
+	}
+	registry.modes[info.Name] = info
+}
+
+func RegisterPadding(info PaddingInfo) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	if _, exists := registry.paddings[info.Name]; exists {
+		panic("algos: padding registered twice: " + info.Name)
+	}
+	registry.paddings[info.Name] = info
+}
+
+// NewCipher создаёт шифр по имени алгоритма чата, например "twofish" или "rc5-32/12/16"
+func NewCipher(algorithm string) (Cipher, error) {
+	name, params, _ := strings.Cut(algorithm, "-")
+
+	registry.mu.RLock()
+	info, ok := registry.ciphers[name]
+	registry.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unsupported algorithm: %s", algorithm)
+	}
+
+	return info.New(params)
+}
+
+// CipherKeySize возвращает длину ключа, которую ожидает алгоритм чата: длину из параметров
+// варианта (RC5-w/r/b) либо наибольший поддерживаемый размер
+func CipherKeySize(algorithm string) (int, error) {
+	cipher, err := NewCipher(algorithm)
+	if err != nil {
+		return 0, err
+	}
+	if sized, ok := cipher.(interface{ KeySize() int }); ok {
+		return sized.KeySize(), nil
+	}
+
+	name, _, _ := strings.Cut(algorithm, "-")
+	info, _ := LookupCipher(name)
+	size := 0
+	for _, s := range info.KeySizes {
+		if s > size {
+			size = s
+		}
+	}
+	if size == 0 {
+		return 0, fmt.Errorf("unknown key size of algorithm: %s", algorithm)
+	}
+	return size, nil
+}
+
+func LookupCipher(name string) (CipherInfo, bool) {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+	info, ok := registry.ciphers[name]
+	return info, ok
+}
+
+func LookupMode(name string) (ModeInfo, bool) {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+	info, ok := registry.modes[name]
+	return info, ok
+}
+
+func LookupPadding(name string) (PaddingInfo, bool) {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+	info, ok := registry.paddings[name]
+	return info, ok
+}
+
+// SupportsBlockSize сообщает, можно ли использовать режим с шифром данного размера блока
+func (m ModeInfo) SupportsBlockSize(blockSize int) bool {
+	if len(m.BlockSizes) == 0 {
+		return true
+	}
+	for _, size := range m.BlockSizes {
+		if size == blockSize {
+			return true
+		}
+	}
+	return false
+}
+
+// AcceptsPadding сообщает, можно ли использовать режим с данной набивкой:
+// без набивки шифруют только потоковые режимы, а режим AEAD — только без неё
+func (m ModeInfo) AcceptsPadding(p PaddingInfo) bool {
+	if m.AEAD {
+		return p.Padding == NoPadding
+	}
+	return p.Padding != NoPadding || m.Stream
+}
+
+func Ciphers() []CipherInfo {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+	result := make([]CipherInfo, 0, len(registry.ciphers))
+	for _, info := range registry.ciphers {
+		result = append(result, info)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+func Modes() []ModeInfo {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+	result := make([]ModeInfo, 0, len(registry.modes))
+	for _, info := range registry.modes {
+		result = append(result, info)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Mode < result[j].Mode })
+	return result
+}
+
+func Paddings() []PaddingInfo {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+	result := make([]PaddingInfo, 0, len(registry.paddings))
+	for _, info := range registry.paddings {
+		result = append(result, info)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Padding < result[j].Padding })
+	return result
+}
@@ -0,0 +1,119 @@
+package algos
+
+import (
+	"bytes"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	SafetyNumberVersion = 0
+	// safetyNumberIterations замедляет подбор ключа с тем же отпечатком
+	safetyNumberIterations = 5200
+	fingerprintSize        = 32
+	// safetyNumberGroups — сколько пятизначных групп даёт отпечаток одного участника
+	safetyNumberGroups = 6
+)
+
+var (
+	ErrSafetyNumberVersion  = errors.New("unsupported safety number version")
+	ErrSafetyNumberMismatch = errors.New("safety numbers don't match")
+)
+
+// SafetyNumber — код безопасности пары пользователей, вычисленный по их ключам личности.
+// Цифры одинаковы у обоих собеседников: их сравнивают вслух или сканируют QR-код. Если
+// сервер подменил ключ одного из них, коды не совпадут.
+type SafetyNumber struct {
+	Digits string // 60 цифр, 12 групп по 5
+
+	local  []byte
+	remote []byte
+}
+
+func NewSafetyNumber(localID, localKey, remoteID, remoteKey string) (*SafetyNumber, error) {
+	local, err := identityFingerprint(localID, localKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid identity key of %s: %w", localID, err)
+	}
+	remote, err := identityFingerprint(remoteID, remoteKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid identity key of %s: %w", remoteID, err)
+	}
+
+	// порядок частей не зависит от того, кто вычисляет код
+	first, second := displayableFingerprint(local), displayableFingerprint(remote)
+	if localID > remoteID {
+		first, second = second, first
+	}
+	return &SafetyNumber{Digits: first + second, local: local, remote: remote}, nil
+}
+
+// QRPayload — содержимое QR-кода: версия, отпечаток владельца экрана и отпечаток собеседника
+func (n *SafetyNumber) QRPayload() []byte {
+	payload := []byte{SafetyNumberVersion}
+	payload = append(payload, n.local...)
+	return append(payload, n.remote...)
+}
+
+// VerifyQRPayload сверяет QR-код, отсканированный с экрана собеседника: в нём отпечатки
+// идут в обратном порядке
+func (n *SafetyNumber) VerifyQRPayload(scanned []byte) error {
+	if len(scanned) == 0 || scanned[0] != SafetyNumberVersion {
+		return ErrSafetyNumberVersion
+	}
+	expected := []byte{SafetyNumberVersion}
+	expected = append(expected, n.remote...)
+	expected = append(expected, n.local...)
+	if subtle.ConstantTimeCompare(scanned, expected) != 1 {
+		return ErrSafetyNumberMismatch
+	}
+	return nil
+}
+
+// Groups разбивает код на группы по 5 цифр для показа
+func (n *SafetyNumber) Groups() []string {
+	groups := make([]string, 0, len(n.Digits)/5)
+	for i := 0; i+5 <= len(n.Digits); i += 5 {
+		groups = append(groups, n.Digits[i:i+5])
+	}
+	return groups
+}
+
+func identityFingerprint(userID, publicKey string) ([]byte, error) {
+	key, err := parseIdentityKey(publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	var version [2]byte
+	binary.BigEndian.PutUint16(version[:], SafetyNumberVersion)
+	var buf bytes.Buffer
+	buf.Write(version[:])
+	buf.Write(key)
+	buf.WriteString(userID)
+
+	hash := sha512.Sum512(buf.Bytes())
+	for i := 0; i < safetyNumberIterations; i++ {
+		hash = sha512.Sum512(append(hash[:], key...))
+	}
+	return hash[:fingerprintSize], nil
+}
+
+// displayableFingerprint превращает первые 30 байт отпечатка в 30 цифр:
+// каждые 5 байт дают число по модулю 100000
+func displayableFingerprint(fingerprint []byte) string {
+	var sb strings.Builder
+	for i := 0; i < safetyNumberGroups; i++ {
+		chunk := fingerprint[i*5 : i*5+5]
+		var value uint64
+		for _, b := range chunk {
+			value = value<<8 | uint64(b)
+		}
+		fmt.Fprintf(&sb, "%05d", value%100000)
+	}
+	return sb.String()
+}
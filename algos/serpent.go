@@ -0,0 +1,210 @@
+package algos
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/bits"
+)
+
+const (
+	serpentBlockSize = 16
+	serpentRounds    = 32
+	serpentPhi       = 0x9e3779b9
+)
+
+type Serpent struct {
+	subKeys   [serpentRounds + 1][4]uint32
+	keyLength int
+}
+
+func init() {
+	RegisterCipher(CipherInfo{
+		Name:        "serpent",
+		DisplayName: "Serpent",
+		BlockSize:   serpentBlockSize,
+		KeySizes:    []int{16, 24, 32},
+		New: func(params string) (Cipher, error) {
+			if params != "" {
+				return nil, fmt.Errorf("serpent does not take parameters: %q", params)
+			}
+			return NewSerpent()
+		},
+	})
+}
+
+func NewSerpent() (*Serpent, error) {
+	return &Serpent{}, nil
+}
+
+var serpentSBoxes = [8][16]byte{
+	{3, 8, 15, 1, 10, 6, 5, 11, 14, 13, 4, 2, 7, 0, 9, 12},
+	{15, 12, 2, 7, 9, 0, 5, 10, 1, 11, 14, 8, 6, 13, 3, 4},
+	{8, 6, 7, 9, 3, 12, 10, 15, 13, 1, 14, 4, 0, 11, 5, 2},
+	{0, 15, 11, 8, 12, 9, 6, 3, 13, 1, 2, 4, 10, 7, 5, 14},
+	{1, 15, 8, 3, 12, 0, 11, 6, 2, 5, 4, 10, 9, 14, 7, 13},
+	{15, 5, 2, 11, 4, 10, 9, 12, 0, 3, 14, 8, 13, 6, 7, 1},
+	{7, 2, 12, 5, 8, 4, 6, 11, 14, 9, 1, 15, 13, 3, 10, 0},
+	{1, 13, 15, 0, 14, 8, 2, 11, 7, 4, 12, 10, 9, 3, 5, 6},
+}
+
+var serpentInvSBoxes = invertSerpentSBoxes()
+
+func invertSerpentSBoxes() [8][16]byte {
+	var inv [8][16]byte
+	for i, box := range serpentSBoxes {
+		for x, y := range box {
+			inv[i][y] = byte(x)
+		}
+	}
+	return inv
+}
+
+func (s *Serpent) CipherKey(key []byte) error {
+	if len(key) != 16 && len(key) != 24 && len(key) != 32 {
+		return errors.New("invalid key size (must be 16, 24, or 32 bytes)")
+	}
+	s.keyLength = len(key)
+	s.generateSubKeys(key)
+	return nil
+}
+
+func (s *Serpent) BlockSize() int {
+	return serpentBlockSize
+}
+
+func (s *Serpent) generateSubKeys(key []byte) {
+	// короткий ключ дополняется одним единичным битом и нулями до 256 бит
+	var padded [32]byte
+	copy(padded[:], key)
+	if len(key) < len(padded) {
+		padded[len(key)] = 0x01
+	}
+
+	var w [8 + 4*(serpentRounds+1)]uint32
+	for i := 0; i < 8; i++ {
+		w[i] = binary.LittleEndian.Uint32(padded[4*i:])
+	}
+	for i := 8; i < len(w); i++ {
+		w[i] = bits.RotateLeft32(w[i-8]^w[i-5]^w[i-3]^w[i-1]^serpentPhi^uint32(i-8), 11)
+	}
+
+	prekeys := w[8:]
+	for i := 0; i <= serpentRounds; i++ {
+		box := &serpentSBoxes[(3-i+8*serpentRounds)%8]
+		s.subKeys[i] = applySerpentSBox(box, [4]uint32{prekeys[4*i], prekeys[4*i+1], prekeys[4*i+2], prekeys[4*i+3]})
+	}
+}
+
+// applySerpentSBox применяет 4-битный S-блок в bitslice-представлении:
+// j-й бит каждого из четырёх слов образует один входной полубайт
+func applySerpentSBox(box *[16]byte, x [4]uint32) [4]uint32 {
+	var y [4]uint32
+	for j := 0; j < 32; j++ {
+		in := (x[0]>>j)&1 | ((x[1]>>j)&1)<<1 | ((x[2]>>j)&1)<<2 | ((x[3]>>j)&1)<<3
+		out := uint32(box[in])
+		y[0] |= (out & 1) << j
+		y[1] |= ((out >> 1) & 1) << j
+		y[2] |= ((out >> 2) & 1) << j
+		y[3] |= ((out >> 3) & 1) << j
+	}
+	return y
+}
+
+func serpentLinearTransform(x [4]uint32) [4]uint32 {
+	x[0] = bits.RotateLeft32(x[0], 13)
+	x[2] = bits.RotateLeft32(x[2], 3)
+	x[1] ^= x[0] ^ x[2]
+	x[3] ^= x[2] ^ (x[0] << 3)
+	x[1] = bits.RotateLeft32(x[1], 1)
+	x[3] = bits.RotateLeft32(x[3], 7)
+	x[0] ^= x[1] ^ x[3]
+	x[2] ^= x[3] ^ (x[1] << 7)
+	x[0] = bits.RotateLeft32(x[0], 5)
+	x[2] = bits.RotateLeft32(x[2], 22)
+	return x
+}
+
+func serpentInverseLinearTransform(x [4]uint32) [4]uint32 {
+	x[2] = bits.RotateLeft32(x[2], -22)
+	x[0] = bits.RotateLeft32(x[0], -5)
+	x[2] ^= x[3] ^ (x[1] << 7)
+	x[0] ^= x[1] ^ x[3]
+	x[3] = bits.RotateLeft32(x[3], -7)
+	x[1] = bits.RotateLeft32(x[1], -1)
+	x[3] ^= x[2] ^ (x[0] << 3)
+	x[1] ^= x[0] ^ x[2]
+	x[2] = bits.RotateLeft32(x[2], -3)
+	x[0] = bits.RotateLeft32(x[0], -13)
+	return x
+}
+
+func xorSerpentKey(x, k [4]uint32) [4]uint32 {
+	return [4]uint32{x[0] ^ k[0], x[1] ^ k[1], x[2] ^ k[2], x[3] ^ k[3]}
+}
+
+func (s *Serpent) Encrypt(data []byte) ([]byte, error) {
+	return s.EncryptBlock(data)
+}
+
+func (s *Serpent) Decrypt(data []byte) ([]byte, error) {
+	return s.DecryptBlock(data)
+}
+
+func (s *Serpent) EncryptBlock(data []byte) ([]byte, error) {
+	if len(data) != serpentBlockSize {
+		return nil, errors.New("invalid block size: must be 16 bytes")
+	}
+	if s.keyLength == 0 {
+		return nil, errors.New("serpent key is not set")
+	}
+
+	var x [4]uint32
+	for i := range x {
+		x[i] = binary.LittleEndian.Uint32(data[4*i:])
+	}
+
+	for round := 0; round < serpentRounds; round++ {
+		x = xorSerpentKey(x, s.subKeys[round])
+		x = applySerpentSBox(&serpentSBoxes[round%8], x)
+		if round < serpentRounds-1 {
+			x = serpentLinearTransform(x)
+		}
+	}
+	x = xorSerpentKey(x, s.subKeys[serpentRounds])
+
+	out := make([]byte, serpentBlockSize)
+	for i := range x {
+		binary.LittleEndian.PutUint32(out[4*i:], x[i])
+	}
+	return out, nil
+}
+
+func (s *Serpent) DecryptBlock(data []byte) ([]byte, error) {
+	if len(data) != serpentBlockSize {
+		return nil, errors.New("invalid block size: must be 16 bytes")
+	}
+	if s.keyLength == 0 {
+		return nil, errors.New("serpent key is not set")
+	}
+
+	var x [4]uint32
+	for i := range x {
+		x[i] = binary.LittleEndian.Uint32(data[4*i:])
+	}
+
+	x = xorSerpentKey(x, s.subKeys[serpentRounds])
+	for round := serpentRounds - 1; round >= 0; round-- {
+		if round < serpentRounds-1 {
+			x = serpentInverseLinearTransform(x)
+		}
+		x = applySerpentSBox(&serpentInvSBoxes[round%8], x)
+		x = xorSerpentKey(x, s.subKeys[round])
+	}
+
+	out := make([]byte, serpentBlockSize)
+	for i := range x {
+		binary.LittleEndian.PutUint32(out[4*i:], x[i])
+	}
+	return out, nil
+}
@@ -0,0 +1,374 @@
+package algos
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"errors"
+	"io"
+	"os"
+)
+
+const (
+	// streamChunkSize — сколько шифртекста DecryptingReader читает из источника за раз
+	streamChunkSize = 64 * 1024
+
+	// spoolMemorySize — сколько шифртекста GCM держится в памяти до проверки тега,
+	// прежде чем уйти во временный файл
+	spoolMemorySize = 16 * streamChunkSize
+)
+
+var ErrStreamClosed = errors.New("encrypting stream is already closed")
+
+type encryptingWriter struct {
+	ctx     *EncryptionContext
+	w       io.Writer
+	gcm     *gcmStream
+	pending []byte // хвост, не заполнивший целый блок
+	written int    // длина открытого текста с начала потока
+	closed  bool
+}
+
+// NewEncryptingWriter шифрует всё, что в него пишут, и отдаёт в w тот же конверт,
+// что и EncryptionContext.Encrypt. Дополнение добавляется только в Close;
+// Close не закрывает w.
+func NewEncryptingWriter(ctx *EncryptionContext, w io.Writer) (io.WriteCloser, error) {
+	return NewEncryptingWriterWithAAD(ctx, w, nil)
+}
+
+// NewEncryptingWriterWithAAD — потоковый аналог EncryptWithAAD
+func NewEncryptingWriterWithAAD(ctx *EncryptionContext, w io.Writer, aad []byte) (io.WriteCloser, error) {
+	sc := ctx.streamCopy()
+
+	ivSize := sc.Cipher.BlockSize()
+	if sc.Mode == GCM {
+		ivSize = gcmNonceSize
+	}
+	iv, err := randomBytes(sc.random, ivSize)
+	if err != nil {
+		return nil, errors.New("failed to generate IV")
+	}
+
+	ew := &encryptingWriter{ctx: sc, w: w}
+	if sc.Mode == GCM {
+		if ew.gcm, err = newGCMStream(sc, iv, aad); err != nil {
+			return nil, err
+		}
+	} else {
+		sc.startChain(iv)
+	}
+
+	if _, err := w.Write(envelopeHeader(iv)); err != nil {
+		return nil, err
+	}
+	return ew, nil
+}
+
+func (ew *encryptingWriter) Write(p []byte) (int, error) {
+	if ew.closed {
+		return 0, ErrStreamClosed
+	}
+
+	ew.pending = append(ew.pending, p...)
+	ew.written += len(p)
+	full := len(ew.pending) - len(ew.pending)%ew.ctx.Cipher.BlockSize()
+	if full == 0 {
+		return len(p), nil
+	}
+
+	encrypted, err := ew.encrypt(ew.pending[:full])
+	if err != nil {
+		return 0, err
+	}
+	ew.pending = append(ew.pending[:0], ew.pending[full:]...)
+
+	if _, err := ew.w.Write(encrypted); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Close дописывает последний блок с дополнением (или тег GCM)
+func (ew *encryptingWriter) Close() error {
+	if ew.closed {
+		return nil
+	}
+	ew.closed = true
+
+	var final []byte
+	if ew.gcm != nil {
+		encrypted, err := ew.gcm.encrypt(ew.pending)
+		if err != nil {
+			return err
+		}
+		tag, err := ew.gcm.tag()
+		if err != nil {
+			return err
+		}
+		final = append(encrypted, tag...)
+	} else {
+		padded, err := ew.ctx.addPadding(ew.pending, ew.ctx.Padding, ew.written)
+		if err != nil {
+			return err
+		}
+		if final, err = ew.encrypt(padded); err != nil {
+			return err
+		}
+	}
+	ew.pending = nil
+
+	_, err := ew.w.Write(final)
+	return err
+}
+
+func (ew *encryptingWriter) encrypt(blocks []byte) ([]byte, error) {
+	if ew.gcm != nil {
+		return ew.gcm.encrypt(blocks)
+	}
+	return ew.ctx.encryptBlocks(blocks)
+}
+
+type decryptingReader struct {
+	ctx      *EncryptionContext
+	r        io.Reader
+	gcm      *gcmStream
+	spool    *spool // GCM: шифртекст, отложенный до проверки тега
+	verified bool   // GCM: тег сошёлся, открытый текст отдаётся из spool
+	chunk    []byte
+	buf      []byte // прочитанный, но ещё не расшифрованный шифртекст
+	out      []byte // расшифрованные данные, ещё не отданные вызывающему
+	done     int    // сколько байтов открытого текста расшифровано до out
+	err      error
+}
+
+// NewDecryptingReader читает конверт из r и отдаёт открытый текст, держа в памяти
+// не больше нескольких блоков сверх streamChunkSize. Блоки, в которых может лежать
+// дополнение, придерживаются до конца потока.
+func NewDecryptingReader(ctx *EncryptionContext, r io.Reader) (io.Reader, error) {
+	return NewDecryptingReaderWithAAD(ctx, r, nil)
+}
+
+// NewDecryptingReaderWithAAD — потоковый аналог DecryptWithAAD. В режиме GCM открытый
+// текст не отдаётся, пока не проверен тег в конце потока: до этого шифртекст откладывается
+// (сверх spoolMemorySize — во временный файл), а при неверном теге Read сразу возвращает
+// ErrAuthentication.
+func NewDecryptingReaderWithAAD(ctx *EncryptionContext, r io.Reader, aad []byte) (io.Reader, error) {
+	sc := ctx.streamCopy()
+
+	iv, err := readEnvelopeHeader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	dr := &decryptingReader{ctx: sc, r: r, chunk: make([]byte, streamChunkSize)}
+	if sc.Mode == GCM {
+		if dr.gcm, err = newGCMStream(sc, iv, aad); err != nil {
+			return nil, err
+		}
+		dr.spool = &spool{}
+	} else {
+		if len(iv) != sc.Cipher.BlockSize() {
+			return nil, ErrInvalidEnvelope
+		}
+		sc.startChain(iv)
+	}
+	return dr, nil
+}
+
+func (dr *decryptingReader) Read(p []byte) (int, error) {
+	for len(dr.out) == 0 && dr.err == nil {
+		dr.fill()
+	}
+	if len(dr.out) > 0 {
+		n := copy(p, dr.out)
+		dr.out = dr.out[n:]
+		return n, nil
+	}
+	return 0, dr.err
+}
+
+func (dr *decryptingReader) fill() {
+	if dr.verified {
+		dr.fillVerified()
+		return
+	}
+
+	n, err := dr.r.Read(dr.chunk)
+	dr.buf = append(dr.buf, dr.chunk[:n]...)
+	if err == io.EOF {
+		dr.finish()
+		return
+	}
+	if err != nil {
+		dr.fail(err)
+		return
+	}
+
+	// конец потока заранее неизвестен, поэтому возможную набивку (или тег GCM) не трогаем
+	blockSize := dr.ctx.Cipher.BlockSize()
+	holdBack := dr.ctx.maxPaddingSize()
+	if dr.gcm != nil {
+		holdBack = gcmTagSize
+	}
+	ready := len(dr.buf) - holdBack
+	if ready <= 0 {
+		return
+	}
+	ready -= ready % blockSize
+	if ready == 0 {
+		return
+	}
+
+	if dr.gcm != nil {
+		dr.gcm.authenticate(dr.buf[:ready])
+		if _, err := dr.spool.Write(dr.buf[:ready]); err != nil {
+			dr.fail(err)
+			return
+		}
+	} else {
+		dr.out, dr.err = dr.ctx.decryptBlocks(dr.buf[:ready])
+	}
+	dr.done += len(dr.out)
+	dr.buf = append(dr.buf[:0], dr.buf[ready:]...)
+}
+
+// fillVerified расшифровывает очередную порцию отложенного шифртекста GCM, тег которого уже проверен
+func (dr *decryptingReader) fillVerified() {
+	n, err := io.ReadFull(dr.spool, dr.chunk)
+	if err == io.EOF {
+		dr.spool.Close()
+		dr.err = io.EOF
+		return
+	}
+	if err != nil && err != io.ErrUnexpectedEOF {
+		dr.fail(err)
+		return
+	}
+	dr.out, dr.err = dr.gcm.xorKeyStream(dr.chunk[:n])
+}
+
+func (dr *decryptingReader) fail(err error) {
+	if dr.spool != nil {
+		dr.spool.Close()
+	}
+	dr.err = err
+}
+
+func (dr *decryptingReader) finish() {
+	if dr.gcm != nil {
+		err := dr.finishGCM()
+		dr.buf = nil
+		if err != nil {
+			dr.fail(err)
+			return
+		}
+		// тег сошёлся: дальше Read отдаёт расшифрованный шифртекст из spool
+		dr.verified = true
+		return
+	}
+
+	final, err := dr.ctx.decryptFinal(dr.buf, dr.done)
+	dr.buf = nil
+	if err != nil {
+		dr.err = err
+		return
+	}
+	dr.out = final
+	dr.err = io.EOF
+}
+
+func (dr *decryptingReader) finishGCM() error {
+	if len(dr.buf) < gcmTagSize {
+		return ErrAuthentication
+	}
+	ciphertext := dr.buf[:len(dr.buf)-gcmTagSize]
+	tag := dr.buf[len(dr.buf)-gcmTagSize:]
+
+	dr.gcm.authenticate(ciphertext)
+	if _, err := dr.spool.Write(ciphertext); err != nil {
+		return err
+	}
+	expectedTag, err := dr.gcm.tag()
+	if err != nil {
+		return err
+	}
+	if subtle.ConstantTimeCompare(tag, expectedTag) != 1 {
+		return ErrAuthentication
+	}
+	return dr.spool.rewind()
+}
+
+// spool хранит шифртекст GCM, пока не проверен тег: сначала в памяти, сверх spoolMemorySize —
+// во временном файле. Открытый текст туда не попадает. Если файл создать нельзя (WASM),
+// шифртекст остаётся в памяти.
+type spool struct {
+	mem  []byte
+	file *os.File
+	r    io.Reader
+}
+
+func (s *spool) Write(p []byte) (int, error) {
+	if s.file == nil && len(s.mem)+len(p) > spoolMemorySize {
+		if f, err := os.CreateTemp("", "kygram-spool-*"); err == nil {
+			// в Unix файл сразу пропадает из каталога и живёт, пока открыт
+			os.Remove(f.Name())
+			s.file = f
+			if _, err := s.file.Write(s.mem); err != nil {
+				return 0, err
+			}
+			s.mem = nil
+		}
+	}
+	if s.file != nil {
+		return s.file.Write(p)
+	}
+	s.mem = append(s.mem, p...)
+	return len(p), nil
+}
+
+// rewind переключает spool на чтение с начала
+func (s *spool) rewind() error {
+	if s.file == nil {
+		s.r = bytes.NewReader(s.mem)
+		return nil
+	}
+	if _, err := s.file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	s.r = s.file
+	return nil
+}
+
+func (s *spool) Read(p []byte) (int, error) {
+	return s.r.Read(p)
+}
+
+func (s *spool) Close() error {
+	s.mem, s.r = nil, nil
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	os.Remove(s.file.Name())
+	s.file = nil
+	return err
+}
+
+// streamCopy отделяет состояние сцепления потока от контекста, чтобы тот
+// можно было параллельно использовать для обычных сообщений
+func (ctx *EncryptionContext) streamCopy() *EncryptionContext {
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+
+	return &EncryptionContext{
+		Key:            ctx.Key,
+		roundKeys:      ctx.roundKeys,
+		Mode:           ctx.Mode,
+		Padding:        ctx.Padding,
+		IV:             append([]byte(nil), ctx.IV...),
+		AdditionalArgs: ctx.AdditionalArgs,
+		Cipher:         ctx.Cipher,
+		expanderKey:    ctx.expanderKey,
+		random:         ctx.random,
+		Parallel:       ctx.Parallel,
+	}
+}
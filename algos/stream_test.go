@@ -0,0 +1,188 @@
+package algos
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+)
+
+func streamCombination(t *testing.T, algorithm, mode, padding string) conformanceCombination {
+	t.Helper()
+	modeInfo, ok := LookupMode(mode)
+	if !ok {
+		t.Fatalf("unknown mode %s", mode)
+	}
+	paddingInfo, ok := LookupPadding(padding)
+	if !ok {
+		t.Fatalf("unknown padding %s", padding)
+	}
+	return conformanceCombination{algorithm, modeInfo, paddingInfo}
+}
+
+func streamContext(t *testing.T, cc conformanceCombination) *EncryptionContext {
+	t.Helper()
+	ctx, err := conformanceContext(cc, conformanceKey, conformanceSeed[:], DefaultParallelConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ctx
+}
+
+func streamMessage(n int) []byte {
+	message := make([]byte, n)
+	for i := range message {
+		message[i] = byte(i*7 + i>>8)
+	}
+	return message
+}
+
+// sourceReader отдаёт шифртекст порциями по chunk байт и помнит, дочитан ли он до конца
+type sourceReader struct {
+	r     io.Reader
+	chunk int
+	eof   bool
+}
+
+func (s *sourceReader) Read(p []byte) (int, error) {
+	if len(p) > s.chunk {
+		p = p[:s.chunk]
+	}
+	n, err := s.r.Read(p)
+	if err == io.EOF {
+		s.eof = true
+	}
+	return n, err
+}
+
+// readStream читает открытый текст кусками по chunk байт. В режиме GCM первый же байт
+// открытого текста допустим только после того, как источник дочитан и тег проверен.
+func readStream(ctx *EncryptionContext, envelope []byte, chunk int) ([]byte, error) {
+	source := &sourceReader{r: bytes.NewReader(envelope), chunk: chunk}
+	reader, err := NewDecryptingReaderWithAAD(ctx, source, conformanceAAD)
+	if err != nil {
+		return nil, err
+	}
+	var out []byte
+	buf := make([]byte, chunk)
+	for {
+		n, err := reader.Read(buf)
+		if n > 0 && ctx.Mode == GCM && !source.eof {
+			return out, errors.New("plaintext released before the tag was read")
+		}
+		out = append(out, buf[:n]...)
+		if err == io.EOF {
+			return out, nil
+		}
+		if err != nil {
+			return out, err
+		}
+	}
+}
+
+func TestStreamChunkBoundaries(t *testing.T) {
+	message := streamMessage(3*streamChunkSize + 5)
+	for _, cc := range []conformanceCombination{
+		streamCombination(t, "serpent", "GCM", "None"),
+		streamCombination(t, "twofish", "CBC", "PKCS7"),
+		streamCombination(t, "rc5-32/12/16", "CTR", "None"),
+	} {
+		c, err := NewCipher(cc.Algorithm)
+		if err != nil {
+			t.Fatal(err)
+		}
+		blockSize := c.BlockSize()
+		want, err := conformanceSeal(cc, message, DefaultParallelConfig)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, chunk := range []int{1, blockSize, streamChunkSize - 1, streamChunkSize, streamChunkSize + 1} {
+			t.Run(fmt.Sprintf("%s/%d", cc, chunk), func(t *testing.T) {
+				// побайтово весь набор читается слишком долго; границы видны и на префиксе
+				plaintext := message
+				if chunk == 1 {
+					plaintext = message[:3*blockSize+1]
+				}
+				envelope, err := sealStream(streamContext(t, cc), plaintext, conformanceAAD, chunk)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if chunk != 1 && !bytes.Equal(envelope, want) {
+					t.Fatal("stream and one-shot ciphertexts differ")
+				}
+				got, err := readStream(streamContext(t, cc), envelope, chunk)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(got, plaintext) {
+					t.Fatal("stream decrypted a different message")
+				}
+			})
+		}
+	}
+}
+
+func TestGCMStreamSpool(t *testing.T) {
+	cc := streamCombination(t, "serpent", "GCM", "None")
+	message := streamMessage(spoolMemorySize + 2*streamChunkSize + 3)
+	envelope, err := conformanceSeal(cc, message, DefaultParallelConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := streamContext(t, cc)
+	source := &sourceReader{r: bytes.NewReader(envelope), chunk: streamChunkSize}
+	reader, err := NewDecryptingReaderWithAAD(ctx, source, conformanceAAD)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := make([]byte, 1)
+	if _, err := io.ReadFull(reader, first); err != nil {
+		t.Fatal(err)
+	}
+	if !source.eof {
+		t.Fatal("plaintext released before the tag was read")
+	}
+	// шифртекст больше spoolMemorySize лежит во временном файле, а не в памяти
+	if spool := reader.(*decryptingReader).spool; spool.file == nil || spool.mem != nil {
+		t.Fatal("large stream was not spooled to a file")
+	}
+	rest, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(append(first, rest...), message) {
+		t.Fatal("spooled stream decrypted a different message")
+	}
+}
+
+func TestGCMStreamBadTag(t *testing.T) {
+	cc := streamCombination(t, "serpent", "GCM", "None")
+	for _, size := range []int{0, 5, streamChunkSize + 1, spoolMemorySize + streamChunkSize} {
+		envelope, err := conformanceSeal(cc, streamMessage(size), DefaultParallelConfig)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for name, tamper := range map[string]func([]byte){
+			"tag":               func(b []byte) { b[len(b)-1] ^= 1 },
+			"first ciphertext":  func(b []byte) { b[3+gcmNonceSize] ^= 1 },
+			"middle ciphertext": func(b []byte) { b[(3+gcmNonceSize+len(b)-gcmTagSize)/2] ^= 1 },
+		} {
+			if size == 0 && name != "tag" {
+				continue
+			}
+			t.Run(fmt.Sprintf("%d/%s", size, name), func(t *testing.T) {
+				tampered := append([]byte(nil), envelope...)
+				tamper(tampered)
+				got, err := readStream(streamContext(t, cc), tampered, streamChunkSize)
+				if !errors.Is(err, ErrAuthentication) {
+					t.Fatalf("got error %v, want ErrAuthentication", err)
+				}
+				if len(got) > 0 {
+					t.Fatalf("%d bytes of plaintext released", len(got))
+				}
+			})
+		}
+	}
+}
@@ -3,6 +3,8 @@ package algos
 import (
 	"encoding/binary"
 	"errors"
+	"fmt"
+	"math/bits"
 )
 
 const (
@@ -10,117 +12,158 @@ const (
 	MaxKeySize = 32
 )
 
+// полиномы полей GF(2^8) для матриц MDS и RS
+const (
+	mdsPolynomial = 0x169 // x⁸ + x⁶ + x⁵ + x³ + 1
+	rsPolynomial  = 0x14d // x⁸ + x⁶ + x³ + x² + 1
+)
+
 type Twofish struct {
 	key       []byte
 	subKeys   [40]uint32
-	sBoxes    [4][256]byte
+	sBoxes    [4][256]uint32
 	keyLength int
 }
 
-func NewTwofish() (*Twofish, error) {
-	tf := &Twofish{}
-	tf.generateSBoxes()
-
-	key := []byte("securekey12345678")
-	tf.key = key
-	tf.keyLength = len(key)
-	tf.generateSubKeys()
+func init() {
+	RegisterCipher(CipherInfo{
+		Name:        "twofish",
+		DisplayName: "Twofish",
+		BlockSize:   BlockSize,
+		KeySizes:    []int{16, 24, 32},
+		New: func(params string) (Cipher, error) {
+			if params != "" {
+				return nil, fmt.Errorf("twofish does not take parameters: %q", params)
+			}
+			return NewTwofish()
+		},
+	})
+}
 
-	// tf.CipherKey(tf.key)
-	return tf, nil
+func NewTwofish() (*Twofish, error) {
+	return &Twofish{}, nil
 }
 
 func (tf *Twofish) CipherKey(key []byte) error {
 	if len(key) != 16 && len(key) != 24 && len(key) != 32 {
 		return errors.New("invalid key size (must be 16, 24, or 32 bytes)")
 	}
-	tf.keyLength = len(tf.key)
+	tf.key = append([]byte(nil), key...)
+	tf.keyLength = len(key)
 	tf.generateSubKeys()
 
 	return nil
 }
 
 func (tf *Twofish) generateSubKeys() {
-	//fmt.Println("Расширяем ключ в tw хули")
-	rsResult := rsEncode(tf.key)
-	var evenKeys, oddKeys []uint32
-
-	for i := 0; i < len(tf.key)/4; i++ {
-		word := binary.LittleEndian.Uint32(tf.key[i*4 : (i+1)*4])
-		if i%2 == 0 {
-			evenKeys = append(evenKeys, word)
-		} else {
-			oddKeys = append(oddKeys, word)
-		}
-	}
+	k := tf.keyLength / 8
 
-	for i := 0; i < 20; i++ {
-		a, b := pht(evenKeys[i%len(evenKeys)], oddKeys[i%len(oddKeys)])
-		// tf.subKeys[2*i] = a + rsResult[0]
-		// tf.subKeys[2*i+1] = b + rsResult[1]
-		k := i % len(rsResult)
-		tf.subKeys[2*i] = a + rsResult[k]
-		tf.subKeys[2*i+1] = b + rsResult[(k+1)%len(rsResult)]
+	var evenKeys, oddKeys [4]uint32
+	for i := 0; i < k; i++ {
+		evenKeys[i] = binary.LittleEndian.Uint32(tf.key[8*i : 8*i+4])
+		oddKeys[i] = binary.LittleEndian.Uint32(tf.key[8*i+4 : 8*i+8])
 	}
-}
 
-func (tf *Twofish) generateSBoxes() {
+	const rho = 0x01010101
+	for i := uint32(0); i < 20; i++ {
+		a := hFunction(2*i*rho, evenKeys[:k])
+		b := bits.RotateLeft32(hFunction((2*i+1)*rho, oddKeys[:k]), 8)
+		tf.subKeys[2*i], tf.subKeys[2*i+1] = pht(a, b)
+		tf.subKeys[2*i+1] = bits.RotateLeft32(tf.subKeys[2*i+1], 9)
+	}
 
-	q0 := generateQ(t0, t1, t2, t3)
-	q1 := generateQ(t0q1, t1q1, t2q1, t3q1)
+	// вектор S идёт в обратном порядке: S[k-1] используется как L0
+	sKeys := rsEncode(tf.key)
+	var sVector [4]uint32
+	for i := 0; i < k; i++ {
+		sVector[i] = sKeys[k-1-i]
+	}
+	tf.generateSBoxes(sVector[:k])
+}
 
+// generateSBoxes строит ключезависимые S-блоки, сразу умноженные на столбцы MDS
+func (tf *Twofish) generateSBoxes(sVector []uint32) {
 	for i := 0; i < 256; i++ {
-		tf.sBoxes[0][i] = applyQ(q0, byte(i))
-		tf.sBoxes[1][i] = applyQ(q1, byte(i))
-		tf.sBoxes[2][i] = applyQ(q0, applyQ(q1, byte(i)))
-		tf.sBoxes[3][i] = applyQ(q1, applyQ(q0, byte(i)))
+		y := sBoxBytes(byte(i), byte(i), byte(i), byte(i), sVector)
+		for j := 0; j < 4; j++ {
+			tf.sBoxes[j][i] = mdsColumn(y[j], j)
+		}
 	}
 }
 
-func (tf *Twofish) HFunction(input uint32) uint32 {
-	x0 := byte(input)
-	x1 := byte(input >> 8)
-	x2 := byte(input >> 16)
-	x3 := byte(input >> 24)
+// sBoxBytes — байтовая часть функции h до умножения на MDS
+func sBoxBytes(y0, y1, y2, y3 byte, l []uint32) [4]byte {
+	k := len(l)
+	b := func(i, j int) byte { return byte(l[i] >> (8 * j)) }
 
-	y0 := tf.sBoxes[0][x0]
-	y1 := tf.sBoxes[1][x1]
-	y2 := tf.sBoxes[2][x2]
-	y3 := tf.sBoxes[3][x3]
+	if k == 4 {
+		y0 = q1[y0] ^ b(3, 0)
+		y1 = q0[y1] ^ b(3, 1)
+		y2 = q0[y2] ^ b(3, 2)
+		y3 = q1[y3] ^ b(3, 3)
+	}
+	if k >= 3 {
+		y0 = q1[y0] ^ b(2, 0)
+		y1 = q1[y1] ^ b(2, 1)
+		y2 = q0[y2] ^ b(2, 2)
+		y3 = q0[y3] ^ b(2, 3)
+	}
+	y0 = q1[q0[q0[y0]^b(1, 0)]^b(0, 0)]
+	y1 = q0[q0[q1[y1]^b(1, 1)]^b(0, 1)]
+	y2 = q1[q1[q0[y2]^b(1, 2)]^b(0, 2)]
+	y3 = q0[q1[q1[y3]^b(1, 3)]^b(0, 3)]
+
+	return [4]byte{y0, y1, y2, y3}
+}
 
-	// MDS сразу нахуй ахах
-	m0 := gfmultiply(y0, 0x01) ^ gfmultiply(y1, 0xEF) ^ gfmultiply(y2, 0x5B) ^ gfmultiply(y3, 0x5B)
-	m1 := gfmultiply(y0, 0xEF) ^ gfmultiply(y1, 0x01) ^ gfmultiply(y2, 0xEF) ^ gfmultiply(y3, 0x5B)
-	m2 := gfmultiply(y0, 0x5B) ^ gfmultiply(y1, 0xEF) ^ gfmultiply(y2, 0x01) ^ gfmultiply(y3, 0xEF)
-	m3 := gfmultiply(y0, 0x5B) ^ gfmultiply(y1, 0x5B) ^ gfmultiply(y2, 0xEF) ^ gfmultiply(y3, 0x01)
+func hFunction(input uint32, l []uint32) uint32 {
+	y := sBoxBytes(byte(input), byte(input>>8), byte(input>>16), byte(input>>24), l)
+	return mdsColumn(y[0], 0) ^ mdsColumn(y[1], 1) ^ mdsColumn(y[2], 2) ^ mdsColumn(y[3], 3)
+}
 
-	return (uint32(m0) << 24) | (uint32(m1) << 16) | (uint32(m2) << 8) | uint32(m3)
+var MDSMatrix = [4][4]byte{
+	{0x01, 0xEF, 0x5B, 0x5B},
+	{0x5B, 0xEF, 0xEF, 0x01},
+	{0xEF, 0x5B, 0x01, 0xEF},
+	{0xEF, 0x01, 0xEF, 0x5B},
 }
 
-func gfmultiply(a, b byte) byte {
-	var product byte = 0
-	const irreducible = 0x69 // x⁸ + x⁶ + x⁵ + x³ + 1
+// mdsColumn умножает байт на столбец col матрицы MDS и возвращает слово little-endian
+func mdsColumn(y byte, col int) uint32 {
+	var out uint32
+	for row := 0; row < 4; row++ {
+		out |= uint32(gfmultiply(y, MDSMatrix[row][col], mdsPolynomial)) << (8 * row)
+	}
+	return out
+}
 
-	for i := 0; i < 8; i++ {
-		if (b & 1) != 0 {
-			product ^= a
-		}
+func (tf *Twofish) gFunction(x uint32) uint32 {
+	return tf.sBoxes[0][byte(x)] ^ tf.sBoxes[1][byte(x>>8)] ^ tf.sBoxes[2][byte(x>>16)] ^ tf.sBoxes[3][byte(x>>24)]
+}
 
-		highBit := a & 0x80
-		a <<= 1
+func gfmultiply(a, b byte, polynomial uint32) byte {
+	var product uint32
+	x := uint32(a)
 
-		if highBit != 0 {
-			a ^= irreducible
+	for b != 0 {
+		if b&1 != 0 {
+			product ^= x
+		}
+		x <<= 1
+		if x&0x100 != 0 {
+			x ^= polynomial
 		}
-
 		b >>= 1
 	}
 
-	return product
+	return byte(product)
+}
+
+func (tf *Twofish) BlockSize() int {
+	return BlockSize
 }
 
 func (tf *Twofish) Encrypt(data []byte) ([]byte, error) {
-	//log.Println("Encrypt вызывается из Twofish")
 	return tf.EncryptBlock(data)
 }
 
@@ -132,39 +175,28 @@ func (tf *Twofish) EncryptBlock(data []byte) ([]byte, error) {
 	if len(data) != 16 {
 		return nil, errors.New("invalid block size: must be 16 bytes")
 	}
+	if tf.keyLength == 0 {
+		return nil, errors.New("twofish key is not set")
+	}
 
 	var P [4]uint32
 	for i := 0; i < 4; i++ {
-		P[i] = uint32(data[i*4]) | uint32(data[i*4+1])<<8 | uint32(data[i*4+2])<<16 | uint32(data[i*4+3])<<24
+		P[i] = binary.LittleEndian.Uint32(data[i*4:]) ^ tf.subKeys[i]
 	}
 
-	P[0] ^= tf.subKeys[0]
-	P[1] ^= tf.subKeys[1]
-	P[2] ^= tf.subKeys[2]
-	P[3] ^= tf.subKeys[3]
-
 	for round := 0; round < 16; round++ {
-		T0 := tf.HFunction(P[0])
-		T1 := tf.HFunction(P[1])
-		P[2] ^= T0 + T1 + tf.subKeys[2*round+8]
-		P[3] ^= T0 + 2*T1 + tf.subKeys[2*round+9]
+		T0 := tf.gFunction(P[0])
+		T1 := tf.gFunction(bits.RotateLeft32(P[1], 8))
+		F0, F1 := pht(T0, T1)
+		P[2] = bits.RotateLeft32(P[2]^(F0+tf.subKeys[2*round+8]), -1)
+		P[3] = bits.RotateLeft32(P[3], 1) ^ (F1 + tf.subKeys[2*round+9])
 
-		if round < 15 {
-			P[0], P[1], P[2], P[3] = P[2], P[3], P[0], P[1]
-		}
+		P[0], P[1], P[2], P[3] = P[2], P[3], P[0], P[1]
 	}
 
-	P[0] ^= tf.subKeys[4]
-	P[1] ^= tf.subKeys[5]
-	P[2] ^= tf.subKeys[6]
-	P[3] ^= tf.subKeys[7]
-
 	ciphertext := make([]byte, 16)
 	for i := 0; i < 4; i++ {
-		ciphertext[i*4] = byte(P[i])
-		ciphertext[i*4+1] = byte(P[i] >> 8)
-		ciphertext[i*4+2] = byte(P[i] >> 16)
-		ciphertext[i*4+3] = byte(P[i] >> 24)
+		binary.LittleEndian.PutUint32(ciphertext[i*4:], P[(i+2)%4]^tf.subKeys[i+4])
 	}
 
 	return ciphertext, nil
@@ -174,39 +206,28 @@ func (tf *Twofish) DecryptBlock(data []byte) ([]byte, error) {
 	if len(data) != 16 {
 		return nil, errors.New("invalid block size: must be 16 bytes")
 	}
+	if tf.keyLength == 0 {
+		return nil, errors.New("twofish key is not set")
+	}
 
 	var P [4]uint32
 	for i := 0; i < 4; i++ {
-		P[i] = uint32(data[i*4]) | uint32(data[i*4+1])<<8 | uint32(data[i*4+2])<<16 | uint32(data[i*4+3])<<24
+		P[(i+2)%4] = binary.LittleEndian.Uint32(data[i*4:]) ^ tf.subKeys[i+4]
 	}
 
-	P[0] ^= tf.subKeys[4]
-	P[1] ^= tf.subKeys[5]
-	P[2] ^= tf.subKeys[6]
-	P[3] ^= tf.subKeys[7]
-
 	for round := 15; round >= 0; round-- {
-		if round < 15 {
-			P[0], P[1], P[2], P[3] = P[2], P[3], P[0], P[1]
-		}
+		P[0], P[1], P[2], P[3] = P[2], P[3], P[0], P[1]
 
-		T0 := tf.HFunction(P[0])
-		T1 := tf.HFunction(P[1])
-		P[2] ^= T0 + T1 + tf.subKeys[2*round+8]
-		P[3] ^= T0 + 2*T1 + tf.subKeys[2*round+9]
+		T0 := tf.gFunction(P[0])
+		T1 := tf.gFunction(bits.RotateLeft32(P[1], 8))
+		F0, F1 := pht(T0, T1)
+		P[2] = bits.RotateLeft32(P[2], 1) ^ (F0 + tf.subKeys[2*round+8])
+		P[3] = bits.RotateLeft32(P[3]^(F1+tf.subKeys[2*round+9]), -1)
 	}
 
-	P[0] ^= tf.subKeys[0]
-	P[1] ^= tf.subKeys[1]
-	P[2] ^= tf.subKeys[2]
-	P[3] ^= tf.subKeys[3]
-
 	plaintext := make([]byte, 16)
 	for i := 0; i < 4; i++ {
-		plaintext[i*4] = byte(P[i])
-		plaintext[i*4+1] = byte(P[i] >> 8)
-		plaintext[i*4+2] = byte(P[i] >> 16)
-		plaintext[i*4+3] = byte(P[i] >> 24)
+		binary.LittleEndian.PutUint32(plaintext[i*4:], P[i]^tf.subKeys[i])
 	}
 
 	return plaintext, nil
@@ -226,6 +247,11 @@ var (
 	t3q1 = [16]byte{0xB, 9, 5, 1, 0xC, 3, 0xD, 0xE, 6, 4, 7, 0xF, 2, 0, 8, 0xA}
 )
 
+var (
+	q0 = generateQ(t0, t1, t2, t3)
+	q1 = generateQ(t0q1, t1q1, t2q1, t3q1)
+)
+
 var RSMatrix = [4][8]byte{
 	{0x01, 0xa4, 0x55, 0x87, 0x5a, 0x58, 0xdb, 0x9e},
 	{0xa4, 0x56, 0x82, 0xf3, 0x1e, 0xc6, 0x68, 0xe5},
@@ -242,23 +268,18 @@ func pht(a, b uint32) (uint32, uint32) {
 func rsEncode(key []byte) []uint32 {
 	blockSize := 8
 	numBlocks := len(key) / blockSize
-	rsResult := make([]uint32, 0, numBlocks*4)
+	rsResult := make([]uint32, 0, numBlocks)
 
 	for block := 0; block < numBlocks; block++ {
-		start := block * blockSize
-		end := start + blockSize
-		chunk := key[start:end]
+		chunk := key[block*blockSize : (block+1)*blockSize]
 
-		var res [4]uint32
+		var res [4]byte
 		for i := 0; i < 4; i++ {
-			var sum byte
 			for j := 0; j < 8; j++ {
-				sum ^= gfmultiply(chunk[j], RSMatrix[i][j])
+				res[i] ^= gfmultiply(chunk[j], RSMatrix[i][j], rsPolynomial)
 			}
-			res[i] = uint32(sum)
 		}
-		combined := binary.LittleEndian.Uint32([]byte{byte(res[0]), byte(res[1]), byte(res[2]), byte(res[3])})
-		rsResult = append(rsResult, combined)
+		rsResult = append(rsResult, binary.LittleEndian.Uint32(res[:]))
 	}
 
 	return rsResult
@@ -286,7 +307,3 @@ func generateQ(t0, t1, t2, t3 [16]byte) [256]byte {
 func ROR4(x, n byte) byte {
 	return (x >> n) | (x<<(4-n))&0x0F
 }
-
-func applyQ(q [256]byte, x byte) byte {
-	return q[x]
-}
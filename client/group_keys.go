@@ -0,0 +1,463 @@
+package client
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"log"
+	"maps"
+	"strconv"
+	"sync"
+
+	"Kygram/algos"
+	"Kygram/proto/protopb"
+
+	"github.com/google/uuid"
+)
+
+// RatchetStep — через сколько сообщений отправитель делает шаг храповика Диффи-Хеллмана,
+// даже если состав получателей не менялся
+const RatchetStep = 100
+
+// receivingChainsPerSender — сколько последних цепочек отправителя хранится, чтобы дочитать
+// сообщения, отправленные до шага храповика
+const receivingChainsPerSender = 2
+
+// GroupKeys — ключи группового чата для одного подключения по схеме sender keys с храповиком:
+// каждый участник шифрует сообщения ключами своей цепочки (новый ключ на каждое сообщение),
+// а начальный ключ цепочки раздаёт остальным, обернув его попарным ключом между одноразовым
+// ключом согласования отправителя и ключом подключения получателя. Цепочка сменяется (шаг
+// храповика Диффи-Хеллмана), когда меняется набор получателей или отправлено RatchetStep сообщений;
+// одноразовый закрытый ключ сразу забывается, поэтому компрометация текущих ключей не раскрывает
+// прошлые сообщения. Цепочка принадлежит одной эпохе ключей чата: с началом новой эпохи
+// отправитель тоже делает шаг храповика. В чатах X25519 участникам не в сети цепочка
+// оборачивается по их предварительным ключам (X3DH). Получатели — устройства: цепочка
+// оборачивается для каждого подключённого устройства участника, включая другие устройства
+// самого отправителя. Ключи подключений забываются вместе с подключением, поэтому каждому
+// участнику, включая самого отправителя, цепочка обёрнута ещё и по его подписанному
+// предварительному ключу — эту копию читает история. Ключи подключений и одноразовые ключи
+// храповика подписаны ключами личности владельцев: цепочка оборачивается и разворачивается
+// только по проверенным ключам.
+type GroupKeys struct {
+	client     *KeyExchangeClient
+	chatID     string
+	userID     string
+	deviceID   string
+	algorithm  string
+	agreement  algos.KeyAgreement
+	private    algos.AgreementKey
+	kdf        algos.KeyDerivation
+	prekeys    *PrekeyStore
+	signer     Signer
+	identities *identityKeys
+
+	mu       sync.Mutex
+	own      *sendingState
+	received map[string]*algos.ReceivingChain // "отправитель/идентификатор" -> цепочка
+	chains   map[string][]string              // отправитель -> идентификаторы цепочек, от старых к новым
+	skipped  *algos.SkippedKeys
+	verified map[string]error  // "идентификатор/открытый ключ" подключения -> итог проверки подписи
+	epochs   map[uint32][]byte // эпоха -> случайный секрет эпохи обычного чата
+}
+
+type sendingState struct {
+	id         string
+	epoch      uint32
+	ratchetKey string // открытый одноразовый ключ шага храповика
+	chain      *algos.SendingChain
+	recipients map[recipient]string // устройство получателя -> открытый ключ, для которого обёрнута цепочка; "" — по предварительным ключам
+	members    map[string]bool      // участники, которым обёрнута копия для истории
+}
+
+// recipient — устройство получателя; пустой deviceID у участника не в сети
+type recipient struct {
+	userID   string
+	deviceID string
+}
+
+// RatchetHeader — то, что получателю нужно знать о ключе сообщения; передаётся в Message
+type RatchetHeader struct {
+	KeyID      string
+	Index      uint32
+	RatchetKey string
+	Epoch      uint32
+}
+
+// NewGroupKeys создаёт ключи чата для подключения устройства deviceID; private — его текущий
+// ключ, nil у читателя истории
+func (c *KeyExchangeClient) NewGroupKeys(chatID, userID, deviceID, algorithm string, agreement algos.KeyAgreement, private algos.AgreementKey, kdf algos.KeyDerivation) *GroupKeys {
+	return &GroupKeys{
+		client:    c,
+		chatID:    chatID,
+		userID:    userID,
+		deviceID:  deviceID,
+		algorithm: algorithm,
+		agreement: agreement,
+		private:   private,
+		kdf:       kdf,
+		received:  make(map[string]*algos.ReceivingChain),
+		chains:    make(map[string][]string),
+		skipped:   algos.NewSkippedKeys(algos.MaxSkippedKeys),
+		verified:  make(map[string]error),
+		epochs:    make(map[uint32][]byte),
+	}
+}
+
+// UseIdentity задаёт подпись одноразовых ключей храповика (signer; nil у читателя истории)
+// и проверку подписей чужих ключей по ключам личности из users
+func (g *GroupKeys) UseIdentity(signer Signer, users *UserClient) {
+	g.signer = signer
+	g.identities = newIdentityKeys(users)
+}
+
+// UsePrekeys позволяет читать цепочки, обёрнутые по предварительным ключам пользователя,
+// пока он был не в сети
+func (g *GroupKeys) UsePrekeys(store *PrekeyStore) {
+	g.prekeys = store
+}
+
+// NextMessageKey возвращает заголовок и ключ очередного сообщения, при необходимости делая
+// шаг храповика
+func (g *GroupKeys) NextMessageKey() (RatchetHeader, []byte, error) {
+	peerKeys, epoch, err := g.client.GetPeerKeys(g.chatID)
+	if err != nil {
+		return RatchetHeader{}, nil, fmt.Errorf("failed to get peer keys: %w", err)
+	}
+	// предварительные ключи — всегда X25519, в чатах с конечной группой их не с чем согласовать
+	_, prekeysAllowed := g.agreement.(algos.X25519)
+	recipients := make(map[recipient]string)
+	members := map[string]bool{g.userID: true}
+	for _, peer := range peerKeys {
+		members[peer.ClientId] = true
+		if peer.ClientId == g.userID && (peer.DeviceId == g.deviceID || peer.PublicKey == "") {
+			continue
+		}
+		if peer.PublicKey != "" {
+			if err := g.verifyPeerKey(peer); err != nil {
+				log.Printf("Rejected public key of client %s (device %s): %v", peer.ClientId, peer.DeviceId, err)
+				continue
+			}
+			recipients[recipient{peer.ClientId, peer.DeviceId}] = peer.PublicKey
+		} else if prekeysAllowed {
+			recipients[recipient{peer.ClientId, peer.DeviceId}] = ""
+		}
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.own == nil || g.own.epoch != epoch || !maps.Equal(g.own.recipients, recipients) || !maps.Equal(g.own.members, members) ||
+		g.own.chain.Index() >= RatchetStep {
+		if err := g.ratchet(epoch, recipients, members); err != nil {
+			return RatchetHeader{}, nil, err
+		}
+	}
+
+	index, key, err := g.own.chain.Next()
+	if err != nil {
+		return RatchetHeader{}, nil, err
+	}
+	// собственное сообщение вернётся в общем потоке; его ключ ждёт в кэше до первого прочтения
+	g.skipped.Put(g.userID+"/"+g.own.id, index, key)
+	return RatchetHeader{KeyID: g.own.id, Index: index, RatchetKey: g.own.ratchetKey, Epoch: g.own.epoch}, key, nil
+}
+
+// verifyPeerKey проверяет подпись ключа подключения ключом личности его владельца;
+// результат запоминается: опубликованный ключ не меняется
+func (g *GroupKeys) verifyPeerKey(peer *protopb.ClientPublicKey) error {
+	if g.identities == nil {
+		return ErrUnknownIdentity
+	}
+	g.mu.Lock()
+	verdict, checked := g.verified[peer.KeyId+"/"+peer.PublicKey]
+	g.mu.Unlock()
+	if checked {
+		return verdict
+	}
+	err := g.identities.verify(peer.ClientId, algos.SessionKeyPayload(g.chatID, peer.ClientId, peer.DeviceId, peer.KeyId, peer.PublicKey), peer.Signature)
+	// ключ личности могли не найти из-за сбоя связи — такой результат не запоминаем
+	if err == nil || !errors.Is(err, ErrUnknownIdentity) {
+		g.mu.Lock()
+		g.verified[peer.KeyId+"/"+peer.PublicKey] = err
+		g.mu.Unlock()
+	}
+	return err
+}
+
+// ratchet начинает новую цепочку и раздаёт её начальный ключ получателям, а участникам
+// members — копии для истории
+func (g *GroupKeys) ratchet(epoch uint32, recipients map[recipient]string, members map[string]bool) error {
+	ephemeral, err := g.agreement.GenerateKey()
+	if err != nil {
+		return fmt.Errorf("failed to generate ratchet key: %w", err)
+	}
+	chainKey := make([]byte, algos.AuthKeySize)
+	if _, err := rand.Read(chainKey); err != nil {
+		return err
+	}
+	// копии для истории оборачиваются по предварительным ключам, а они всегда X25519
+	history, err := algos.X25519{}.GenerateKey()
+	if err != nil {
+		return fmt.Errorf("failed to generate history key: %w", err)
+	}
+	id := uuid.New().String()
+	ratchetKey, historyKey := ephemeral.PublicKey(), history.PublicKey()
+	if g.signer == nil {
+		return fmt.Errorf("no identity key to sign ratchet key")
+	}
+	signature, err := g.signer.Sign(algos.SenderKeyPayload(g.chatID, g.userID, id, ratchetKey, historyKey))
+	if err != nil {
+		return fmt.Errorf("failed to sign ratchet key: %w", err)
+	}
+
+	var wrapped []*protopb.WrappedSenderKey
+	for to, publicKey := range recipients {
+		recipientID := to.userID
+		key := &protopb.WrappedSenderKey{RecipientId: recipientID, RecipientDeviceId: to.deviceID, RecipientPublicKey: publicKey}
+		var secret []byte
+		if publicKey == "" {
+			if secret, err = g.prekeySecret(ephemeral, key); err != nil {
+				log.Printf("Offline client %s is unreachable: %v", recipientID, err)
+				continue
+			}
+		} else if secret, err = ephemeral.SharedSecret(publicKey); err != nil {
+			log.Printf("Rejected public key of client %s (device %s): %v", recipientID, to.deviceID, err)
+			continue
+		}
+		if key.WrappedKey, err = g.wrapChain(secret, recipientID, chainKey, id, ratchetKey, epoch); err != nil {
+			return err
+		}
+		wrapped = append(wrapped, key)
+	}
+	devices := len(wrapped)
+	for recipientID := range members {
+		key := &protopb.WrappedSenderKey{RecipientId: recipientID, RecipientDeviceId: algos.HistoryDeviceID}
+		secret, err := g.historySecret(history, key)
+		if err != nil {
+			log.Printf("No history copy of chain %s for client %s: %v", id, recipientID, err)
+			continue
+		}
+		if key.WrappedKey, err = g.wrapChain(secret, recipientID, chainKey, id, ratchetKey, epoch); err != nil {
+			return err
+		}
+		wrapped = append(wrapped, key)
+	}
+
+	if err := g.client.DistributeSenderKey(g.chatID, g.userID, id, ratchetKey, historyKey, signature, wrapped); err != nil {
+		return err
+	}
+
+	if g.own != nil {
+		g.skipped.Forget(g.userID + "/" + g.own.id)
+	}
+	g.own = &sendingState{
+		id:         id,
+		epoch:      epoch,
+		ratchetKey: ratchetKey,
+		chain:      algos.NewSendingChain(chainKey, g.kdf.KeySize),
+		recipients: recipients,
+		members:    members,
+	}
+	log.Printf("[GROUP KEYS] Шаг храповика: цепочка %s в чате %s (эпоха %d) для %d устройств получателей и %d копий для истории",
+		id, g.chatID, epoch, devices, len(wrapped)-devices)
+	return nil
+}
+
+func (g *GroupKeys) wrapChain(secret []byte, recipientID string, chainKey []byte, id, ratchetKey string, epoch uint32) ([]byte, error) {
+	pairwise, err := g.kdf.Derive(secret, algos.SenderKeyPurpose, g.userID, recipientID)
+	if err != nil {
+		return nil, err
+	}
+	return algos.WrapKey(g.algorithm, pairwise, chainKey, chainBinding(g.chatID, g.userID, recipientID, id, ratchetKey, epoch)...)
+}
+
+// prekeySecret согласует секрет с получателем не в сети по его набору предварительных ключей
+// и записывает в обёртку, какие ключи набора использованы
+func (g *GroupKeys) prekeySecret(ephemeral algos.AgreementKey, key *protopb.WrappedSenderKey) ([]byte, error) {
+	bundle, err := g.client.FetchPrekeyBundle(g.chatID, g.userID, key.RecipientId, false)
+	if err != nil {
+		return nil, fmt.Errorf("no prekey bundle: %w", err)
+	}
+	secret, err := algos.X3DHInitiate(ephemeral, bundle)
+	if err != nil {
+		return nil, err
+	}
+	key.RecipientPublicKey = bundle.SignedPrekey
+	key.SignedPrekeyId = bundle.SignedPrekeyID
+	key.OneTimePrekeyId = bundle.OneTimePrekeyID
+	return secret, nil
+}
+
+// historySecret согласует секрет копии для истории с подписанным предварительным ключом
+// участника, не расходуя его одноразовые ключи. Подпись ключа проверяется ключом личности
+// из кэша, а не присланным вместе с набором.
+func (g *GroupKeys) historySecret(history algos.AgreementKey, key *protopb.WrappedSenderKey) ([]byte, error) {
+	bundle, err := g.client.FetchPrekeyBundle(g.chatID, g.userID, key.RecipientId, true)
+	if err != nil {
+		return nil, fmt.Errorf("no signed prekey: %w", err)
+	}
+	if g.identities == nil {
+		return nil, ErrUnknownIdentity
+	}
+	payload := algos.SignedPrekeyPayload(key.RecipientId, bundle.SignedPrekeyID, bundle.SignedPrekey)
+	if err := g.identities.verify(key.RecipientId, payload, bundle.SignedPrekeySignature); err != nil {
+		return nil, fmt.Errorf("signed prekey %d: %w", bundle.SignedPrekeyID, err)
+	}
+	secret, err := history.SharedSecret(bundle.SignedPrekey)
+	if err != nil {
+		return nil, err
+	}
+	key.RecipientPublicKey = bundle.SignedPrekey
+	key.SignedPrekeyId = bundle.SignedPrekeyID
+	return secret, nil
+}
+
+// MessageKey возвращает ключ сообщения по заголовку; ключ каждого сообщения выдаётся один раз
+func (g *GroupKeys) MessageKey(senderID string, header RatchetHeader) ([]byte, error) {
+	chainID := senderID + "/" + header.KeyID
+	if senderID == g.userID {
+		g.mu.Lock()
+		key, ok := g.skipped.Take(chainID, header.Index)
+		own := g.own != nil && g.own.id == header.KeyID
+		g.mu.Unlock()
+		if ok {
+			return key, nil
+		}
+		if own {
+			return nil, algos.ErrMessageKeyUnavailable
+		}
+		// сообщение с другого устройства пользователя: цепочка обёрнута и для этого
+	}
+
+	g.mu.Lock()
+	chain, ok := g.received[chainID]
+	g.mu.Unlock()
+	if !ok {
+		var err error
+		if chain, err = g.fetchChain(senderID, header); err != nil {
+			return nil, err
+		}
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return chain.MessageKey(header.Index)
+}
+
+func (g *GroupKeys) fetchChain(senderID string, header RatchetHeader) (*algos.ReceivingChain, error) {
+	// у читателя истории нет устройства: ему нужна копия для истории
+	deviceID := g.deviceID
+	if deviceID == "" {
+		deviceID = algos.HistoryDeviceID
+	}
+	resp, err := g.client.GetSenderKey(g.chatID, senderID, header.KeyID, g.userID, deviceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sender key: %w", err)
+	}
+	if resp.SenderPublicKey != header.RatchetKey {
+		return nil, fmt.Errorf("ratchet key of chain %s doesn't match the message", header.KeyID)
+	}
+	if g.identities == nil {
+		return nil, ErrUnknownIdentity
+	}
+	if err := g.identities.verify(senderID, algos.SenderKeyPayload(g.chatID, senderID, header.KeyID, resp.SenderPublicKey, resp.HistoryPublicKey), resp.SenderKeySignature); err != nil {
+		return nil, fmt.Errorf("ratchet key of chain %s: %w", header.KeyID, err)
+	}
+	secret, err := g.recipientSecret(resp)
+	if err != nil {
+		return nil, fmt.Errorf("chain %s: %w", header.KeyID, err)
+	}
+	pairwise, err := g.kdf.Derive(secret, algos.SenderKeyPurpose, g.userID, senderID)
+	if err != nil {
+		return nil, err
+	}
+	// эпоха из заголовка входит в привязку обёртки, поэтому подменить её в сообщении нельзя
+	chainKey, err := algos.UnwrapKey(g.algorithm, pairwise, resp.Key.WrappedKey,
+		chainBinding(g.chatID, senderID, g.userID, header.KeyID, header.RatchetKey, header.Epoch)...)
+	if err != nil {
+		return nil, err
+	}
+
+	chainID := senderID + "/" + header.KeyID
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	// пока шли запросы, цепочку мог получить параллельный вызов
+	if chain, ok := g.received[chainID]; ok {
+		return chain, nil
+	}
+	chain := algos.NewReceivingChain(chainID, chainKey, g.kdf.KeySize, g.skipped)
+	g.received[chainID] = chain
+	ids := append(g.chains[senderID], header.KeyID)
+	for len(ids) > receivingChainsPerSender {
+		old := senderID + "/" + ids[0]
+		delete(g.received, old)
+		g.skipped.Forget(old)
+		ids = ids[1:]
+	}
+	g.chains[senderID] = ids
+	return chain, nil
+}
+
+func (g *GroupKeys) recipientSecret(resp *protopb.GetSenderKeyResponse) ([]byte, error) {
+	if resp.Key.RecipientDeviceId == algos.HistoryDeviceID {
+		if g.prekeys == nil {
+			return nil, fmt.Errorf("history copy is wrapped for prekeys of user %s", g.userID)
+		}
+		return g.prekeys.SharedSecret(resp.Key.SignedPrekeyId, 0, resp.HistoryPublicKey)
+	}
+	if resp.Key.SignedPrekeyId != 0 {
+		if g.prekeys == nil {
+			return nil, fmt.Errorf("distributed to prekeys of user %s", g.userID)
+		}
+		return g.prekeys.SharedSecret(resp.Key.SignedPrekeyId, resp.Key.OneTimePrekeyId, resp.SenderPublicKey)
+	}
+	// цепочка обёрнута для прежнего подключения; отправитель сменит её, увидев новый открытый ключ
+	if g.private == nil || resp.Key.RecipientPublicKey != g.private.PublicKey() {
+		return nil, fmt.Errorf("distributed to another key of user %s", g.userID)
+	}
+	return g.private.SharedSecret(resp.SenderPublicKey)
+}
+
+// EpochSecret возвращает случайный секрет эпохи обычного чата, из которого выводится ключ
+// сообщений без ключа отправителя. Сервер оборачивает секрет для ключа этого подключения,
+// а читателю истории — для его подписанного предварительного ключа.
+func (g *GroupKeys) EpochSecret(epoch uint32) ([]byte, error) {
+	g.mu.Lock()
+	secret, ok := g.epochs[epoch]
+	g.mu.Unlock()
+	if ok {
+		return secret, nil
+	}
+
+	resp, err := g.client.GetEpochKey(g.chatID, g.userID, g.deviceID, epoch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get secret of epoch %d: %w", epoch, err)
+	}
+	var shared []byte
+	switch {
+	case resp.SignedPrekeyId != 0:
+		if g.prekeys == nil {
+			return nil, fmt.Errorf("secret of epoch %d is wrapped for prekeys of user %s", epoch, g.userID)
+		}
+		shared, err = g.prekeys.SharedSecret(resp.SignedPrekeyId, 0, resp.EphemeralPublicKey)
+	case g.private == nil || resp.RecipientPublicKey != g.private.PublicKey():
+		return nil, fmt.Errorf("secret of epoch %d is wrapped for another key of user %s", epoch, g.userID)
+	default:
+		shared, err = g.private.SharedSecret(resp.EphemeralPublicKey)
+	}
+	if err != nil {
+		return nil, err
+	}
+	secret, err = algos.UnwrapEpochSecret(g.kdf, g.algorithm, shared, resp.WrappedSecret, g.userID, resp.EphemeralPublicKey, epoch)
+	if err != nil {
+		return nil, fmt.Errorf("secret of epoch %d: %w", epoch, err)
+	}
+
+	g.mu.Lock()
+	g.epochs[epoch] = secret
+	g.mu.Unlock()
+	return secret, nil
+}
+
+func chainBinding(chatID, senderID, recipientID, keyID, ratchetKey string, epoch uint32) []string {
+	return []string{chatID, senderID, recipientID, keyID, ratchetKey, strconv.FormatUint(uint64(epoch), 10)}
+}
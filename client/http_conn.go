@@ -0,0 +1,82 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// RPCContentType — тип тела запросов и ответов моста /rpc/: сообщение protobuf без рамки gRPC
+const RPCContentType = "application/x-protobuf"
+
+// HTTPConn передаёт унарные вызовы gRPC через HTTP-мост сервера: тело запроса — сообщение
+// protobuf, путь — baseURL + "/rpc" + полное имя метода. Так клиентские ключи работают там,
+// где gRPC недоступен, например в браузере; потоковые вызовы мост не поддерживает.
+// Мост принимает вызовы только с токеном пользователя из Login.
+type HTTPConn struct {
+	baseURL string
+	token   string
+	client  *http.Client
+}
+
+func NewHTTPConn(baseURL, token string) *HTTPConn {
+	return &HTTPConn{baseURL: strings.TrimSuffix(baseURL, "/"), token: token, client: http.DefaultClient}
+}
+
+func (c *HTTPConn) Invoke(ctx context.Context, method string, args, reply any, opts ...grpc.CallOption) error {
+	request, ok := args.(proto.Message)
+	if !ok {
+		return fmt.Errorf("%s: request is not a protobuf message", method)
+	}
+	body, err := proto.Marshal(request)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/rpc"+method, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", RPCContentType)
+	// токен пользователя мост передаст дальше в метаданных вызова
+	md, _ := metadata.FromOutgoingContext(ctx)
+	for _, value := range md.Get("authorization") {
+		req.Header.Add("Authorization", value)
+	}
+	if req.Header.Get("Authorization") == "" && c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return status.Error(codes.Unavailable, err.Error())
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return status.Error(codes.Unavailable, err.Error())
+	}
+
+	// мост передаёт статус gRPC в заголовках, как в ответе gRPC-Web
+	if code := resp.Header.Get("Grpc-Status"); code != "" && code != "0" {
+		n, _ := strconv.Atoi(code)
+		return status.Error(codes.Code(n), resp.Header.Get("Grpc-Message"))
+	}
+	if resp.StatusCode != http.StatusOK {
+		return status.Errorf(codes.Unknown, "%s: %s", resp.Status, strings.TrimSpace(string(data)))
+	}
+	return proto.Unmarshal(data, reply.(proto.Message))
+}
+
+func (c *HTTPConn) NewStream(ctx context.Context, desc *grpc.StreamDesc, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	return nil, status.Errorf(codes.Unimplemented, "streaming call %s is not supported over HTTP", method)
+}
@@ -0,0 +1,277 @@
+package client
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"Kygram/algos"
+	"Kygram/proto/protopb"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+var (
+	ErrUnsigned        = errors.New("message is not signed")
+	ErrUnsignedKey     = errors.New("key is not signed")
+	ErrUnknownIdentity = errors.New("sender has no identity key")
+	ErrReplayedMessage = errors.New("message sequence number was already used")
+)
+
+// LoadOrCreateIdentity читает ключ личности пользователя из dir или создаёт новый.
+// Ключ хранится только на стороне клиента: зерно в шестнадцатеричном виде, права 0600.
+func LoadOrCreateIdentity(dir, userID string) (*algos.IdentityKey, error) {
+	return LoadOrCreateIdentityIn(DirStorage{Dir: dir}, userID)
+}
+
+// LoadOrCreateIdentityIn — то же для произвольного хранилища ключей клиента
+func LoadOrCreateIdentityIn(storage KeyStorage, userID string) (*algos.IdentityKey, error) {
+	name := userID + ".ed25519"
+	data, err := storage.Load(name)
+	if err == nil {
+		seed, err := hex.DecodeString(strings.TrimSpace(string(data)))
+		if err != nil {
+			return nil, fmt.Errorf("invalid identity key file %s: %w", name, err)
+		}
+		return algos.NewIdentityKey(seed)
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read identity key: %w", err)
+	}
+
+	key, err := algos.GenerateIdentityKey()
+	if err != nil {
+		return nil, err
+	}
+	if err := storage.Store(name, []byte(hex.EncodeToString(key.Seed()))); err != nil {
+		return nil, fmt.Errorf("failed to save identity key: %w", err)
+	}
+	return key, nil
+}
+
+type UserClient struct {
+	client protopb.UserServiceClient
+}
+
+func NewUserClient(serverAddr string) (*UserClient, error) {
+	conn, err := grpc.Dial(serverAddr, grpc.WithInsecure())
+	if err != nil {
+		return nil, err
+	}
+	return NewUserClientWithConn(conn), nil
+}
+
+// NewUserClientWithConn работает поверх готового соединения, например HTTPConn в браузере
+func NewUserClientWithConn(conn grpc.ClientConnInterface) *UserClient {
+	return &UserClient{client: protopb.NewUserServiceClient(conn)}
+}
+
+// RegisterIdentityKey публикует открытый ключ личности, подписав запрос им же; token —
+// токен пользователя, выданный Login
+func (c *UserClient) RegisterIdentityKey(token, userID string, key *algos.IdentityKey) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token)
+
+	publicKey := key.PublicKey()
+	resp, err := c.client.RegisterIdentityKey(ctx, &protopb.RegisterIdentityKeyRequest{
+		UserId:    userID,
+		PublicKey: publicKey,
+		Signature: key.Sign(algos.IdentityRegistrationPayload(userID, publicKey)),
+	})
+	if err != nil {
+		return err
+	}
+	if !resp.Success {
+		return fmt.Errorf("identity key rejected: %s", resp.Message)
+	}
+	return nil
+}
+
+func (c *UserClient) GetIdentityKey(userID string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	resp, err := c.client.GetIdentityKey(ctx, &protopb.GetIdentityKeyRequest{UserId: userID})
+	if err != nil {
+		return "", err
+	}
+	return resp.PublicKey, nil
+}
+
+// identityKeys запоминает ключи личности пользователей для проверки подписей под их ключами
+// согласования; если подпись не сходится, ключ запрашивается заново: пользователь мог его сменить
+type identityKeys struct {
+	users *UserClient
+
+	mu   sync.Mutex
+	keys map[string]string
+}
+
+func newIdentityKeys(users *UserClient) *identityKeys {
+	return &identityKeys{users: users, keys: make(map[string]string)}
+}
+
+func (k *identityKeys) verify(userID string, payload, signature []byte) error {
+	if len(signature) == 0 {
+		return ErrUnsignedKey
+	}
+	k.mu.Lock()
+	publicKey, cached := k.keys[userID]
+	k.mu.Unlock()
+	if cached && algos.VerifySignature(publicKey, payload, signature) == nil {
+		return nil
+	}
+	fresh, err := k.users.GetIdentityKey(userID)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrUnknownIdentity, err)
+	}
+	if err := algos.VerifySignature(fresh, payload, signature); err != nil {
+		return err
+	}
+	k.mu.Lock()
+	k.keys[userID] = fresh
+	k.mu.Unlock()
+	return nil
+}
+
+// MessageSigner подписывает сообщения одного отправителя в чате, продолжая нумерацию
+// с последнего сохранённого номера
+type MessageSigner struct {
+	signer Signer
+
+	mu       sync.Mutex
+	sequence uint64
+}
+
+func NewMessageSigner(signer Signer, lastSequence uint64) *MessageSigner {
+	return &MessageSigner{signer: signer, sequence: lastSequence}
+}
+
+// Sign заполняет в сообщении номер, время и подпись
+func (s *MessageSigner) Sign(msg *protopb.Message) error {
+	s.mu.Lock()
+	s.sequence++
+	msg.Sequence = s.sequence
+	s.mu.Unlock()
+
+	msg.SignedAt = time.Now().UnixMilli()
+	signature, err := s.signer.Sign(MessageEnvelope(msg).Payload())
+	if err != nil {
+		msg.Sequence, msg.SignedAt = 0, 0
+		return fmt.Errorf("failed to sign message: %w", err)
+	}
+	msg.Signature = signature
+	return nil
+}
+
+// MessageEnvelope выбирает из сообщения подписываемые поля
+func MessageEnvelope(msg *protopb.Message) algos.SignedEnvelope {
+	return algos.SignedEnvelope{
+		ChatID:      msg.ChatId,
+		SenderID:    msg.SenderId,
+		Sequence:    msg.Sequence,
+		Timestamp:   msg.SignedAt,
+		MessageType: msg.MessageType,
+		FileName:    msg.FileName,
+		ChunkIndex:  msg.ChunkIndex,
+		Ciphertext:  msg.EncryptedMessage,
+	}
+}
+
+// RecordEnvelope выбирает подписываемые поля из записи истории чата chatID
+func RecordEnvelope(chatID string, rec *protopb.MessageRecord) algos.SignedEnvelope {
+	return algos.SignedEnvelope{
+		ChatID:      chatID,
+		SenderID:    rec.SenderId,
+		Sequence:    rec.Sequence,
+		Timestamp:   rec.SignedAt,
+		MessageType: rec.MessageType,
+		FileName:    rec.FileName,
+		ChunkIndex:  rec.ChunkIndex,
+		Ciphertext:  rec.EncryptedMessage,
+	}
+}
+
+// MessageVerifier проверяет подписи сообщений по ключам личности отправителей и отвергает
+// повторы: номер сообщения каждого отправителя должен расти
+type MessageVerifier struct {
+	users *UserClient
+
+	mu       sync.Mutex
+	keys     map[string]string // отправитель -> ключ личности
+	sequence map[string]uint64 // отправитель -> последний принятый номер
+	onChange func(senderID, publicKey string)
+}
+
+func (c *UserClient) NewMessageVerifier() *MessageVerifier {
+	return &MessageVerifier{
+		users:    c,
+		keys:     make(map[string]string),
+		sequence: make(map[string]uint64),
+	}
+}
+
+// OnKeyChange задаёт функцию, которую Verify вызывает, когда подпись отправителя сходится
+// только с его новым ключом личности
+func (v *MessageVerifier) OnKeyChange(f func(senderID, publicKey string)) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.onChange = f
+}
+
+func (v *MessageVerifier) Verify(envelope algos.SignedEnvelope, signature []byte) error {
+	if len(signature) == 0 {
+		return ErrUnsigned
+	}
+
+	v.mu.Lock()
+	publicKey, cached := v.keys[envelope.SenderID]
+	v.mu.Unlock()
+
+	err := algos.ErrInvalidSignature
+	if cached {
+		err = algos.VerifySignature(publicKey, envelope.Payload(), signature)
+	}
+	// отправитель мог сменить ключ после того, как мы его запомнили
+	if err != nil {
+		fresh, fetchErr := v.users.GetIdentityKey(envelope.SenderID)
+		if fetchErr != nil {
+			if cached {
+				return err
+			}
+			return fmt.Errorf("%w: %v", ErrUnknownIdentity, fetchErr)
+		}
+		if fresh == publicKey {
+			return err
+		}
+		if err = algos.VerifySignature(fresh, envelope.Payload(), signature); err != nil {
+			return err
+		}
+		publicKey = fresh
+	}
+
+	v.mu.Lock()
+	onChange := v.onChange
+	changed := cached && v.keys[envelope.SenderID] != publicKey
+	v.keys[envelope.SenderID] = publicKey
+	replayed := envelope.Sequence <= v.sequence[envelope.SenderID]
+	if !replayed {
+		v.sequence[envelope.SenderID] = envelope.Sequence
+	}
+	v.mu.Unlock()
+
+	if changed && onChange != nil {
+		onChange(envelope.SenderID, publicKey)
+	}
+	if replayed {
+		return ErrReplayedMessage
+	}
+	return nil
+}
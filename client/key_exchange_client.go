@@ -2,18 +2,20 @@ package client
 
 import (
 	"context"
-	"log"
-	"math/big"
+	"fmt"
 	"time"
 
 	"Kygram/algos"
 	"Kygram/proto/protopb"
 
+	"github.com/google/uuid"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
 )
 
 type KeyExchangeClient struct {
 	client protopb.KeyExchangeServiceClient
+	token  string
 }
 
 func NewKeyExchangeClient(serverAddr string) (*KeyExchangeClient, error) {
@@ -22,74 +24,250 @@ func NewKeyExchangeClient(serverAddr string) (*KeyExchangeClient, error) {
 		return nil, err
 	}
 
-	client := protopb.NewKeyExchangeServiceClient(conn)
-	return &KeyExchangeClient{client: client}, nil
+	return NewKeyExchangeClientWithConn(conn), nil
 }
 
-func (c *KeyExchangeClient) GenerateAndSendKey(chatID string, userID string) (*big.Int, error) {
-	prime, err := algos.GeneratePrime(1024)
-	if err != nil {
-		return nil, err
+// NewKeyExchangeClientWithConn работает поверх готового соединения, например HTTPConn в браузере
+func NewKeyExchangeClientWithConn(conn grpc.ClientConnInterface) *KeyExchangeClient {
+	return &KeyExchangeClient{client: protopb.NewKeyExchangeServiceClient(conn)}
+}
+
+// WithToken возвращает клиент, который передаёт в каждом вызове токен пользователя из Login:
+// без него сервис не примет вызовы, меняющие ключи пользователя
+func (c *KeyExchangeClient) WithToken(token string) *KeyExchangeClient {
+	return &KeyExchangeClient{client: c.client, token: token}
+}
+
+func (c *KeyExchangeClient) callContext() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	if c.token != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+c.token)
 	}
+	return ctx, cancel
+}
 
-	privateKey, err := algos.GeneratePrivateKey(prime)
+// GenerateAndSendKey создаёт ключевую пару по алгоритму согласования чата и публикует открытый ключ,
+// подписанный ключом личности, как текущий ключ устройства deviceID; возвращает ключ и его
+// идентификатор для последующего отзыва
+func (c *KeyExchangeClient) GenerateAndSendKey(chatID, userID, deviceID string, agreement algos.KeyAgreement, identity Signer) (algos.AgreementKey, string, error) {
+	privateKey, err := agreement.GenerateKey()
 	if err != nil {
-		return nil, err
+		return nil, "", err
+	}
+	keyID := uuid.New().String()
+	signature, err := identity.Sign(algos.SessionKeyPayload(chatID, userID, deviceID, keyID, privateKey.PublicKey()))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to sign public key: %w", err)
 	}
 
-	generator := big.NewInt(2)
-	publicKey := algos.GeneratePublicKey(generator, privateKey, prime)
-
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := c.callContext()
 	defer cancel()
 
-	_, err = c.client.SendPublicKey(ctx, &protopb.SendPublicKeyRequest{
+	resp, err := c.client.SendPublicKey(ctx, &protopb.SendPublicKeyRequest{
 		ChatId:    chatID,
 		ClientId:  userID,
-		PublicKey: publicKey.String(),
+		PublicKey: privateKey.PublicKey(),
+		DeviceId:  deviceID,
+		KeyId:     keyID,
+		Signature: signature,
 	})
 	if err != nil {
-		return nil, err
+		return nil, "", err
+	}
+	if !resp.Success {
+		return nil, "", fmt.Errorf("public key rejected: %s", resp.Error)
 	}
 
-	return privateKey, nil
+	return privateKey, resp.KeyId, nil
 }
 
-func (c *KeyExchangeClient) GetPeerKeys(chatID string) ([]*protopb.ClientPublicKey, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+// RevokePublicKeys отзывает текущие ключи устройства; пустые chatID и keyID означают
+// все чаты и все ключи устройства
+func (c *KeyExchangeClient) RevokePublicKeys(chatID, userID, deviceID, keyID string) (uint32, error) {
+	ctx, cancel := c.callContext()
+	defer cancel()
+
+	resp, err := c.client.RevokePublicKeys(ctx, &protopb.RevokePublicKeysRequest{
+		ChatId:   chatID,
+		ClientId: userID,
+		DeviceId: deviceID,
+		KeyId:    keyID,
+	})
+	if err != nil {
+		return 0, err
+	}
+	if !resp.Success {
+		return 0, fmt.Errorf("revocation rejected: %s", resp.Error)
+	}
+	return resp.Revoked, nil
+}
+
+func (c *KeyExchangeClient) GetPublicKeyHistory(chatID, userID string) ([]*protopb.ClientPublicKey, error) {
+	ctx, cancel := c.callContext()
+	defer cancel()
+
+	resp, err := c.client.GetPublicKeyHistory(ctx, &protopb.PublicKeyHistoryRequest{ChatId: chatID, ClientId: userID})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Keys, nil
+}
+
+// GetPeerKeys возвращает текущие ключи устройств участников чата и текущую эпоху ключей;
+// участник не в сети представлен записью без ключа
+func (c *KeyExchangeClient) GetPeerKeys(chatID string) ([]*protopb.ClientPublicKey, uint32, error) {
+	ctx, cancel := c.callContext()
 	defer cancel()
 
 	resp, err := c.client.ExchangeKeys(ctx, &protopb.KeyExchangeRequest{
 		ChatId: chatID,
 	})
 	if err != nil {
-		return nil, err
+		return nil, 0, err
+	}
+
+	return resp.PublicKeys, resp.KeyEpoch, nil
+}
+
+func (c *KeyExchangeClient) DistributeSenderKey(chatID, senderID, keyID, senderPublicKey, historyPublicKey string, signature []byte, keys []*protopb.WrappedSenderKey) error {
+	ctx, cancel := c.callContext()
+	defer cancel()
+
+	resp, err := c.client.DistributeSenderKey(ctx, &protopb.DistributeSenderKeyRequest{
+		ChatId:             chatID,
+		SenderId:           senderID,
+		KeyId:              keyID,
+		SenderPublicKey:    senderPublicKey,
+		SenderKeySignature: signature,
+		HistoryPublicKey:   historyPublicKey,
+		Keys:               keys,
+	})
+	if err != nil {
+		return err
+	}
+	if !resp.Success {
+		return fmt.Errorf("sender key rejected: %s", resp.Error)
+	}
+	return nil
+}
+
+func (c *KeyExchangeClient) GetSenderKey(chatID, senderID, keyID, recipientID, recipientDeviceID string) (*protopb.GetSenderKeyResponse, error) {
+	ctx, cancel := c.callContext()
+	defer cancel()
+
+	return c.client.GetSenderKey(ctx, &protopb.GetSenderKeyRequest{
+		ChatId:            chatID,
+		SenderId:          senderID,
+		KeyId:             keyID,
+		RecipientId:       recipientID,
+		RecipientDeviceId: recipientDeviceID,
+	})
+}
+
+// GetEpochKey запрашивает секрет эпохи обычного чата, обёрнутый для ключа устройства deviceID
+// или, если он пуст, для подписанного предварительного ключа пользователя
+func (c *KeyExchangeClient) GetEpochKey(chatID, userID, deviceID string, epoch uint32) (*protopb.GetEpochKeyResponse, error) {
+	ctx, cancel := c.callContext()
+	defer cancel()
+
+	return c.client.GetEpochKey(ctx, &protopb.GetEpochKeyRequest{ChatId: chatID, UserId: userID, DeviceId: deviceID, Epoch: epoch})
+}
+
+func (c *KeyExchangeClient) GetSafetyNumber(userID, peerID string) (*protopb.SafetyNumberResponse, error) {
+	ctx, cancel := c.callContext()
+	defer cancel()
+
+	return c.client.GetSafetyNumber(ctx, &protopb.SafetyNumberRequest{UserId: userID, PeerId: peerID})
+}
+
+// SetPeerVerified отмечает ключ личности собеседника publicKey проверенным или снимает отметку
+func (c *KeyExchangeClient) SetPeerVerified(userID, peerID, publicKey string, verified bool) error {
+	ctx, cancel := c.callContext()
+	defer cancel()
+
+	resp, err := c.client.SetPeerVerified(ctx, &protopb.SetPeerVerifiedRequest{
+		UserId:    userID,
+		PeerId:    peerID,
+		PublicKey: publicKey,
+		Verified:  verified,
+	})
+	if err != nil {
+		return err
 	}
+	if !resp.Success {
+		return fmt.Errorf("verification rejected: %s", resp.Error)
+	}
+	return nil
+}
 
-	return resp.PublicKeys, nil
+func (c *KeyExchangeClient) GetPeerVerification(userID, chatID string) ([]*protopb.PeerVerification, error) {
+	ctx, cancel := c.callContext()
+	defer cancel()
+
+	resp, err := c.client.GetPeerVerification(ctx, &protopb.PeerVerificationRequest{UserId: userID, ChatId: chatID})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Peers, nil
 }
 
-func (c *KeyExchangeClient) ComputeSharedKeys(privateKey *big.Int, peerKeys []*protopb.ClientPublicKey, prime *big.Int) map[string][]byte {
-	sharedKeys := make(map[string][]byte)
+// UploadPrekeys публикует предварительные ключи и возвращает запас одноразовых на сервере;
+// replace заменяет прежний запас вместо пополнения
+func (c *KeyExchangeClient) UploadPrekeys(userID string, signed *protopb.Prekey, oneTime []*protopb.Prekey, replace bool) (uint32, error) {
+	ctx, cancel := c.callContext()
+	defer cancel()
 
-	for _, peer := range peerKeys {
-		if peer.PublicKey == "" {
-			log.Printf("Empty public key for client %s, skipping", peer.ClientId)
-			continue
-		}
+	resp, err := c.client.UploadPrekeys(ctx, &protopb.UploadPrekeysRequest{
+		UserId:         userID,
+		SignedPrekey:   signed,
+		OneTimePrekeys: oneTime,
+		Replace:        replace,
+	})
+	if err != nil {
+		return 0, err
+	}
+	if !resp.Success {
+		return 0, fmt.Errorf("prekeys rejected: %s", resp.Error)
+	}
+	return resp.OneTimeCount, nil
+}
 
-		peerPublicKey, ok := new(big.Int).SetString(peer.PublicKey, 10)
-		if !ok {
-			log.Printf("Failed to parse public key for client %s", peer.ClientId)
-			continue
-		}
+func (c *KeyExchangeClient) GetPrekeyStatus(userID string) (*protopb.PrekeyStatusResponse, error) {
+	ctx, cancel := c.callContext()
+	defer cancel()
 
-		sharedKey := algos.GenerateSharedKey(privateKey, peerPublicKey, prime)
+	return c.client.GetPrekeyStatus(ctx, &protopb.PrekeyStatusRequest{UserId: userID})
+}
 
-		hashedKey := algos.HashSharedKey(sharedKey)
+// FetchPrekeyBundle запрашивает набор ключей участника чата userID; сервер расходует
+// один его одноразовый ключ, а с signedOnly выдаёт только подписанный
+func (c *KeyExchangeClient) FetchPrekeyBundle(chatID, requesterID, userID string, signedOnly bool) (*algos.PrekeyBundle, error) {
+	ctx, cancel := c.callContext()
+	defer cancel()
+
+	resp, err := c.client.FetchPrekeyBundle(ctx, &protopb.FetchPrekeyBundleRequest{
+		ChatId:      chatID,
+		RequesterId: requesterID,
+		UserId:      userID,
+		SignedOnly:  signedOnly,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if resp.SignedPrekey == nil {
+		return nil, fmt.Errorf("bundle of %s has no signed prekey", userID)
+	}
 
-		sharedKeys[peer.ClientId] = hashedKey
+	bundle := &algos.PrekeyBundle{
+		UserID:                userID,
+		IdentityKey:           resp.IdentityKey,
+		SignedPrekeyID:        resp.SignedPrekey.KeyId,
+		SignedPrekey:          resp.SignedPrekey.PublicKey,
+		SignedPrekeySignature: resp.SignedPrekey.Signature,
+	}
+	if resp.OneTimePrekey != nil {
+		bundle.OneTimePrekeyID = resp.OneTimePrekey.KeyId
+		bundle.OneTimePrekey = resp.OneTimePrekey.PublicKey
 	}
-	log.Printf("[DH CLIENT] Успешно вычислены %d общих ключей", len(sharedKeys))
-	return sharedKeys
+	return bundle, nil
 }
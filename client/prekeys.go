@@ -0,0 +1,251 @@
+package client
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"Kygram/algos"
+	"Kygram/proto/protopb"
+)
+
+const (
+	// PrekeyPoolSize — сколько одноразовых ключей пользователь держит на сервере;
+	// запас пополняется, когда в нём остаётся меньше половины
+	PrekeyPoolSize = 100
+	// SignedPrekeyLifetime — через сколько подписанный ключ заменяется новым
+	SignedPrekeyLifetime = 7 * 24 * time.Hour
+	// PrekeyRetention — сколько хранятся закрытые части использованных одноразовых ключей, чтобы
+	// дочитать отправленную по ним историю. Заменённые подписанные ключи не забываются: по ним
+	// обёрнуты копии цепочек для истории.
+	PrekeyRetention = 14 * 24 * time.Hour
+)
+
+var ErrPrekeyNotFound = errors.New("prekey not found")
+
+// PrekeyStore хранит закрытые предварительные ключи пользователя рядом с ключом личности.
+// Подключения одного пользователя в процессе работают с общим хранилищем.
+type PrekeyStore struct {
+	storage KeyStorage
+	userID  string
+
+	mu    sync.Mutex
+	state prekeyState
+}
+
+type prekeyState struct {
+	NextID  uint32         `json:"next_id"`
+	Signed  []storedPrekey `json:"signed"` // текущий — последний
+	OneTime []storedPrekey `json:"one_time"`
+}
+
+type storedPrekey struct {
+	ID        uint32    `json:"id"`
+	Private   string    `json:"private"`
+	CreatedAt time.Time `json:"created_at"`
+	// подписанный ключ — когда его заменил новый, одноразовый — когда им воспользовались
+	RetiredAt *time.Time `json:"retired_at,omitempty"`
+}
+
+type prekeyStoreKey struct {
+	storage KeyStorage
+	userID  string
+}
+
+var prekeyStores sync.Map // prekeyStoreKey -> *PrekeyStore
+
+func OpenPrekeyStore(dir, userID string) (*PrekeyStore, error) {
+	return OpenPrekeyStoreIn(DirStorage{Dir: dir}, userID)
+}
+
+// OpenPrekeyStoreIn открывает предварительные ключи пользователя в произвольном хранилище
+func OpenPrekeyStoreIn(storage KeyStorage, userID string) (*PrekeyStore, error) {
+	key := prekeyStoreKey{storage, userID}
+	if store, ok := prekeyStores.Load(key); ok {
+		return store.(*PrekeyStore), nil
+	}
+
+	store := &PrekeyStore{storage: storage, userID: userID}
+	data, err := storage.Load(store.name())
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(data, &store.state); err != nil {
+			return nil, fmt.Errorf("invalid prekey file %s: %w", store.name(), err)
+		}
+	case !errors.Is(err, os.ErrNotExist):
+		return nil, fmt.Errorf("failed to read prekeys: %w", err)
+	}
+
+	actual, _ := prekeyStores.LoadOrStore(key, store)
+	return actual.(*PrekeyStore), nil
+}
+
+// Publish заменяет устаревший подписанный ключ, пополняет запас одноразовых ключей на сервере
+// и забывает использованные одноразовые ключи старше PrekeyRetention
+func (s *PrekeyStore) Publish(kx *KeyExchangeClient, identity Signer) error {
+	status, err := kx.GetPrekeyStatus(s.userID)
+	if err != nil {
+		return fmt.Errorf("failed to get prekey status: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	s.prune(now)
+
+	// новое хранилище не знает закрытых частей ключей, опубликованных раньше, и заменяет весь запас
+	fresh := len(s.state.Signed) == 0
+	var signed *protopb.Prekey
+	current := s.currentSigned()
+	if current == nil || now.Sub(current.CreatedAt) >= SignedPrekeyLifetime {
+		if current != nil {
+			current.RetiredAt = &now
+		}
+		key, err := s.generate(&s.state.Signed, now)
+		if err != nil {
+			return err
+		}
+		signed = &protopb.Prekey{KeyId: key.ID, PublicKey: key.PublicKey()}
+	} else if status.SignedPrekeyId != current.ID {
+		// сервер потерял ключ или хранит чужой: публикуем текущий заново
+		key, err := current.prekey()
+		if err != nil {
+			return err
+		}
+		signed = &protopb.Prekey{KeyId: key.ID, PublicKey: key.PublicKey()}
+	}
+	if signed != nil {
+		if signed.Signature, err = identity.Sign(algos.SignedPrekeyPayload(s.userID, signed.KeyId, signed.PublicKey)); err != nil {
+			return fmt.Errorf("failed to sign prekey: %w", err)
+		}
+	}
+
+	var oneTime []*protopb.Prekey
+	if fresh || int(status.OneTimeCount) < PrekeyPoolSize/2 {
+		have := int(status.OneTimeCount)
+		if fresh {
+			have = 0
+		}
+		for i := have; i < PrekeyPoolSize; i++ {
+			key, err := s.generate(&s.state.OneTime, now)
+			if err != nil {
+				return err
+			}
+			oneTime = append(oneTime, &protopb.Prekey{KeyId: key.ID, PublicKey: key.PublicKey()})
+		}
+	}
+
+	// закрытые части сохраняются до публикации, чтобы сервер не выдал ключ, которого у нас нет
+	if err := s.save(); err != nil {
+		return err
+	}
+	if signed == nil && len(oneTime) == 0 {
+		return nil
+	}
+	count, err := kx.UploadPrekeys(s.userID, signed, oneTime, fresh)
+	if err != nil {
+		return err
+	}
+	log.Printf("[PREKEYS] Пользователь %s опубликовал %d одноразовых ключей (запас %d), новый подписанный ключ: %t",
+		s.userID, len(oneTime), count, signed != nil)
+	return nil
+}
+
+// SharedSecret вычисляет секрет X3DH получателя для ключа, обёрнутого по его предварительным ключам.
+// Использованный одноразовый ключ хранится ещё PrekeyRetention и затем забывается.
+func (s *PrekeyStore) SharedSecret(signedID, oneTimeID uint32, ephemeralPublicKey string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored := findPrekey(s.state.Signed, signedID)
+	if stored == nil {
+		return nil, fmt.Errorf("%w: signed prekey %d", ErrPrekeyNotFound, signedID)
+	}
+	signed, err := stored.prekey()
+	if err != nil {
+		return nil, err
+	}
+
+	var oneTime *algos.Prekey
+	if oneTimeID != 0 {
+		stored := findPrekey(s.state.OneTime, oneTimeID)
+		if stored == nil {
+			return nil, fmt.Errorf("%w: one-time prekey %d", ErrPrekeyNotFound, oneTimeID)
+		}
+		if oneTime, err = stored.prekey(); err != nil {
+			return nil, err
+		}
+		if stored.RetiredAt == nil {
+			now := time.Now()
+			stored.RetiredAt = &now
+			if err := s.save(); err != nil {
+				log.Println("Failed to save prekeys:", err)
+			}
+		}
+	}
+	return algos.X3DHRespond(signed, oneTime, ephemeralPublicKey)
+}
+
+func (s *PrekeyStore) currentSigned() *storedPrekey {
+	if len(s.state.Signed) == 0 {
+		return nil
+	}
+	return &s.state.Signed[len(s.state.Signed)-1]
+}
+
+func (s *PrekeyStore) generate(keys *[]storedPrekey, now time.Time) (*algos.Prekey, error) {
+	s.state.NextID++
+	key, err := algos.GeneratePrekey(s.state.NextID)
+	if err != nil {
+		return nil, err
+	}
+	*keys = append(*keys, storedPrekey{ID: key.ID, Private: hex.EncodeToString(key.PrivateBytes()), CreatedAt: now})
+	return key, nil
+}
+
+func (s *PrekeyStore) prune(now time.Time) {
+	kept := s.state.OneTime[:0]
+	for _, key := range s.state.OneTime {
+		if key.RetiredAt == nil || now.Sub(*key.RetiredAt) < PrekeyRetention {
+			kept = append(kept, key)
+		}
+	}
+	s.state.OneTime = kept
+}
+
+func (s *PrekeyStore) name() string {
+	return s.userID + ".prekeys"
+}
+
+func (s *PrekeyStore) save() error {
+	data, err := json.Marshal(&s.state)
+	if err != nil {
+		return err
+	}
+	if err := s.storage.Store(s.name(), data); err != nil {
+		return fmt.Errorf("failed to save prekeys: %w", err)
+	}
+	return nil
+}
+
+func findPrekey(keys []storedPrekey, id uint32) *storedPrekey {
+	for i := range keys {
+		if keys[i].ID == id {
+			return &keys[i]
+		}
+	}
+	return nil
+}
+
+func (k *storedPrekey) prekey() (*algos.Prekey, error) {
+	private, err := hex.DecodeString(k.Private)
+	if err != nil {
+		return nil, fmt.Errorf("invalid prekey %d: %w", k.ID, err)
+	}
+	return algos.NewPrekey(k.ID, private)
+}
@@ -0,0 +1,472 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+	"time"
+
+	"Kygram/algos"
+	"Kygram/proto/protopb"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+)
+
+var ErrNotEndToEnd = errors.New("chat is not end-to-end encrypted")
+
+// SessionConfig — подключение клиента к чату со сквозным шифрованием. Conn — соединение
+// gRPC или HTTPConn, Storage — хранилище ключа личности и предварительных ключей,
+// Token — токен пользователя из Login, без него не зарегистрировать ключ личности и не
+// опубликовать ключи.
+type SessionConfig struct {
+	Conn     grpc.ClientConnInterface
+	Storage  KeyStorage
+	Token    string
+	ChatID   string
+	UserID   string
+	DeviceID string
+}
+
+// ChatSession шифрует и расшифровывает сообщения чата со сквозным шифрованием на стороне
+// клиента. Ключи согласования, цепочки отправителей и ключ личности не покидают клиент;
+// сервер только хранит и пересылает готовые конверты.
+type ChatSession struct {
+	conn     grpc.ClientConnInterface
+	chatID   string
+	userID   string
+	deviceID string
+	keyID    string
+	params   *protopb.ConnectResponse
+
+	kx          *KeyExchangeClient
+	history     protopb.ClientServiceClient
+	keys        *GroupKeys
+	historyKeys *GroupKeys
+	signer      *MessageSigner
+	verifier    *MessageVerifier
+	users       *UserClient
+
+	mu       sync.Mutex
+	outgoing map[string]*sessionOutgoingFile // имя файла -> шифруемый файл
+	incoming map[string]*sessionIncomingFile // "отправитель/имя файла" -> собираемый файл
+}
+
+type sessionOutgoingFile struct {
+	header    RatchetHeader
+	encrypted bytes.Buffer
+	encryptor io.WriteCloser
+	nextChunk int
+}
+
+type sessionIncomingFile struct {
+	key       []byte
+	keyErr    error
+	verifyErr error
+	encrypted bytes.Buffer
+	nextChunk int32
+}
+
+// DecryptedMessage — расшифрованное сообщение. VerifyErr объясняет, почему не проверена подпись
+// отправителя; у истории заполнены имя отправителя и время.
+type DecryptedMessage struct {
+	SenderID    string
+	SenderName  string
+	CreatedAt   string
+	MessageType string
+	FileName    string
+	Data        []byte
+	VerifyErr   error
+	Err         error // сообщение не удалось расшифровать
+}
+
+// OpenChatSession получает параметры чата, публикует ключ устройства, ключ личности
+// и предварительные ключи и готовит цепочки отправителей
+func OpenChatSession(cfg SessionConfig) (*ChatSession, error) {
+	// по устройству получатели различают ключи подключений одного пользователя
+	if cfg.DeviceID == "" {
+		return nil, errors.New("device ID is required")
+	}
+	history := protopb.NewClientServiceClient(cfg.Conn)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	params, err := history.Connect(ctx, &protopb.ConnectRequest{ChatId: cfg.ChatID, ClientId: cfg.UserID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to chat: %w", err)
+	}
+	if !params.E2E {
+		return nil, ErrNotEndToEnd
+	}
+
+	agreement, err := algos.NewKeyAgreement(params.KeyAgreement, params.DhGroup, params.Prime, params.Generator)
+	if err != nil {
+		return nil, err
+	}
+	salt, err := hex.DecodeString(params.KdfSalt)
+	if err != nil {
+		return nil, fmt.Errorf("invalid KDF salt: %w", err)
+	}
+	kdf, err := algos.NewKeyDerivation(salt, cfg.ChatID, params.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &ChatSession{
+		conn:     cfg.Conn,
+		chatID:   cfg.ChatID,
+		userID:   cfg.UserID,
+		deviceID: cfg.DeviceID,
+		params:   params,
+		kx:       NewKeyExchangeClientWithConn(cfg.Conn).WithToken(cfg.Token),
+		history:  history,
+		users:    NewUserClientWithConn(cfg.Conn),
+		outgoing: make(map[string]*sessionOutgoingFile),
+		incoming: make(map[string]*sessionIncomingFile),
+	}
+
+	// без подписи получатели не отличат сообщения участника от подделки сервера
+	identity, err := LoadOrCreateIdentityIn(cfg.Storage, cfg.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.users.RegisterIdentityKey(cfg.Token, cfg.UserID, identity); err != nil {
+		return nil, fmt.Errorf("failed to register identity key: %w", err)
+	}
+	records, err := s.fetchHistory()
+	if err != nil {
+		return nil, err
+	}
+	var lastSequence uint64
+	for _, rec := range records {
+		if rec.SenderId == cfg.UserID && rec.Sequence > lastSequence {
+			lastSequence = rec.Sequence
+		}
+	}
+	s.signer = NewMessageSigner(KeySigner{identity}, lastSequence)
+	s.verifier = s.users.NewMessageVerifier()
+
+	private, keyID, err := s.kx.GenerateAndSendKey(cfg.ChatID, cfg.UserID, cfg.DeviceID, agreement, KeySigner{identity})
+	if err != nil {
+		return nil, fmt.Errorf("failed to send public key: %w", err)
+	}
+	s.keyID = keyID
+	s.keys = s.kx.NewGroupKeys(cfg.ChatID, cfg.UserID, cfg.DeviceID, params.Algorithm, agreement, private, kdf)
+	s.keys.UseIdentity(KeySigner{identity}, s.users)
+	s.historyKeys = s.kx.NewGroupKeys(cfg.ChatID, cfg.UserID, "", params.Algorithm, agreement, nil, kdf)
+	s.historyKeys.UseIdentity(nil, s.users)
+
+	prekeys, err := OpenPrekeyStoreIn(cfg.Storage, cfg.UserID)
+	if err != nil {
+		log.Println("Failed to open prekey store:", err)
+	} else {
+		if err := prekeys.Publish(s.kx, KeySigner{identity}); err != nil {
+			log.Println("Failed to publish prekeys:", err)
+		}
+		s.keys.UsePrekeys(prekeys)
+		s.historyKeys.UsePrekeys(prekeys)
+	}
+
+	log.Printf("[E2E] Устройство %s пользователя %s подключено к чату %s", cfg.DeviceID, cfg.UserID, cfg.ChatID)
+	return s, nil
+}
+
+// KeyID — идентификатор ключа устройства, опубликованного сеансом
+func (s *ChatSession) KeyID() string {
+	return s.keyID
+}
+
+// OnKeyChange задаёт функцию, которую сеанс вызывает, когда ключ личности отправителя сменился
+func (s *ChatSession) OnKeyChange(f func(senderID, publicKey string)) {
+	s.verifier.OnKeyChange(f)
+}
+
+// OpenStream подключается к потоку сообщений чата; по HTTPConn потоки недоступны,
+// и сообщения передаёт WebSocket сервера
+func (s *ChatSession) OpenStream(ctx context.Context) (protopb.ChatService_StreamMessagesClient, error) {
+	stream, err := protopb.NewChatServiceClient(s.conn).StreamMessages(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := stream.Send(&protopb.Message{ChatId: s.chatID, SenderId: s.userID}); err != nil {
+		return nil, err
+	}
+	return stream, nil
+}
+
+// EncryptText шифрует и подписывает текстовое сообщение
+func (s *ChatSession) EncryptText(text string) (*protopb.Message, error) {
+	header, key, err := s.keys.NextMessageKey()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get message key: %w", err)
+	}
+	ctx, err := algos.NewMessageContext(s.params.Algorithm, s.params.Mode, s.params.Padding, key)
+	if err != nil {
+		return nil, err
+	}
+	ciphertext, err := ctx.EncryptWithAAD([]byte(text), algos.MessageAAD(s.chatID, s.userID))
+	if err != nil {
+		return nil, err
+	}
+
+	msg := s.envelope(header, "text", ciphertext)
+	msg.MessageId = uuid.New().String()
+	if err := s.signer.Sign(msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// EncryptFileChunk шифрует очередную часть файла одним потоком на весь файл и возвращает
+// подписанное сообщение с шифртекстом, готовым к этой части. Части должны идти по порядку.
+func (s *ChatSession) EncryptFileChunk(fileName string, chunkIndex, totalChunks int, data []byte) (*protopb.Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	file := s.outgoing[fileName]
+	if file == nil {
+		if chunkIndex != 0 {
+			return nil, fmt.Errorf("missing first chunk of file %s", fileName)
+		}
+		file = &sessionOutgoingFile{}
+		header, key, err := s.keys.NextMessageKey()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get message key: %w", err)
+		}
+		ctx, err := algos.NewMessageContext(s.params.Algorithm, s.params.Mode, s.params.Padding, key)
+		if err != nil {
+			return nil, err
+		}
+		file.header = header
+		if file.encryptor, err = algos.NewEncryptingWriterWithAAD(ctx, &file.encrypted, algos.MessageAAD(s.chatID, s.userID)); err != nil {
+			return nil, err
+		}
+		s.outgoing[fileName] = file
+	}
+
+	if chunkIndex != file.nextChunk {
+		delete(s.outgoing, fileName)
+		return nil, fmt.Errorf("chunk %d of file %s is out of order", chunkIndex+1, fileName)
+	}
+	file.nextChunk++
+	if _, err := file.encryptor.Write(data); err != nil {
+		delete(s.outgoing, fileName)
+		return nil, err
+	}
+	lastChunk := chunkIndex == totalChunks-1
+	if lastChunk {
+		delete(s.outgoing, fileName)
+		if err := file.encryptor.Close(); err != nil {
+			return nil, err
+		}
+	}
+
+	msg := s.envelope(file.header, "file", append([]byte(nil), file.encrypted.Bytes()...))
+	msg.FileName = fileName
+	msg.ChunkIndex = int32(chunkIndex)
+	msg.TotalChunks = int32(totalChunks)
+	file.encrypted.Reset()
+	if err := s.signer.Sign(msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+func (s *ChatSession) envelope(header RatchetHeader, messageType string, ciphertext []byte) *protopb.Message {
+	return &protopb.Message{
+		ChatId:           s.chatID,
+		SenderId:         s.userID,
+		EncryptedMessage: ciphertext,
+		Algorithm:        s.params.Algorithm,
+		Mode:             s.params.Mode,
+		Padding:          s.params.Padding,
+		MessageType:      messageType,
+		SenderKeyId:      header.KeyID,
+		RatchetIndex:     header.Index,
+		RatchetKey:       header.RatchetKey,
+		KeyEpoch:         header.Epoch,
+	}
+}
+
+// Decrypt проверяет подпись и расшифровывает сообщение из потока чата. Файл расшифровывается
+// целиком по последней части; для остальных частей возвращается nil.
+func (s *ChatSession) Decrypt(msg *protopb.Message) *DecryptedMessage {
+	result := &DecryptedMessage{SenderID: msg.SenderId, MessageType: msg.MessageType, FileName: msg.FileName}
+	if msg.ChatId != s.chatID || msg.SenderKeyId == "" {
+		result.Err = algos.ErrMessageKeyUnavailable
+		return result
+	}
+	verifyErr := s.verifier.Verify(MessageEnvelope(msg), msg.Signature)
+	header := RatchetHeader{KeyID: msg.SenderKeyId, Index: msg.RatchetIndex, RatchetKey: msg.RatchetKey, Epoch: msg.KeyEpoch}
+
+	if msg.MessageType != "file" {
+		result.VerifyErr = verifyErr
+		key, err := s.keys.MessageKey(msg.SenderId, header)
+		if err == nil {
+			result.Data, err = s.decrypt(key, msg.SenderId, msg.EncryptedMessage)
+		}
+		result.Err = err
+		return result
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fileKey := msg.SenderId + "/" + msg.FileName
+	file := s.incoming[fileKey]
+	if file == nil {
+		if msg.ChunkIndex != 0 {
+			log.Printf("Missing first chunk for file %s, dropping chunk %d", msg.FileName, msg.ChunkIndex+1)
+			return nil
+		}
+		// ключ сообщения выдаётся один раз, поэтому для файла он запрашивается по первой части
+		file = &sessionIncomingFile{}
+		file.key, file.keyErr = s.keys.MessageKey(msg.SenderId, header)
+		s.incoming[fileKey] = file
+	}
+	if msg.ChunkIndex != file.nextChunk {
+		log.Printf("Chunk %d of file %s arrived out of order, dropping the file", msg.ChunkIndex+1, msg.FileName)
+		delete(s.incoming, fileKey)
+		return nil
+	}
+	file.nextChunk++
+	if file.verifyErr == nil {
+		file.verifyErr = verifyErr
+	}
+	file.encrypted.Write(msg.EncryptedMessage)
+	if msg.ChunkIndex != msg.TotalChunks-1 {
+		return nil
+	}
+
+	delete(s.incoming, fileKey)
+	result.VerifyErr = file.verifyErr
+	result.Err = file.keyErr
+	if result.Err == nil {
+		result.Data, result.Err = s.decryptFile(file.key, msg.SenderId, &file.encrypted)
+	}
+	return result
+}
+
+// DecryptHistory расшифровывает сохранённую историю чата. Как и на сервере, читатель истории
+// получает копии цепочек для истории, обёрнутые по его подписанным предварительным ключам.
+func (s *ChatSession) DecryptHistory() ([]*DecryptedMessage, error) {
+	records, err := s.fetchHistory()
+	if err != nil {
+		return nil, err
+	}
+	verifier := s.users.NewMessageVerifier()
+	failedChains := make(map[string]error)
+	messageKey := func(rec *protopb.MessageRecord) ([]byte, error) {
+		if rec.SenderKeyId == "" {
+			return nil, algos.ErrMessageKeyUnavailable
+		}
+		chainID := rec.SenderId + "/" + rec.SenderKeyId
+		if err, failed := failedChains[chainID]; failed {
+			return nil, err
+		}
+		key, err := s.historyKeys.MessageKey(rec.SenderId, RatchetHeader{
+			KeyID:      rec.SenderKeyId,
+			Index:      rec.RatchetIndex,
+			RatchetKey: rec.RatchetKey,
+			Epoch:      rec.KeyEpoch,
+		})
+		if err != nil && !errors.Is(err, algos.ErrMessageKeyUnavailable) {
+			failedChains[chainID] = err
+		}
+		return key, err
+	}
+
+	var messages []*DecryptedMessage
+	fileParts := make(map[string][]io.Reader)
+	fileVerification := make(map[string]error)
+	for _, rec := range records {
+		result := &DecryptedMessage{
+			SenderID:    rec.SenderId,
+			SenderName:  rec.SenderName,
+			CreatedAt:   rec.CreatedAt,
+			MessageType: rec.MessageType,
+			FileName:    rec.FileName,
+		}
+		result.VerifyErr = verifier.Verify(RecordEnvelope(s.chatID, rec), rec.Signature)
+
+		if rec.MessageType == "file" {
+			fileKey := rec.SenderId + "/" + rec.FileName
+			if prev := fileVerification[fileKey]; prev != nil {
+				result.VerifyErr = prev
+			}
+			fileVerification[fileKey] = result.VerifyErr
+			fileParts[fileKey] = append(fileParts[fileKey], bytes.NewReader(rec.EncryptedMessage))
+			if rec.ChunkIndex != rec.TotalChunks-1 {
+				continue
+			}
+			parts := fileParts[fileKey]
+			delete(fileParts, fileKey)
+			delete(fileVerification, fileKey)
+
+			key, err := messageKey(rec)
+			if err == nil {
+				result.Data, err = s.decryptFile(key, rec.SenderId, io.MultiReader(parts...))
+			}
+			result.Err = err
+			messages = append(messages, result)
+			continue
+		}
+
+		key, err := messageKey(rec)
+		if err == nil {
+			result.Data, err = s.decrypt(key, rec.SenderId, rec.EncryptedMessage)
+		}
+		result.Err = err
+		messages = append(messages, result)
+	}
+	return messages, nil
+}
+
+// Close отзывает ключ устройства: отправители переходят на предварительные ключи пользователя
+func (s *ChatSession) Close() error {
+	_, err := s.kx.RevokePublicKeys(s.chatID, s.userID, s.deviceID, s.keyID)
+	return err
+}
+
+func (s *ChatSession) fetchHistory() ([]*protopb.MessageRecord, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	resp, err := s.history.GetChatHistory(ctx, &protopb.GetChatHistoryRequest{ChatId: s.chatID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chat history: %w", err)
+	}
+	return resp.Messages, nil
+}
+
+func (s *ChatSession) decrypt(key []byte, senderID string, ciphertext []byte) ([]byte, error) {
+	ctx, err := algos.NewMessageContext(s.params.Algorithm, s.params.Mode, s.params.Padding, key)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := ctx.DecryptWithAAD(ciphertext, algos.MessageAAD(s.chatID, senderID))
+	return plaintext, opaqueDecryptError(err)
+}
+
+func (s *ChatSession) decryptFile(key []byte, senderID string, encrypted io.Reader) ([]byte, error) {
+	ctx, err := algos.NewMessageContext(s.params.Algorithm, s.params.Mode, s.params.Padding, key)
+	if err != nil {
+		return nil, err
+	}
+	decrypted, err := algos.NewDecryptingReaderWithAAD(ctx, encrypted, algos.MessageAAD(s.chatID, senderID))
+	if err != nil {
+		return nil, opaqueDecryptError(err)
+	}
+	data, err := io.ReadAll(decrypted)
+	return data, opaqueDecryptError(err)
+}
+
+// opaqueDecryptError сводит ошибки разбора и проверки конверта к algos.ErrDecrypt
+func opaqueDecryptError(err error) error {
+	if errors.Is(err, algos.ErrInvalidEnvelope) || errors.Is(err, algos.ErrAuthentication) {
+		return algos.ErrDecrypt
+	}
+	return err
+}
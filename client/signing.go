@@ -0,0 +1,85 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"Kygram/algos"
+)
+
+var ErrSigningRefused = errors.New("signing request refused")
+
+// Signer подписывает данные ключом личности пользователя. Закрытый ключ может быть и вне
+// процесса: в обычных чатах сервер просит подпись у браузера.
+type Signer interface {
+	Sign(payload []byte) ([]byte, error)
+}
+
+// KeySigner подписывает ключом, который хранится у самого клиента
+type KeySigner struct {
+	Key *algos.IdentityKey
+}
+
+func (s KeySigner) Sign(payload []byte) ([]byte, error) {
+	return s.Key.Sign(payload), nil
+}
+
+// SigningGuard подписывает ключом личности то, что просит сервер обычного чата, но только
+// сообщения и ключи (подключения, храповика, предварительные) самого пользователя; номер сообщения в каждом чате должен
+// расти, поэтому повторно подписать старый номер сервер не заставит. Последние номера
+// хранятся рядом с ключом личности.
+type SigningGuard struct {
+	identity *algos.IdentityKey
+	storage  KeyStorage
+	userID   string
+
+	mu        sync.Mutex
+	sequences map[string]uint64 // чат -> последний подписанный номер
+}
+
+func NewSigningGuard(storage KeyStorage, userID string, identity *algos.IdentityKey) (*SigningGuard, error) {
+	g := &SigningGuard{identity: identity, storage: storage, userID: userID, sequences: make(map[string]uint64)}
+	data, err := storage.Load(g.name())
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(data, &g.sequences); err != nil {
+			return nil, fmt.Errorf("invalid sequence file %s: %w", g.name(), err)
+		}
+	case !errors.Is(err, os.ErrNotExist):
+		return nil, fmt.Errorf("failed to read sequences: %w", err)
+	}
+	return g, nil
+}
+
+func (g *SigningGuard) Sign(payload []byte) ([]byte, error) {
+	claim, err := algos.ParseSignedPayload(payload)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrSigningRefused, err)
+	}
+	if claim.UserID != g.userID {
+		return nil, fmt.Errorf("%w: %s payload of user %s", ErrSigningRefused, claim.Kind, claim.UserID)
+	}
+	if claim.Kind == algos.ClaimMessage {
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		if claim.Sequence <= g.sequences[claim.ChatID] {
+			return nil, fmt.Errorf("%w: message %d in chat %s was already signed", ErrSigningRefused, claim.Sequence, claim.ChatID)
+		}
+		g.sequences[claim.ChatID] = claim.Sequence
+		data, err := json.Marshal(g.sequences)
+		if err != nil {
+			return nil, err
+		}
+		if err := g.storage.Store(g.name(), data); err != nil {
+			return nil, fmt.Errorf("failed to save sequences: %w", err)
+		}
+	}
+	return g.identity.Sign(payload), nil
+}
+
+func (g *SigningGuard) name() string {
+	return g.userID + ".sequences"
+}
@@ -0,0 +1,204 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"testing"
+
+	"Kygram/algos"
+	"Kygram/proto/protopb"
+
+	"google.golang.org/grpc"
+)
+
+// identityDirectory отдаёт ключи личности пользователей вместо UserService
+type identityDirectory struct {
+	protopb.UserServiceClient
+
+	mu   sync.Mutex
+	keys map[string]string
+}
+
+func (d *identityDirectory) GetIdentityKey(ctx context.Context, req *protopb.GetIdentityKeyRequest, _ ...grpc.CallOption) (*protopb.GetIdentityKeyResponse, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	key, ok := d.keys[req.UserId]
+	if !ok {
+		return nil, errors.New("identity key not found")
+	}
+	return &protopb.GetIdentityKeyResponse{PublicKey: key}, nil
+}
+
+func (d *identityDirectory) set(userID string, key *algos.IdentityKey) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.keys[userID] = key.PublicKey()
+}
+
+// memoryStorage хранит ключи клиента в памяти
+type memoryStorage map[string][]byte
+
+func (s memoryStorage) Load(name string) ([]byte, error) {
+	data, ok := s[name]
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	return data, nil
+}
+
+func (s memoryStorage) Store(name string, data []byte) error {
+	s[name] = append([]byte(nil), data...)
+	return nil
+}
+
+func testIdentity(t *testing.T) *algos.IdentityKey {
+	t.Helper()
+	key, err := algos.GenerateIdentityKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func signedMessage(t *testing.T, signer *MessageSigner, text string) *protopb.Message {
+	t.Helper()
+	msg := &protopb.Message{ChatId: "chat", SenderId: "alice", MessageType: "text", EncryptedMessage: []byte(text)}
+	if err := signer.Sign(msg); err != nil {
+		t.Fatal(err)
+	}
+	return msg
+}
+
+func TestMessageVerifier(t *testing.T) {
+	alice := testIdentity(t)
+	directory := &identityDirectory{keys: make(map[string]string)}
+	directory.set("alice", alice)
+	users := &UserClient{client: directory}
+	signer := NewMessageSigner(KeySigner{alice}, 0)
+
+	first := signedMessage(t, signer, "first")
+	second := signedMessage(t, signer, "second")
+	if first.Sequence != 1 || second.Sequence != 2 {
+		t.Fatalf("sequence numbers %d, %d", first.Sequence, second.Sequence)
+	}
+
+	t.Run("valid", func(t *testing.T) {
+		verifier := users.NewMessageVerifier()
+		for _, msg := range []*protopb.Message{first, second} {
+			if err := verifier.Verify(MessageEnvelope(msg), msg.Signature); err != nil {
+				t.Fatalf("message %d: %v", msg.Sequence, err)
+			}
+		}
+	})
+
+	t.Run("tampered", func(t *testing.T) {
+		verifier := users.NewMessageVerifier()
+		tampered := MessageEnvelope(first)
+		tampered.Ciphertext = []byte("forged")
+		if err := verifier.Verify(tampered, first.Signature); !errors.Is(err, algos.ErrInvalidSignature) {
+			t.Fatalf("tampered ciphertext: %v", err)
+		}
+		tampered = MessageEnvelope(first)
+		tampered.ChatID = "other chat"
+		if err := verifier.Verify(tampered, first.Signature); !errors.Is(err, algos.ErrInvalidSignature) {
+			t.Fatalf("message moved to another chat: %v", err)
+		}
+		// отвергнутое сообщение не сдвигает номер
+		if err := verifier.Verify(MessageEnvelope(first), first.Signature); err != nil {
+			t.Fatalf("genuine message after a forgery: %v", err)
+		}
+	})
+
+	t.Run("wrong key", func(t *testing.T) {
+		verifier := users.NewMessageVerifier()
+		forged := signedMessage(t, NewMessageSigner(KeySigner{testIdentity(t)}, 0), "forged")
+		if err := verifier.Verify(MessageEnvelope(forged), forged.Signature); !errors.Is(err, algos.ErrInvalidSignature) {
+			t.Fatalf("message signed by another key: %v", err)
+		}
+		unknown := MessageEnvelope(first)
+		unknown.SenderID = "nobody"
+		if err := verifier.Verify(unknown, first.Signature); !errors.Is(err, ErrUnknownIdentity) {
+			t.Fatalf("sender without identity key: %v", err)
+		}
+	})
+
+	t.Run("replay", func(t *testing.T) {
+		verifier := users.NewMessageVerifier()
+		if err := verifier.Verify(MessageEnvelope(second), second.Signature); err != nil {
+			t.Fatal(err)
+		}
+		if err := verifier.Verify(MessageEnvelope(second), second.Signature); !errors.Is(err, ErrReplayedMessage) {
+			t.Fatalf("repeated message: %v", err)
+		}
+		if err := verifier.Verify(MessageEnvelope(first), first.Signature); !errors.Is(err, ErrReplayedMessage) {
+			t.Fatalf("older message after a newer one: %v", err)
+		}
+	})
+
+	t.Run("unsigned", func(t *testing.T) {
+		verifier := users.NewMessageVerifier()
+		if err := verifier.Verify(MessageEnvelope(first), nil); !errors.Is(err, ErrUnsigned) {
+			t.Fatalf("unsigned message: %v", err)
+		}
+	})
+
+	t.Run("key change", func(t *testing.T) {
+		verifier := users.NewMessageVerifier()
+		var changed string
+		verifier.OnKeyChange(func(senderID, publicKey string) { changed = senderID })
+		if err := verifier.Verify(MessageEnvelope(first), first.Signature); err != nil {
+			t.Fatal(err)
+		}
+
+		replacement := testIdentity(t)
+		directory.set("alice", replacement)
+		defer directory.set("alice", alice)
+		msg := signedMessage(t, NewMessageSigner(KeySigner{replacement}, second.Sequence), "after rotation")
+		if err := verifier.Verify(MessageEnvelope(msg), msg.Signature); err != nil {
+			t.Fatalf("message under the new key: %v", err)
+		}
+		if changed != "alice" {
+			t.Fatal("key change was not reported")
+		}
+	})
+}
+
+func TestSigningGuard(t *testing.T) {
+	alice := testIdentity(t)
+	storage := memoryStorage{}
+	guard, err := NewSigningGuard(storage, "alice", alice)
+	if err != nil {
+		t.Fatal(err)
+	}
+	envelope := algos.SignedEnvelope{ChatID: "chat", SenderID: "alice", Sequence: 5, Ciphertext: []byte("hi")}
+
+	signature, err := guard.Sign(envelope.Payload())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := algos.VerifySignature(alice.PublicKey(), envelope.Payload(), signature); err != nil {
+		t.Fatal(err)
+	}
+
+	// повтор номера не подписывается, в том числе после перезапуска
+	restarted, err := NewSigningGuard(storage, "alice", alice)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, g := range []*SigningGuard{guard, restarted} {
+		if _, err := g.Sign(envelope.Payload()); !errors.Is(err, ErrSigningRefused) {
+			t.Fatalf("sequence signed twice: %v", err)
+		}
+	}
+
+	foreign := envelope
+	foreign.SenderID, foreign.Sequence = "mallory", 6
+	if _, err := guard.Sign(foreign.Payload()); !errors.Is(err, ErrSigningRefused) {
+		t.Fatalf("message of another user signed: %v", err)
+	}
+	if _, err := guard.Sign(algos.IdentityReplacementPayload("alice", alice.PublicKey(), "server key")); !errors.Is(err, ErrSigningRefused) {
+		t.Fatalf("identity key replacement signed on request: %v", err)
+	}
+}
@@ -0,0 +1,37 @@
+package client
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// KeyStorage хранит закрытые ключи клиента: ключ личности и предварительные ключи.
+// Load возвращает ошибку, удовлетворяющую errors.Is(err, os.ErrNotExist), если записи нет.
+// Реализация должна быть сравнимой: по ней и пользователю кэшируются хранилища предварительных ключей.
+type KeyStorage interface {
+	Load(name string) ([]byte, error)
+	Store(name string, data []byte) error
+}
+
+// DirStorage хранит ключи файлами в каталоге с правами 0600
+type DirStorage struct {
+	Dir string
+}
+
+func (s DirStorage) Load(name string) ([]byte, error) {
+	return os.ReadFile(filepath.Join(s.Dir, name))
+}
+
+// Store записывает файл через временный, чтобы оборванная запись не испортила ключи
+func (s DirStorage) Store(name string, data []byte) error {
+	if err := os.MkdirAll(s.Dir, 0700); err != nil {
+		return fmt.Errorf("failed to create key directory: %w", err)
+	}
+	path := filepath.Join(s.Dir, name)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,253 @@
+//go:build js && wasm
+
+package main
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"syscall/js"
+	"time"
+
+	"Kygram/client"
+	"Kygram/proto/protopb"
+
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// wasm — клиент чатов со сквозным шифрованием для браузера: ключи создаются и хранятся
+// в localStorage, сообщения шифруются здесь же, сервер доступен через мост /rpc/. В обычных
+// чатах здесь же хранится ключ личности: сервер просит подписи у браузера.
+// Все методы возвращают Promise: сетевые вызовы в WASM нельзя делать из обработчика JavaScript.
+func main() {
+	js.Global().Set("kygram", js.ValueOf(map[string]any{
+		"openSession":  js.FuncOf(openSession),
+		"openIdentity": js.FuncOf(openIdentity),
+	}))
+	select {}
+}
+
+// localStorage хранит ключи клиента в localStorage браузера под префиксом
+type localStorage struct {
+	prefix string
+}
+
+func (s localStorage) Load(name string) ([]byte, error) {
+	value := js.Global().Get("localStorage").Call("getItem", s.prefix+name)
+	if value.IsNull() {
+		return nil, os.ErrNotExist
+	}
+	return []byte(value.String()), nil
+}
+
+func (s localStorage) Store(name string, data []byte) (err error) {
+	// переполненное хранилище бросает исключение, а syscall/js превращает его в panic
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("failed to store %s: %v", name, r)
+		}
+	}()
+	js.Global().Get("localStorage").Call("setItem", s.prefix+name, string(data))
+	return nil
+}
+
+// openSession({chatId, userId, deviceId, baseUrl, token}) открывает client.ChatSession
+func openSession(this js.Value, args []js.Value) any {
+	if len(args) != 1 {
+		return rejected(errors.New("openSession expects an options object"))
+	}
+	opts := args[0]
+	cfg := client.SessionConfig{
+		Conn:     client.NewHTTPConn(opts.Get("baseUrl").String(), opts.Get("token").String()),
+		Storage:  localStorage{prefix: "kygram/"},
+		Token:    opts.Get("token").String(),
+		ChatID:   opts.Get("chatId").String(),
+		UserID:   opts.Get("userId").String(),
+		DeviceID: opts.Get("deviceId").String(),
+	}
+	return promise(func() (any, error) {
+		session, err := client.OpenChatSession(cfg)
+		if err != nil {
+			return nil, err
+		}
+		return sessionObject(session), nil
+	})
+}
+
+// openIdentity({userId, baseUrl, token}) загружает или создаёт ключ личности, регистрирует его
+// и возвращает {publicKey, sign(payload)}: sign подписывает данные, присланные сервером обычного
+// чата (base64), если это предварительный ключ или сообщение самого пользователя
+func openIdentity(this js.Value, args []js.Value) any {
+	if len(args) != 1 {
+		return rejected(errors.New("openIdentity expects an options object"))
+	}
+	opts := args[0]
+	storage := localStorage{prefix: "kygram/"}
+	userID, token := opts.Get("userId").String(), opts.Get("token").String()
+	users := client.NewUserClientWithConn(client.NewHTTPConn(opts.Get("baseUrl").String(), token))
+	return promise(func() (any, error) {
+		identity, err := client.LoadOrCreateIdentityIn(storage, userID)
+		if err != nil {
+			return nil, err
+		}
+		if err := users.RegisterIdentityKey(token, userID, identity); err != nil {
+			return nil, fmt.Errorf("failed to register identity key: %w", err)
+		}
+		guard, err := client.NewSigningGuard(storage, userID, identity)
+		if err != nil {
+			return nil, err
+		}
+		return js.ValueOf(map[string]any{
+			"publicKey": identity.PublicKey(),
+			"sign": js.FuncOf(func(this js.Value, args []js.Value) any {
+				payload, err := base64.StdEncoding.DecodeString(args[0].String())
+				if err != nil {
+					return rejected(fmt.Errorf("invalid payload: %w", err))
+				}
+				return promise(func() (any, error) {
+					signature, err := guard.Sign(payload)
+					if err != nil {
+						return nil, err
+					}
+					return base64.StdEncoding.EncodeToString(signature), nil
+				})
+			}),
+		}), nil
+	})
+}
+
+func sessionObject(session *client.ChatSession) js.Value {
+	return js.ValueOf(map[string]any{
+		"keyId": session.KeyID(),
+		// encrypt(text) — конверт текстового сообщения в JSON
+		"encrypt": js.FuncOf(func(this js.Value, args []js.Value) any {
+			text := args[0].String()
+			return promise(func() (any, error) {
+				msg, err := session.EncryptText(text)
+				if err != nil {
+					return nil, err
+				}
+				return marshalEnvelope(msg)
+			})
+		}),
+		// encryptFileChunk(fileName, chunkIndex, totalChunks, Uint8Array) — конверт части файла
+		"encryptFileChunk": js.FuncOf(func(this js.Value, args []js.Value) any {
+			fileName, chunkIndex, totalChunks := args[0].String(), args[1].Int(), args[2].Int()
+			data := make([]byte, args[3].Get("length").Int())
+			js.CopyBytesToGo(data, args[3])
+			return promise(func() (any, error) {
+				msg, err := session.EncryptFileChunk(fileName, chunkIndex, totalChunks, data)
+				if err != nil {
+					return nil, err
+				}
+				return marshalEnvelope(msg)
+			})
+		}),
+		// decrypt(envelope) — сообщение в том же виде, в каком его присылает сервер, или null
+		// для непоследней части файла
+		"decrypt": js.FuncOf(func(this js.Value, args []js.Value) any {
+			envelope := args[0].String()
+			return promise(func() (any, error) {
+				var msg protopb.Message
+				if err := protojson.Unmarshal([]byte(envelope), &msg); err != nil {
+					return nil, fmt.Errorf("invalid envelope: %w", err)
+				}
+				decrypted := session.Decrypt(&msg)
+				if decrypted == nil {
+					return nil, nil
+				}
+				return messageObject(decrypted, "message"), nil
+			})
+		}),
+		// decryptHistory() — история чата в том же виде, что и ответ /messages
+		"decryptHistory": js.FuncOf(func(this js.Value, args []js.Value) any {
+			return promise(func() (any, error) {
+				history, err := session.DecryptHistory()
+				if err != nil {
+					return nil, err
+				}
+				messages := make([]any, 0, len(history))
+				for _, decrypted := range history {
+					messages = append(messages, messageObject(decrypted, "text"))
+				}
+				return messages, nil
+			})
+		}),
+		// onKeyChange(callback) — callback(senderId), когда ключ личности отправителя сменился
+		"onKeyChange": js.FuncOf(func(this js.Value, args []js.Value) any {
+			callback := args[0]
+			session.OnKeyChange(func(senderID, publicKey string) {
+				callback.Invoke(senderID)
+			})
+			return nil
+		}),
+		"close": js.FuncOf(func(this js.Value, args []js.Value) any {
+			return promise(func() (any, error) {
+				return nil, session.Close()
+			})
+		}),
+	})
+}
+
+func marshalEnvelope(msg *protopb.Message) (any, error) {
+	data, err := protojson.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// messageObject повторяет ответ сервера для обычных чатов; текст истории лежит в поле "text",
+// текст из потока — в "message"
+func messageObject(m *client.DecryptedMessage, textField string) map[string]any {
+	createdAt := m.CreatedAt
+	if createdAt == "" {
+		createdAt = time.Now().Format(time.RFC3339)
+	}
+	obj := map[string]any{
+		"sender_id":    m.SenderID,
+		"sender_name":  m.SenderName,
+		"created_at":   createdAt,
+		"message_type": m.MessageType,
+		"file_name":    m.FileName,
+		"verified":     m.VerifyErr == nil,
+	}
+	if m.VerifyErr != nil {
+		obj["verification_error"] = m.VerifyErr.Error()
+	}
+	if m.Err != nil {
+		obj["error"] = "message could not be decrypted"
+		return obj
+	}
+	if m.MessageType == "file" {
+		obj["message"] = base64.StdEncoding.EncodeToString(m.Data)
+		obj["is_base64"] = true
+	} else {
+		obj[textField] = string(m.Data)
+	}
+	return obj
+}
+
+// promise выполняет f в горутине и возвращает Promise с её результатом
+func promise(f func() (any, error)) js.Value {
+	var executor js.Func
+	executor = js.FuncOf(func(this js.Value, args []js.Value) any {
+		resolve, reject := args[0], args[1]
+		go func() {
+			defer executor.Release()
+			result, err := f()
+			if err != nil {
+				reject.Invoke(js.Global().Get("Error").New(err.Error()))
+				return
+			}
+			resolve.Invoke(result)
+		}()
+		return nil
+	})
+	return js.Global().Get("Promise").New(executor)
+}
+
+func rejected(err error) js.Value {
+	return js.Global().Get("Promise").Call("reject", js.Global().Get("Error").New(err.Error()))
+}
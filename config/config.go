@@ -7,6 +7,7 @@ import (
 	"io/ioutil"
 	"log"
 	"os"
+	"strconv"
 	"time"
 
 	"github.com/go-redis/redis/v8"
@@ -24,6 +25,35 @@ func LoadEnv() {
 	}
 }
 
+// GetEnv возвращает значение переменной окружения или fallback, если она не задана
+func GetEnv(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// GetEnvInt возвращает целое значение переменной окружения или fallback, если она не задана
+func GetEnvInt(key string, fallback int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("Некорректное значение %s=%q, используется %d", key, value, fallback)
+		return fallback
+	}
+	return n
+}
+
+// JWTKey — ключ подписи токенов: их выдаёт HTTP-сервер, а проверяют и он, и сервисы gRPC,
+// поэтому ключ у них общий
+func JWTKey() []byte {
+	return []byte(GetEnv("JWT_SECRET", "your-secret-key"))
+}
+
 func GetDB() *sql.DB {
 	LoadEnv()
 	dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
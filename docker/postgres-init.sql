@@ -7,16 +7,68 @@ CREATE TABLE IF NOT EXISTS users (
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
 
+-- долговременные ключи подписи пользователей (Ed25519, base64)
+CREATE TABLE IF NOT EXISTS identity_keys (
+    user_id UUID PRIMARY KEY REFERENCES users(user_id) ON DELETE CASCADE,
+    public_key TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- ключи личности собеседников, код безопасности которых пользователь сверил
+CREATE TABLE IF NOT EXISTS verified_keys (
+    user_id UUID REFERENCES users(user_id) ON DELETE CASCADE,
+    peer_id UUID REFERENCES users(user_id) ON DELETE CASCADE,
+    public_key TEXT NOT NULL,
+    verified_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, peer_id)
+);
+
+-- предварительные ключи X25519 для согласования с пользователем не в сети (X3DH):
+-- текущий подписанный ключ и запас одноразовых, которые удаляются при выдаче
+CREATE TABLE IF NOT EXISTS signed_prekeys (
+    user_id UUID PRIMARY KEY REFERENCES users(user_id) ON DELETE CASCADE,
+    key_id INTEGER NOT NULL,
+    public_key TEXT NOT NULL,
+    signature BYTEA NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS one_time_prekeys (
+    user_id UUID REFERENCES users(user_id) ON DELETE CASCADE,
+    key_id INTEGER NOT NULL,
+    public_key TEXT NOT NULL,
+    PRIMARY KEY (user_id, key_id)
+);
+
 CREATE TABLE IF NOT EXISTS chats (
     chat_id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
     name VARCHAR(255),
     algorithm VARCHAR(50) NOT NULL,
     mode VARCHAR(50) NOT NULL,
     padding VARCHAR(50) NOT NULL,
-    prime TEXT NOT NULL,
+    prime TEXT NOT NULL, -- пусто для X25519
+    key_agreement VARCHAR(20) NOT NULL DEFAULT 'dh',
+    dh_group VARCHAR(50) NOT NULL DEFAULT 'ffdhe2048',
+    generator TEXT NOT NULL DEFAULT '2',
+    kdf_salt TEXT NOT NULL DEFAULT '',
+    e2e BOOLEAN NOT NULL DEFAULT FALSE, -- сквозное шифрование: сервер только хранит и пересылает конверты
+    key_epoch INT NOT NULL DEFAULT 0, -- 0: чат создан до эпох, сообщения зашифрованы старым фиксированным ключом
+    epoch_started_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    epoch_messages INT NOT NULL DEFAULT 0,
+    first_secret_epoch INT NOT NULL DEFAULT 1, -- эпохи до неё выводили ключ из старого фиксированного
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
 
+-- случайные секреты эпох обычных чатов; ключ эпохи выводится из секрета по HKDF, а клиентам
+-- секрет выдаёт GetEpochKey, обернув его для подписанного ключа устройства
+CREATE TABLE IF NOT EXISTS chat_epoch_keys (
+    chat_id UUID REFERENCES chats(chat_id) ON DELETE CASCADE,
+    epoch INT NOT NULL,
+    secret BYTEA NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (chat_id, epoch)
+);
+
 CREATE TABLE IF NOT EXISTS chat_participants (
     chat_id UUID REFERENCES chats(chat_id) ON DELETE CASCADE,
     user_id UUID REFERENCES users(user_id) ON DELETE CASCADE,
@@ -24,20 +76,129 @@ CREATE TABLE IF NOT EXISTS chat_participants (
     PRIMARY KEY (chat_id, user_id)
 );
 
+-- ключи подключений: у каждого устройства участника в чате один текущий ключ,
+-- заменённые и отозванные остаются в истории с отметкой revoked_at
 CREATE TABLE IF NOT EXISTS session_keys (
+    chat_id UUID REFERENCES chats(chat_id) ON DELETE CASCADE,
     user_id UUID REFERENCES users(user_id) ON DELETE CASCADE,
-    public_key TEXT NOT NULL,
-    PRIMARY KEY (user_id)
+    device_id VARCHAR(64) NOT NULL,
+    key_id UUID NOT NULL,
+    public_key TEXT NOT NULL, -- десятичное число для DH, base64 для X25519
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    revoked_at TIMESTAMP,
+    signature BYTEA, -- подпись ключом личности владельца
+    PRIMARY KEY (chat_id, user_id, device_id, key_id)
 );
 
 CREATE TABLE IF NOT EXISTS messages (
     message_id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
     chat_id UUID REFERENCES chats(chat_id) ON DELETE CASCADE,
     sender_id UUID REFERENCES users(user_id) ON DELETE CASCADE,
-    encrypted_message BYTEA NOT NULL,
+    encrypted_message BYTEA NOT NULL, -- конверт шифртекста вместе с IV
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     message_type VARCHAR(50) NOT NULL,
     file_name TEXT, 
     chunk_index INT, 
-    total_chunks INT
-);
\ No newline at end of file
+    total_chunks INT,
+    sender_key_id TEXT NOT NULL DEFAULT '', -- пусто: сообщение зашифровано стандартным ключом
+    ratchet_index INT NOT NULL DEFAULT 0,
+    ratchet_key TEXT NOT NULL DEFAULT '',
+    key_epoch INT NOT NULL DEFAULT 0,
+    signature BYTEA, -- подпись ключом личности отправителя; NULL — сообщение не подписано
+    sequence BIGINT NOT NULL DEFAULT 0,
+    signed_at BIGINT NOT NULL DEFAULT 0
+);
+
+-- ключи отправителей групповых чатов, обёрнутые для каждого получателя попарным ключом
+CREATE TABLE IF NOT EXISTS sender_keys (
+    chat_id UUID REFERENCES chats(chat_id) ON DELETE CASCADE,
+    sender_id UUID REFERENCES users(user_id) ON DELETE CASCADE,
+    key_id UUID NOT NULL,
+    recipient_id UUID REFERENCES users(user_id) ON DELETE CASCADE,
+    recipient_device_id VARCHAR(64) NOT NULL DEFAULT '', -- пусто, если обёрнут по предварительным ключам; 'history' у копии для истории
+    sender_public_key TEXT NOT NULL,
+    recipient_public_key TEXT NOT NULL,
+    wrapped_key BYTEA NOT NULL,
+    signed_prekey_id INTEGER NOT NULL DEFAULT 0,
+    one_time_prekey_id INTEGER NOT NULL DEFAULT 0,
+    sender_key_signature BYTEA, -- подпись sender_public_key и history_public_key ключом личности отправителя
+    history_public_key TEXT NOT NULL DEFAULT '', -- ключ X25519 копий цепочки для истории
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (chat_id, sender_id, key_id, recipient_id, recipient_device_id)
+);
+
+-- Миграции баз, созданных прежними версиями схемы: CREATE TABLE IF NOT EXISTS не трогает
+-- существующие таблицы, поэтому новые столбцы и первичные ключи добавляются здесь.
+-- Старые чаты со случайным простым переходят на стандартную группу ffdhe2048, их соль
+-- пустая, а эпоха 0 — как и у всех старых сообщений.
+ALTER TABLE chats
+    ADD COLUMN IF NOT EXISTS key_agreement VARCHAR(20) NOT NULL DEFAULT 'dh',
+    ADD COLUMN IF NOT EXISTS dh_group VARCHAR(50) NOT NULL DEFAULT 'ffdhe2048',
+    ADD COLUMN IF NOT EXISTS generator TEXT NOT NULL DEFAULT '2',
+    ADD COLUMN IF NOT EXISTS kdf_salt TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS e2e BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN IF NOT EXISTS key_epoch INT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS epoch_started_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS epoch_messages INT NOT NULL DEFAULT 0;
+
+ALTER TABLE messages
+    ADD COLUMN IF NOT EXISTS sender_key_id TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS ratchet_index INT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS ratchet_key TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS key_epoch INT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS signature BYTEA,
+    ADD COLUMN IF NOT EXISTS sequence BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS signed_at BIGINT NOT NULL DEFAULT 0;
+
+ALTER TABLE session_keys
+    ADD COLUMN IF NOT EXISTS chat_id UUID REFERENCES chats(chat_id) ON DELETE CASCADE,
+    ADD COLUMN IF NOT EXISTS device_id VARCHAR(64),
+    ADD COLUMN IF NOT EXISTS key_id UUID,
+    ADD COLUMN IF NOT EXISTS created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS revoked_at TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS signature BYTEA;
+
+ALTER TABLE sender_keys
+    ADD COLUMN IF NOT EXISTS recipient_device_id VARCHAR(64) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS signed_prekey_id INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS one_time_prekey_id INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS sender_key_signature BYTEA,
+    ADD COLUMN IF NOT EXISTS history_public_key TEXT NOT NULL DEFAULT '';
+
+DO $$
+BEGIN
+    -- старые ключи подключений (по одному на пользователя) не привязаны ни к чату, ни к устройству:
+    -- они удаляются, а клиенты публикуют новые при следующем подключении
+    IF NOT EXISTS (SELECT 1 FROM information_schema.key_column_usage
+                   WHERE table_name = 'session_keys' AND constraint_name = 'session_keys_pkey'
+                     AND column_name = 'key_id') THEN
+        DELETE FROM session_keys WHERE chat_id IS NULL OR device_id IS NULL OR key_id IS NULL;
+        ALTER TABLE session_keys DROP CONSTRAINT IF EXISTS session_keys_pkey;
+        ALTER TABLE session_keys DROP COLUMN IF EXISTS key_agreement;
+        ALTER TABLE session_keys ADD PRIMARY KEY (chat_id, user_id, device_id, key_id);
+    END IF;
+
+    IF NOT EXISTS (SELECT 1 FROM information_schema.key_column_usage
+                   WHERE table_name = 'sender_keys' AND constraint_name = 'sender_keys_pkey'
+                     AND column_name = 'recipient_device_id') THEN
+        ALTER TABLE sender_keys DROP CONSTRAINT IF EXISTS sender_keys_pkey;
+        ALTER TABLE sender_keys ADD PRIMARY KEY (chat_id, sender_id, key_id, recipient_id, recipient_device_id);
+    END IF;
+
+    -- ключи уже начатых эпох выведены из старого фиксированного ключа, и сообщения этих эпох
+    -- читаются по-прежнему; текущая такая эпоха сменяется на эпоху со случайным секретом
+    -- при первом же сообщении
+    IF NOT EXISTS (SELECT 1 FROM information_schema.columns
+                   WHERE table_name = 'chats' AND column_name = 'first_secret_epoch') THEN
+        ALTER TABLE chats ADD COLUMN first_secret_epoch INT NOT NULL DEFAULT 1;
+        UPDATE chats SET first_secret_epoch = key_epoch + 1;
+    END IF;
+END $$;
+
+-- у каждого устройства в чате не больше одного текущего ключа; индекс создаётся после
+-- миграций, потому что в старой таблице session_keys нет его столбцов
+CREATE UNIQUE INDEX IF NOT EXISTS session_keys_current ON session_keys (chat_id, user_id, device_id)
+    WHERE revoked_at IS NULL;
+
+-- GCM не использует набивку: чаты, созданные до этого ограничения, переводятся на None
+UPDATE chats SET padding = 'None' WHERE mode = 'GCM' AND padding <> 'None';
@@ -7,13 +7,27 @@ import (
 )
 
 type Chat struct {
-	ChatID    uuid.UUID `json:"chat_id"`
-	Name      string    `json:"name"`
-	Algorithm string    `json:"algorithm"`
-	Mode      string    `json:"mode"`
-	Padding   string    `json:"padding"`
-	Prime     string    `json:"prime"`
-	CreatedAt time.Time `json:"created_at"`
+	ChatID       uuid.UUID `json:"chat_id"`
+	Name         string    `json:"name"`
+	Algorithm    string    `json:"algorithm"`
+	Mode         string    `json:"mode"`
+	Padding      string    `json:"padding"`
+	Prime        string    `json:"prime"`
+	KeyAgreement string    `json:"key_agreement"`
+	DHGroup      string    `json:"dh_group"`
+	Generator    string    `json:"generator"`
+	KDFSalt      string    `json:"kdf_salt"`
+	// E2E — сообщения шифруют и расшифровывают только клиенты, сервер их не расшифровывает
+	E2E bool `json:"e2e"`
+	// KeyEpoch — номер текущей эпохи ключей; эпоха сменяется при изменении состава чата,
+	// по времени и по числу сообщений
+	KeyEpoch       int       `json:"key_epoch"`
+	EpochStartedAt time.Time `json:"epoch_started_at"`
+	EpochMessages  int       `json:"epoch_messages"`
+	// FirstSecretEpoch — первая эпоха со случайным секретом; ключи более ранних эпох выведены
+	// из старого фиксированного ключа
+	FirstSecretEpoch int       `json:"first_secret_epoch"`
+	CreatedAt        time.Time `json:"created_at"`
 }
 
 type Participant struct {
@@ -32,4 +46,51 @@ type Message struct {
 	FileName         string    `json:"file_name"`
 	ChunkIndex       int       `json:"chunk_index"`
 	TotalChunks      int       `json:"total_chunks"`
+	SenderKeyID      string    `json:"sender_key_id"`
+	RatchetIndex     uint32    `json:"ratchet_index"`
+	RatchetKey       string    `json:"ratchet_key"`
+	KeyEpoch         int       `json:"key_epoch"`
+	Signature        []byte    `json:"signature"`
+	Sequence         uint64    `json:"sequence"`
+	SignedAt         int64     `json:"signed_at"`
+}
+
+// SenderKey — ключ отправителя, обёрнутый для одного получателя
+type SenderKey struct {
+	ChatID             uuid.UUID
+	SenderID           uuid.UUID
+	KeyID              uuid.UUID
+	RecipientID        uuid.UUID
+	RecipientDeviceID  string
+	SenderPublicKey    string
+	RecipientPublicKey string
+	WrappedKey         []byte
+	// ненулевые, если ключ обёрнут по предварительным ключам получателя
+	SignedPrekeyID  uint32
+	OneTimePrekeyID uint32
+	// подпись SenderPublicKey и HistoryPublicKey ключом личности отправителя
+	SenderKeySignature []byte
+	// ключ X25519, по которому копии цепочки для истории обёрнуты подписанными
+	// предварительными ключами получателей
+	HistoryPublicKey string
+}
+
+// SessionKey — открытый ключ подключения одного устройства участника чата. RevokedAt
+// заполнен у заменённых и отозванных ключей.
+type SessionKey struct {
+	ChatID    uuid.UUID
+	UserID    uuid.UUID
+	DeviceID  string
+	KeyID     uuid.UUID
+	PublicKey string
+	CreatedAt time.Time
+	RevokedAt *time.Time
+	Signature []byte // подпись ключом личности владельца
+}
+
+// Prekey — открытый предварительный ключ пользователя; Signature есть только у подписанного
+type Prekey struct {
+	KeyID     uint32
+	PublicKey string
+	Signature []byte
 }
@@ -19,11 +19,13 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	UserService_RegisterUser_FullMethodName = "/auth.UserService/RegisterUser"
-	UserService_Login_FullMethodName        = "/auth.UserService/Login"
-	UserService_Logout_FullMethodName       = "/auth.UserService/Logout"
-	UserService_GetUser_FullMethodName      = "/auth.UserService/GetUser"
-	UserService_ListUsers_FullMethodName    = "/auth.UserService/ListUsers"
+	UserService_RegisterUser_FullMethodName        = "/auth.UserService/RegisterUser"
+	UserService_Login_FullMethodName               = "/auth.UserService/Login"
+	UserService_Logout_FullMethodName              = "/auth.UserService/Logout"
+	UserService_GetUser_FullMethodName             = "/auth.UserService/GetUser"
+	UserService_ListUsers_FullMethodName           = "/auth.UserService/ListUsers"
+	UserService_RegisterIdentityKey_FullMethodName = "/auth.UserService/RegisterIdentityKey"
+	UserService_GetIdentityKey_FullMethodName      = "/auth.UserService/GetIdentityKey"
 )
 
 // UserServiceClient is the client API for UserService service.
@@ -35,6 +37,8 @@ type UserServiceClient interface {
 	Logout(ctx context.Context, in *LogoutRequest, opts ...grpc.CallOption) (*LogoutResponse, error)
 	GetUser(ctx context.Context, in *GetUserRequest, opts ...grpc.CallOption) (*GetUserResponse, error)
 	ListUsers(ctx context.Context, in *ListUsersRequest, opts ...grpc.CallOption) (*ListUsersResponse, error)
+	RegisterIdentityKey(ctx context.Context, in *RegisterIdentityKeyRequest, opts ...grpc.CallOption) (*RegisterIdentityKeyResponse, error)
+	GetIdentityKey(ctx context.Context, in *GetIdentityKeyRequest, opts ...grpc.CallOption) (*GetIdentityKeyResponse, error)
 }
 
 type userServiceClient struct {
@@ -95,6 +99,26 @@ func (c *userServiceClient) ListUsers(ctx context.Context, in *ListUsersRequest,
 	return out, nil
 }
 
+func (c *userServiceClient) RegisterIdentityKey(ctx context.Context, in *RegisterIdentityKeyRequest, opts ...grpc.CallOption) (*RegisterIdentityKeyResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RegisterIdentityKeyResponse)
+	err := c.cc.Invoke(ctx, UserService_RegisterIdentityKey_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userServiceClient) GetIdentityKey(ctx context.Context, in *GetIdentityKeyRequest, opts ...grpc.CallOption) (*GetIdentityKeyResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetIdentityKeyResponse)
+	err := c.cc.Invoke(ctx, UserService_GetIdentityKey_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // UserServiceServer is the server API for UserService service.
 // All implementations must embed UnimplementedUserServiceServer
 // for forward compatibility.
@@ -104,6 +128,8 @@ type UserServiceServer interface {
 	Logout(context.Context, *LogoutRequest) (*LogoutResponse, error)
 	GetUser(context.Context, *GetUserRequest) (*GetUserResponse, error)
 	ListUsers(context.Context, *ListUsersRequest) (*ListUsersResponse, error)
+	RegisterIdentityKey(context.Context, *RegisterIdentityKeyRequest) (*RegisterIdentityKeyResponse, error)
+	GetIdentityKey(context.Context, *GetIdentityKeyRequest) (*GetIdentityKeyResponse, error)
 	mustEmbedUnimplementedUserServiceServer()
 }
 
@@ -129,6 +155,12 @@ func (UnimplementedUserServiceServer) GetUser(context.Context, *GetUserRequest)
 func (UnimplementedUserServiceServer) ListUsers(context.Context, *ListUsersRequest) (*ListUsersResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ListUsers not implemented")
 }
+func (UnimplementedUserServiceServer) RegisterIdentityKey(context.Context, *RegisterIdentityKeyRequest) (*RegisterIdentityKeyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RegisterIdentityKey not implemented")
+}
+func (UnimplementedUserServiceServer) GetIdentityKey(context.Context, *GetIdentityKeyRequest) (*GetIdentityKeyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetIdentityKey not implemented")
+}
 func (UnimplementedUserServiceServer) mustEmbedUnimplementedUserServiceServer() {}
 func (UnimplementedUserServiceServer) testEmbeddedByValue()                     {}
 
@@ -240,6 +272,42 @@ func _UserService_ListUsers_Handler(srv interface{}, ctx context.Context, dec fu
 	return interceptor(ctx, in, info, handler)
 }
 
+func _UserService_RegisterIdentityKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RegisterIdentityKeyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).RegisterIdentityKey(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserService_RegisterIdentityKey_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).RegisterIdentityKey(ctx, req.(*RegisterIdentityKeyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserService_GetIdentityKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetIdentityKeyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).GetIdentityKey(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserService_GetIdentityKey_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).GetIdentityKey(ctx, req.(*GetIdentityKeyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // UserService_ServiceDesc is the grpc.ServiceDesc for UserService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -267,6 +335,14 @@ var UserService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "ListUsers",
 			Handler:    _UserService_ListUsers_Handler,
 		},
+		{
+			MethodName: "RegisterIdentityKey",
+			Handler:    _UserService_RegisterIdentityKey_Handler,
+		},
+		{
+			MethodName: "GetIdentityKey",
+			Handler:    _UserService_GetIdentityKey_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "auth.proto",
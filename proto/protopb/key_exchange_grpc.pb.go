@@ -19,8 +19,19 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	KeyExchangeService_SendPublicKey_FullMethodName = "/keyexchange.KeyExchangeService/SendPublicKey"
-	KeyExchangeService_ExchangeKeys_FullMethodName  = "/keyexchange.KeyExchangeService/ExchangeKeys"
+	KeyExchangeService_SendPublicKey_FullMethodName       = "/keyexchange.KeyExchangeService/SendPublicKey"
+	KeyExchangeService_ExchangeKeys_FullMethodName        = "/keyexchange.KeyExchangeService/ExchangeKeys"
+	KeyExchangeService_DistributeSenderKey_FullMethodName = "/keyexchange.KeyExchangeService/DistributeSenderKey"
+	KeyExchangeService_GetSenderKey_FullMethodName        = "/keyexchange.KeyExchangeService/GetSenderKey"
+	KeyExchangeService_GetSafetyNumber_FullMethodName     = "/keyexchange.KeyExchangeService/GetSafetyNumber"
+	KeyExchangeService_SetPeerVerified_FullMethodName     = "/keyexchange.KeyExchangeService/SetPeerVerified"
+	KeyExchangeService_GetPeerVerification_FullMethodName = "/keyexchange.KeyExchangeService/GetPeerVerification"
+	KeyExchangeService_UploadPrekeys_FullMethodName       = "/keyexchange.KeyExchangeService/UploadPrekeys"
+	KeyExchangeService_GetPrekeyStatus_FullMethodName     = "/keyexchange.KeyExchangeService/GetPrekeyStatus"
+	KeyExchangeService_FetchPrekeyBundle_FullMethodName   = "/keyexchange.KeyExchangeService/FetchPrekeyBundle"
+	KeyExchangeService_RevokePublicKeys_FullMethodName    = "/keyexchange.KeyExchangeService/RevokePublicKeys"
+	KeyExchangeService_GetPublicKeyHistory_FullMethodName = "/keyexchange.KeyExchangeService/GetPublicKeyHistory"
+	KeyExchangeService_GetEpochKey_FullMethodName         = "/keyexchange.KeyExchangeService/GetEpochKey"
 )
 
 // KeyExchangeServiceClient is the client API for KeyExchangeService service.
@@ -29,6 +40,17 @@ const (
 type KeyExchangeServiceClient interface {
 	SendPublicKey(ctx context.Context, in *SendPublicKeyRequest, opts ...grpc.CallOption) (*SendPublicKeyResponse, error)
 	ExchangeKeys(ctx context.Context, in *KeyExchangeRequest, opts ...grpc.CallOption) (*KeyExchangeResponse, error)
+	DistributeSenderKey(ctx context.Context, in *DistributeSenderKeyRequest, opts ...grpc.CallOption) (*DistributeSenderKeyResponse, error)
+	GetSenderKey(ctx context.Context, in *GetSenderKeyRequest, opts ...grpc.CallOption) (*GetSenderKeyResponse, error)
+	GetSafetyNumber(ctx context.Context, in *SafetyNumberRequest, opts ...grpc.CallOption) (*SafetyNumberResponse, error)
+	SetPeerVerified(ctx context.Context, in *SetPeerVerifiedRequest, opts ...grpc.CallOption) (*SetPeerVerifiedResponse, error)
+	GetPeerVerification(ctx context.Context, in *PeerVerificationRequest, opts ...grpc.CallOption) (*PeerVerificationResponse, error)
+	UploadPrekeys(ctx context.Context, in *UploadPrekeysRequest, opts ...grpc.CallOption) (*UploadPrekeysResponse, error)
+	GetPrekeyStatus(ctx context.Context, in *PrekeyStatusRequest, opts ...grpc.CallOption) (*PrekeyStatusResponse, error)
+	FetchPrekeyBundle(ctx context.Context, in *FetchPrekeyBundleRequest, opts ...grpc.CallOption) (*PrekeyBundle, error)
+	RevokePublicKeys(ctx context.Context, in *RevokePublicKeysRequest, opts ...grpc.CallOption) (*RevokePublicKeysResponse, error)
+	GetPublicKeyHistory(ctx context.Context, in *PublicKeyHistoryRequest, opts ...grpc.CallOption) (*PublicKeyHistoryResponse, error)
+	GetEpochKey(ctx context.Context, in *GetEpochKeyRequest, opts ...grpc.CallOption) (*GetEpochKeyResponse, error)
 }
 
 type keyExchangeServiceClient struct {
@@ -59,12 +81,133 @@ func (c *keyExchangeServiceClient) ExchangeKeys(ctx context.Context, in *KeyExch
 	return out, nil
 }
 
+func (c *keyExchangeServiceClient) DistributeSenderKey(ctx context.Context, in *DistributeSenderKeyRequest, opts ...grpc.CallOption) (*DistributeSenderKeyResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DistributeSenderKeyResponse)
+	err := c.cc.Invoke(ctx, KeyExchangeService_DistributeSenderKey_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keyExchangeServiceClient) GetSenderKey(ctx context.Context, in *GetSenderKeyRequest, opts ...grpc.CallOption) (*GetSenderKeyResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetSenderKeyResponse)
+	err := c.cc.Invoke(ctx, KeyExchangeService_GetSenderKey_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keyExchangeServiceClient) GetSafetyNumber(ctx context.Context, in *SafetyNumberRequest, opts ...grpc.CallOption) (*SafetyNumberResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SafetyNumberResponse)
+	err := c.cc.Invoke(ctx, KeyExchangeService_GetSafetyNumber_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keyExchangeServiceClient) SetPeerVerified(ctx context.Context, in *SetPeerVerifiedRequest, opts ...grpc.CallOption) (*SetPeerVerifiedResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SetPeerVerifiedResponse)
+	err := c.cc.Invoke(ctx, KeyExchangeService_SetPeerVerified_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keyExchangeServiceClient) GetPeerVerification(ctx context.Context, in *PeerVerificationRequest, opts ...grpc.CallOption) (*PeerVerificationResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PeerVerificationResponse)
+	err := c.cc.Invoke(ctx, KeyExchangeService_GetPeerVerification_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keyExchangeServiceClient) UploadPrekeys(ctx context.Context, in *UploadPrekeysRequest, opts ...grpc.CallOption) (*UploadPrekeysResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UploadPrekeysResponse)
+	err := c.cc.Invoke(ctx, KeyExchangeService_UploadPrekeys_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keyExchangeServiceClient) GetPrekeyStatus(ctx context.Context, in *PrekeyStatusRequest, opts ...grpc.CallOption) (*PrekeyStatusResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PrekeyStatusResponse)
+	err := c.cc.Invoke(ctx, KeyExchangeService_GetPrekeyStatus_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keyExchangeServiceClient) FetchPrekeyBundle(ctx context.Context, in *FetchPrekeyBundleRequest, opts ...grpc.CallOption) (*PrekeyBundle, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PrekeyBundle)
+	err := c.cc.Invoke(ctx, KeyExchangeService_FetchPrekeyBundle_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keyExchangeServiceClient) RevokePublicKeys(ctx context.Context, in *RevokePublicKeysRequest, opts ...grpc.CallOption) (*RevokePublicKeysResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RevokePublicKeysResponse)
+	err := c.cc.Invoke(ctx, KeyExchangeService_RevokePublicKeys_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keyExchangeServiceClient) GetPublicKeyHistory(ctx context.Context, in *PublicKeyHistoryRequest, opts ...grpc.CallOption) (*PublicKeyHistoryResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PublicKeyHistoryResponse)
+	err := c.cc.Invoke(ctx, KeyExchangeService_GetPublicKeyHistory_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keyExchangeServiceClient) GetEpochKey(ctx context.Context, in *GetEpochKeyRequest, opts ...grpc.CallOption) (*GetEpochKeyResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetEpochKeyResponse)
+	err := c.cc.Invoke(ctx, KeyExchangeService_GetEpochKey_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // KeyExchangeServiceServer is the server API for KeyExchangeService service.
 // All implementations must embed UnimplementedKeyExchangeServiceServer
 // for forward compatibility.
 type KeyExchangeServiceServer interface {
 	SendPublicKey(context.Context, *SendPublicKeyRequest) (*SendPublicKeyResponse, error)
 	ExchangeKeys(context.Context, *KeyExchangeRequest) (*KeyExchangeResponse, error)
+	DistributeSenderKey(context.Context, *DistributeSenderKeyRequest) (*DistributeSenderKeyResponse, error)
+	GetSenderKey(context.Context, *GetSenderKeyRequest) (*GetSenderKeyResponse, error)
+	GetSafetyNumber(context.Context, *SafetyNumberRequest) (*SafetyNumberResponse, error)
+	SetPeerVerified(context.Context, *SetPeerVerifiedRequest) (*SetPeerVerifiedResponse, error)
+	GetPeerVerification(context.Context, *PeerVerificationRequest) (*PeerVerificationResponse, error)
+	UploadPrekeys(context.Context, *UploadPrekeysRequest) (*UploadPrekeysResponse, error)
+	GetPrekeyStatus(context.Context, *PrekeyStatusRequest) (*PrekeyStatusResponse, error)
+	FetchPrekeyBundle(context.Context, *FetchPrekeyBundleRequest) (*PrekeyBundle, error)
+	RevokePublicKeys(context.Context, *RevokePublicKeysRequest) (*RevokePublicKeysResponse, error)
+	GetPublicKeyHistory(context.Context, *PublicKeyHistoryRequest) (*PublicKeyHistoryResponse, error)
+	GetEpochKey(context.Context, *GetEpochKeyRequest) (*GetEpochKeyResponse, error)
 	mustEmbedUnimplementedKeyExchangeServiceServer()
 }
 
@@ -81,6 +224,39 @@ func (UnimplementedKeyExchangeServiceServer) SendPublicKey(context.Context, *Sen
 func (UnimplementedKeyExchangeServiceServer) ExchangeKeys(context.Context, *KeyExchangeRequest) (*KeyExchangeResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ExchangeKeys not implemented")
 }
+func (UnimplementedKeyExchangeServiceServer) DistributeSenderKey(context.Context, *DistributeSenderKeyRequest) (*DistributeSenderKeyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DistributeSenderKey not implemented")
+}
+func (UnimplementedKeyExchangeServiceServer) GetSenderKey(context.Context, *GetSenderKeyRequest) (*GetSenderKeyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetSenderKey not implemented")
+}
+func (UnimplementedKeyExchangeServiceServer) GetSafetyNumber(context.Context, *SafetyNumberRequest) (*SafetyNumberResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetSafetyNumber not implemented")
+}
+func (UnimplementedKeyExchangeServiceServer) SetPeerVerified(context.Context, *SetPeerVerifiedRequest) (*SetPeerVerifiedResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetPeerVerified not implemented")
+}
+func (UnimplementedKeyExchangeServiceServer) GetPeerVerification(context.Context, *PeerVerificationRequest) (*PeerVerificationResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPeerVerification not implemented")
+}
+func (UnimplementedKeyExchangeServiceServer) UploadPrekeys(context.Context, *UploadPrekeysRequest) (*UploadPrekeysResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UploadPrekeys not implemented")
+}
+func (UnimplementedKeyExchangeServiceServer) GetPrekeyStatus(context.Context, *PrekeyStatusRequest) (*PrekeyStatusResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPrekeyStatus not implemented")
+}
+func (UnimplementedKeyExchangeServiceServer) FetchPrekeyBundle(context.Context, *FetchPrekeyBundleRequest) (*PrekeyBundle, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FetchPrekeyBundle not implemented")
+}
+func (UnimplementedKeyExchangeServiceServer) RevokePublicKeys(context.Context, *RevokePublicKeysRequest) (*RevokePublicKeysResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RevokePublicKeys not implemented")
+}
+func (UnimplementedKeyExchangeServiceServer) GetPublicKeyHistory(context.Context, *PublicKeyHistoryRequest) (*PublicKeyHistoryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPublicKeyHistory not implemented")
+}
+func (UnimplementedKeyExchangeServiceServer) GetEpochKey(context.Context, *GetEpochKeyRequest) (*GetEpochKeyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetEpochKey not implemented")
+}
 func (UnimplementedKeyExchangeServiceServer) mustEmbedUnimplementedKeyExchangeServiceServer() {}
 func (UnimplementedKeyExchangeServiceServer) testEmbeddedByValue()                            {}
 
@@ -138,6 +314,204 @@ func _KeyExchangeService_ExchangeKeys_Handler(srv interface{}, ctx context.Conte
 	return interceptor(ctx, in, info, handler)
 }
 
+func _KeyExchangeService_DistributeSenderKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DistributeSenderKeyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeyExchangeServiceServer).DistributeSenderKey(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KeyExchangeService_DistributeSenderKey_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeyExchangeServiceServer).DistributeSenderKey(ctx, req.(*DistributeSenderKeyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KeyExchangeService_GetSenderKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetSenderKeyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeyExchangeServiceServer).GetSenderKey(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KeyExchangeService_GetSenderKey_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeyExchangeServiceServer).GetSenderKey(ctx, req.(*GetSenderKeyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KeyExchangeService_GetSafetyNumber_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SafetyNumberRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeyExchangeServiceServer).GetSafetyNumber(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KeyExchangeService_GetSafetyNumber_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeyExchangeServiceServer).GetSafetyNumber(ctx, req.(*SafetyNumberRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KeyExchangeService_SetPeerVerified_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetPeerVerifiedRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeyExchangeServiceServer).SetPeerVerified(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KeyExchangeService_SetPeerVerified_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeyExchangeServiceServer).SetPeerVerified(ctx, req.(*SetPeerVerifiedRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KeyExchangeService_GetPeerVerification_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PeerVerificationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeyExchangeServiceServer).GetPeerVerification(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KeyExchangeService_GetPeerVerification_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeyExchangeServiceServer).GetPeerVerification(ctx, req.(*PeerVerificationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KeyExchangeService_UploadPrekeys_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UploadPrekeysRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeyExchangeServiceServer).UploadPrekeys(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KeyExchangeService_UploadPrekeys_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeyExchangeServiceServer).UploadPrekeys(ctx, req.(*UploadPrekeysRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KeyExchangeService_GetPrekeyStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PrekeyStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeyExchangeServiceServer).GetPrekeyStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KeyExchangeService_GetPrekeyStatus_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeyExchangeServiceServer).GetPrekeyStatus(ctx, req.(*PrekeyStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KeyExchangeService_FetchPrekeyBundle_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FetchPrekeyBundleRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeyExchangeServiceServer).FetchPrekeyBundle(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KeyExchangeService_FetchPrekeyBundle_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeyExchangeServiceServer).FetchPrekeyBundle(ctx, req.(*FetchPrekeyBundleRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KeyExchangeService_RevokePublicKeys_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RevokePublicKeysRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeyExchangeServiceServer).RevokePublicKeys(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KeyExchangeService_RevokePublicKeys_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeyExchangeServiceServer).RevokePublicKeys(ctx, req.(*RevokePublicKeysRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KeyExchangeService_GetPublicKeyHistory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PublicKeyHistoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeyExchangeServiceServer).GetPublicKeyHistory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KeyExchangeService_GetPublicKeyHistory_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeyExchangeServiceServer).GetPublicKeyHistory(ctx, req.(*PublicKeyHistoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KeyExchangeService_GetEpochKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetEpochKeyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeyExchangeServiceServer).GetEpochKey(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KeyExchangeService_GetEpochKey_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeyExchangeServiceServer).GetEpochKey(ctx, req.(*GetEpochKeyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // KeyExchangeService_ServiceDesc is the grpc.ServiceDesc for KeyExchangeService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -153,6 +527,50 @@ var KeyExchangeService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "ExchangeKeys",
 			Handler:    _KeyExchangeService_ExchangeKeys_Handler,
 		},
+		{
+			MethodName: "DistributeSenderKey",
+			Handler:    _KeyExchangeService_DistributeSenderKey_Handler,
+		},
+		{
+			MethodName: "GetSenderKey",
+			Handler:    _KeyExchangeService_GetSenderKey_Handler,
+		},
+		{
+			MethodName: "GetSafetyNumber",
+			Handler:    _KeyExchangeService_GetSafetyNumber_Handler,
+		},
+		{
+			MethodName: "SetPeerVerified",
+			Handler:    _KeyExchangeService_SetPeerVerified_Handler,
+		},
+		{
+			MethodName: "GetPeerVerification",
+			Handler:    _KeyExchangeService_GetPeerVerification_Handler,
+		},
+		{
+			MethodName: "UploadPrekeys",
+			Handler:    _KeyExchangeService_UploadPrekeys_Handler,
+		},
+		{
+			MethodName: "GetPrekeyStatus",
+			Handler:    _KeyExchangeService_GetPrekeyStatus_Handler,
+		},
+		{
+			MethodName: "FetchPrekeyBundle",
+			Handler:    _KeyExchangeService_FetchPrekeyBundle_Handler,
+		},
+		{
+			MethodName: "RevokePublicKeys",
+			Handler:    _KeyExchangeService_RevokePublicKeys_Handler,
+		},
+		{
+			MethodName: "GetPublicKeyHistory",
+			Handler:    _KeyExchangeService_GetPublicKeyHistory_Handler,
+		},
+		{
+			MethodName: "GetEpochKey",
+			Handler:    _KeyExchangeService_GetEpochKey_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "key_exchange.proto",
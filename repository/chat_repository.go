@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"crypto/rand"
 	"database/sql"
 	"fmt"
 
@@ -10,6 +11,9 @@ import (
 	"github.com/google/uuid"
 )
 
+// epochSecretSize — длина случайного секрета эпохи, из которого выводится её ключ
+const epochSecretSize = 32
+
 type ChatRepository struct {
 	db *sql.DB
 }
@@ -22,18 +26,24 @@ func NewChatRepository(db *sql.DB) *ChatRepository {
 	return &ChatRepository{db: db}
 }
 
-func (r *ChatRepository) CreateChat(ctx context.Context, chatID uuid.UUID, name, algorithm, mode, padding, prime string, participants []uuid.UUID) error {
+// CreateChat сохраняет чат вместе с алгоритмом согласования, группой Диффи-Хеллмана
+// (именем, простым и генератором; для X25519 они пустые), солью для вывода ключей в шестнадцатеричном виде
+// и режимом сквозного шифрования; новый чат начинается с первой эпохи ключей
+func (r *ChatRepository) CreateChat(ctx context.Context, chatID uuid.UUID, name, algorithm, mode, padding, keyAgreement, dhGroup, prime, generator, kdfSalt string, e2e bool, participants []uuid.UUID) error {
 	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer tx.Rollback()
 
-	query := `INSERT INTO chats (chat_id, name, algorithm, mode, padding, prime) VALUES ($1, $2, $3, $4, $5, $6)`
-	_, err = tx.ExecContext(ctx, query, chatID, name, algorithm, mode, padding, prime)
+	query := `INSERT INTO chats (chat_id, name, algorithm, mode, padding, prime, key_agreement, dh_group, generator, kdf_salt, e2e, key_epoch) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, 1)`
+	_, err = tx.ExecContext(ctx, query, chatID, name, algorithm, mode, padding, prime, keyAgreement, dhGroup, generator, kdfSalt, e2e)
 	if err != nil {
 		return fmt.Errorf("failed to insert chat: %w", err)
 	}
+	if err := saveEpochSecret(ctx, tx, chatID, 1); err != nil {
+		return err
+	}
 
 	query = `INSERT INTO chat_participants (chat_id, user_id) VALUES ($1, $2)`
 	for _, participant := range participants {
@@ -63,6 +73,12 @@ func (r *ChatRepository) DeleteChat(ctx context.Context, chatID uuid.UUID) error
 		return fmt.Errorf("failed to delete chat participants: %w", err)
 	}
 
+	query = `DELETE FROM sender_keys WHERE chat_id = $1`
+	_, err = tx.ExecContext(ctx, query, chatID)
+	if err != nil {
+		return fmt.Errorf("failed to delete sender keys: %w", err)
+	}
+
 	query = `DELETE FROM messages WHERE chat_id = $1`
 	_, err = tx.ExecContext(ctx, query, chatID)
 	if err != nil {
@@ -112,7 +128,8 @@ func (r *ChatRepository) GetUserChats(userID uuid.UUID) ([]models.Chat, error) {
 }
 func (r *ChatRepository) GetChatByID(chatID uuid.UUID) (*models.Chat, error) {
 	query := `
-        SELECT chat_id, name, algorithm, mode, padding, prime, created_at
+        SELECT chat_id, name, algorithm, mode, padding, prime, key_agreement, dh_group, generator, kdf_salt, e2e,
+               key_epoch, epoch_started_at, epoch_messages, first_secret_epoch, created_at
         FROM chats
         WHERE chat_id = $1
     `
@@ -125,6 +142,15 @@ func (r *ChatRepository) GetChatByID(chatID uuid.UUID) (*models.Chat, error) {
 		&chat.Mode,
 		&chat.Padding,
 		&chat.Prime,
+		&chat.KeyAgreement,
+		&chat.DHGroup,
+		&chat.Generator,
+		&chat.KDFSalt,
+		&chat.E2E,
+		&chat.KeyEpoch,
+		&chat.EpochStartedAt,
+		&chat.EpochMessages,
+		&chat.FirstSecretEpoch,
 		&chat.CreatedAt,
 	)
 
@@ -167,8 +193,15 @@ func (r *ChatRepository) SaveMessage(ctx context.Context, msg models.Message) er
             message_type,
             file_name,
             chunk_index,
-            total_chunks
-        ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+            total_chunks,
+            sender_key_id,
+            ratchet_index,
+            ratchet_key,
+            key_epoch,
+            signature,
+            sequence,
+            signed_at
+        ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
     `
 	_, err := r.db.ExecContext(
 		ctx,
@@ -182,6 +215,13 @@ func (r *ChatRepository) SaveMessage(ctx context.Context, msg models.Message) er
 		msg.FileName,
 		msg.ChunkIndex,
 		msg.TotalChunks,
+		msg.SenderKeyID,
+		msg.RatchetIndex,
+		msg.RatchetKey,
+		msg.KeyEpoch,
+		msg.Signature,
+		msg.Sequence,
+		msg.SignedAt,
 	)
 	if err != nil {
 		return fmt.Errorf("failed to save message: %w", err)
@@ -191,10 +231,11 @@ func (r *ChatRepository) SaveMessage(ctx context.Context, msg models.Message) er
 
 func (r *ChatRepository) GetMessages(chatID uuid.UUID) ([]models.Message, error) {
 	query := `
-        SELECT message_id, chat_id, sender_id, encrypted_message, created_at, message_type, file_name, chunk_index, total_chunks
+        SELECT message_id, chat_id, sender_id, encrypted_message, created_at, message_type, file_name, chunk_index, total_chunks, sender_key_id, ratchet_index, ratchet_key, key_epoch,
+               signature, sequence, signed_at
         FROM messages
         WHERE chat_id = $1
-        ORDER BY created_at ASC
+        ORDER BY created_at ASC, chunk_index ASC
     `
 	rows, err := r.db.Query(query, chatID)
 	if err != nil {
@@ -215,6 +256,13 @@ func (r *ChatRepository) GetMessages(chatID uuid.UUID) ([]models.Message, error)
 			&msg.FileName,
 			&msg.ChunkIndex,
 			&msg.TotalChunks,
+			&msg.SenderKeyID,
+			&msg.RatchetIndex,
+			&msg.RatchetKey,
+			&msg.KeyEpoch,
+			&msg.Signature,
+			&msg.Sequence,
+			&msg.SignedAt,
 		); err != nil {
 			return nil, fmt.Errorf("failed to scan message: %w", err)
 		}
@@ -228,6 +276,16 @@ func (r *ChatRepository) GetMessages(chatID uuid.UUID) ([]models.Message, error)
 	return messages, nil
 }
 
+// LastSequence возвращает наибольший номер подписанного сообщения отправителя в чате
+func (r *ChatRepository) LastSequence(ctx context.Context, chatID, senderID uuid.UUID) (uint64, error) {
+	var sequence uint64
+	query := `SELECT COALESCE(MAX(sequence), 0) FROM messages WHERE chat_id = $1 AND sender_id = $2`
+	if err := r.db.QueryRowContext(ctx, query, chatID, senderID).Scan(&sequence); err != nil {
+		return 0, fmt.Errorf("failed to get last sequence: %w", err)
+	}
+	return sequence, nil
+}
+
 func (r *ChatRepository) ChatExists(ctx context.Context, chatID uuid.UUID) (bool, error) {
 	var exists bool
 	query := `SELECT EXISTS(SELECT 1 FROM chats WHERE chat_id = $1)`
@@ -253,66 +311,325 @@ func (r *UserRepository) GetUsernameByID(ctx context.Context, userID uuid.UUID)
 	return username, nil
 }
 
-func (r *ChatRepository) SavePublicKey(ctx context.Context, userID uuid.UUID, publicKey string) error {
+// SavePublicKey делает ключ текущим ключом устройства в чате; прежний текущий ключ
+// устройства отзывается и остаётся в истории
+func (r *ChatRepository) SavePublicKey(ctx context.Context, key models.SessionKey) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
 	query := `
-		INSERT INTO session_keys (user_id, public_key)
-		VALUES ($1, $2)
-		ON CONFLICT (user_id) DO UPDATE SET
-		public_key = EXCLUDED.public_key
+		UPDATE session_keys SET revoked_at = CURRENT_TIMESTAMP
+		WHERE chat_id = $1 AND user_id = $2 AND device_id = $3 AND revoked_at IS NULL
 	`
-	_, err := r.db.ExecContext(ctx, query, userID, publicKey)
-	return err
-}
+	if _, err := tx.ExecContext(ctx, query, key.ChatID, key.UserID, key.DeviceID); err != nil {
+		return fmt.Errorf("failed to revoke previous key: %w", err)
+	}
 
-func (r *ChatRepository) GetPublicKeyByUserID(ctx context.Context, userID uuid.UUID) (string, error) {
-	var publicKey string
-	query := `SELECT public_key FROM session_keys WHERE user_id = $1`
+	query = `
+		INSERT INTO session_keys (chat_id, user_id, device_id, key_id, public_key, signature)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+	if _, err := tx.ExecContext(ctx, query, key.ChatID, key.UserID, key.DeviceID, key.KeyID, key.PublicKey, key.Signature); err != nil {
+		return fmt.Errorf("failed to save public key: %w", err)
+	}
 
-	err := r.db.QueryRowContext(ctx, query, userID).Scan(&publicKey)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			return "", fmt.Errorf("public key not found for user %s", userID)
-		}
-		return "", fmt.Errorf("failed to get public key: %w", err)
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
+	return nil
+}
 
-	return publicKey, nil
+// GetPublicKeysByChatID возвращает текущие ключи устройств участников чата
+func (r *ChatRepository) GetPublicKeysByChatID(ctx context.Context, chatID uuid.UUID) ([]models.SessionKey, error) {
+	query := `
+		SELECT sk.user_id, sk.device_id, sk.key_id, sk.public_key, sk.created_at, sk.revoked_at, sk.signature
+		FROM session_keys sk
+		JOIN chat_participants cp ON cp.chat_id = sk.chat_id AND cp.user_id = sk.user_id
+		WHERE sk.chat_id = $1 AND sk.revoked_at IS NULL
+		ORDER BY sk.user_id, sk.device_id
+	`
+	return r.querySessionKeys(ctx, chatID, query, chatID)
 }
 
-func (r *ChatRepository) GetPublicKeysByChatID(ctx context.Context, chatID uuid.UUID) (map[uuid.UUID]string, error) {
-	query := `		SELECT u.user_id, sk.public_key
-		FROM chat_participants cp
-		JOIN users u ON cp.user_id = u.user_id
-		LEFT JOIN session_keys sk ON u.user_id = sk.user_id
-		WHERE cp.chat_id = $1
+// GetPublicKeyHistory возвращает все ключи пользователя в чате, включая отозванные, от старых к новым
+func (r *ChatRepository) GetPublicKeyHistory(ctx context.Context, chatID, userID uuid.UUID) ([]models.SessionKey, error) {
+	query := `
+		SELECT user_id, device_id, key_id, public_key, created_at, revoked_at, signature
+		FROM session_keys
+		WHERE chat_id = $1 AND user_id = $2
+		ORDER BY created_at, key_id
 	`
+	return r.querySessionKeys(ctx, chatID, query, chatID, userID)
+}
 
-	rows, err := r.db.QueryContext(ctx, query, chatID)
+func (r *ChatRepository) querySessionKeys(ctx context.Context, chatID uuid.UUID, query string, args ...any) ([]models.SessionKey, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to query public keys: %w", err)
 	}
 	defer rows.Close()
 
-	publicKeys := make(map[uuid.UUID]string)
+	var keys []models.SessionKey
 	for rows.Next() {
-		var userID uuid.UUID
-		var publicKey sql.NullString
-		if err := rows.Scan(&userID, &publicKey); err != nil {
-			return nil, err
+		key := models.SessionKey{ChatID: chatID}
+		var revokedAt sql.NullTime
+		if err := rows.Scan(&key.UserID, &key.DeviceID, &key.KeyID, &key.PublicKey, &key.CreatedAt, &revokedAt, &key.Signature); err != nil {
+			return nil, fmt.Errorf("failed to scan public key: %w", err)
+		}
+		if revokedAt.Valid {
+			key.RevokedAt = &revokedAt.Time
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+// RevokePublicKeys отзывает текущие ключи устройства: в одном чате или, если chatID не задан,
+// во всех; keyID, если задан, ограничивает отзыв одним ключом. Возвращает число отозванных ключей.
+func (r *ChatRepository) RevokePublicKeys(ctx context.Context, chatID uuid.NullUUID, userID uuid.UUID, deviceID string, keyID uuid.NullUUID) (int64, error) {
+	query := `
+		UPDATE session_keys SET revoked_at = CURRENT_TIMESTAMP
+		WHERE user_id = $1 AND device_id = $2 AND revoked_at IS NULL
+		AND ($3::uuid IS NULL OR chat_id = $3) AND ($4::uuid IS NULL OR key_id = $4)
+	`
+	res, err := r.db.ExecContext(ctx, query, userID, deviceID, chatID, keyID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to revoke public keys: %w", err)
+	}
+	return res.RowsAffected()
+}
+
+// AddParticipant добавляет участника и, если он действительно новый, начинает новую эпоху ключей
+func (r *ChatRepository) AddParticipant(ctx context.Context, chatID, userID uuid.UUID) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO chat_participants (chat_id, user_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`
+	res, err := tx.ExecContext(ctx, query, chatID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to add participant: %w", err)
+	}
+	if added, err := res.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to add participant: %w", err)
+	} else if added > 0 {
+		if _, err := advanceKeyEpoch(ctx, tx, chatID); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// RemoveParticipant исключает участника, удаляет адресованные ему ключи отправителей
+// и начинает новую эпоху ключей
+func (r *ChatRepository) RemoveParticipant(ctx context.Context, chatID, userID uuid.UUID) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `DELETE FROM chat_participants WHERE chat_id = $1 AND user_id = $2`
+	res, err := tx.ExecContext(ctx, query, chatID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to remove participant: %w", err)
+	}
+
+	query = `DELETE FROM sender_keys WHERE chat_id = $1 AND recipient_id = $2`
+	_, err = tx.ExecContext(ctx, query, chatID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete sender keys: %w", err)
+	}
+
+	query = `UPDATE session_keys SET revoked_at = CURRENT_TIMESTAMP WHERE chat_id = $1 AND user_id = $2 AND revoked_at IS NULL`
+	if _, err = tx.ExecContext(ctx, query, chatID, userID); err != nil {
+		return fmt.Errorf("failed to revoke public keys: %w", err)
+	}
+
+	if removed, err := res.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to remove participant: %w", err)
+	} else if removed > 0 {
+		if _, err := advanceKeyEpoch(ctx, tx, chatID); err != nil {
+			return err
 		}
-		if publicKey.Valid {
-			publicKeys[userID] = publicKey.String
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// AdvanceKeyEpoch начинает эпоху from+1, если текущая эпоха чата всё ещё from; false — её уже
+// сменил параллельный запрос, и новая эпоха не начинается второй раз
+func (r *ChatRepository) AdvanceKeyEpoch(ctx context.Context, chatID uuid.UUID, from int) (bool, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var epoch int
+	query := `
+		UPDATE chats SET key_epoch = key_epoch + 1, epoch_started_at = CURRENT_TIMESTAMP, epoch_messages = 0
+		WHERE chat_id = $1 AND key_epoch = $2
+		RETURNING key_epoch
+	`
+	err = tx.QueryRowContext(ctx, query, chatID, from).Scan(&epoch)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to advance key epoch: %w", err)
+	}
+	if err := saveEpochSecret(ctx, tx, chatID, epoch); err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return true, nil
+}
+
+// advanceKeyEpoch начинает новую эпоху в транзакции, которая меняет состав чата
+func advanceKeyEpoch(ctx context.Context, tx *sql.Tx, chatID uuid.UUID) (int, error) {
+	var epoch int
+	query := `
+		UPDATE chats SET key_epoch = key_epoch + 1, epoch_started_at = CURRENT_TIMESTAMP, epoch_messages = 0
+		WHERE chat_id = $1
+		RETURNING key_epoch
+	`
+	if err := tx.QueryRowContext(ctx, query, chatID).Scan(&epoch); err != nil {
+		return 0, fmt.Errorf("failed to advance key epoch: %w", err)
+	}
+	return epoch, saveEpochSecret(ctx, tx, chatID, epoch)
+}
+
+// saveEpochSecret создаёт случайный секрет эпохи в той же транзакции, что начинает эпоху;
+// у чатов со сквозным шифрованием ключей эпох на сервере нет
+func saveEpochSecret(ctx context.Context, tx *sql.Tx, chatID uuid.UUID, epoch int) error {
+	secret := make([]byte, epochSecretSize)
+	if _, err := rand.Read(secret); err != nil {
+		return fmt.Errorf("failed to generate epoch secret: %w", err)
+	}
+	query := `
+		INSERT INTO chat_epoch_keys (chat_id, epoch, secret)
+		SELECT chat_id, $2, $3 FROM chats WHERE chat_id = $1 AND NOT e2e
+	`
+	if _, err := tx.ExecContext(ctx, query, chatID, epoch, secret); err != nil {
+		return fmt.Errorf("failed to save epoch secret: %w", err)
+	}
+	return nil
+}
+
+// GetEpochSecret возвращает случайный секрет эпохи чата
+func (r *ChatRepository) GetEpochSecret(ctx context.Context, chatID uuid.UUID, epoch int) ([]byte, error) {
+	var secret []byte
+	query := `SELECT secret FROM chat_epoch_keys WHERE chat_id = $1 AND epoch = $2`
+	if err := r.db.QueryRowContext(ctx, query, chatID, epoch).Scan(&secret); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("secret of epoch %d not found in chat %s", epoch, chatID)
 		}
+		return nil, fmt.Errorf("failed to get epoch secret: %w", err)
 	}
+	return secret, nil
+}
+
+// CountEpochMessage учитывает сообщение в эпохе epoch, если она всё ещё текущая
+func (r *ChatRepository) CountEpochMessage(ctx context.Context, chatID uuid.UUID, epoch int) error {
+	query := `UPDATE chats SET epoch_messages = epoch_messages + 1 WHERE chat_id = $1 AND key_epoch = $2`
+	if _, err := r.db.ExecContext(ctx, query, chatID, epoch); err != nil {
+		return fmt.Errorf("failed to count epoch message: %w", err)
+	}
+	return nil
+}
 
-	return publicKeys, nil
+func (r *ChatRepository) IsParticipant(ctx context.Context, chatID, userID uuid.UUID) (bool, error) {
+	var exists bool
+	query := `SELECT EXISTS(SELECT 1 FROM chat_participants WHERE chat_id = $1 AND user_id = $2)`
+	err := r.db.QueryRowContext(ctx, query, chatID, userID).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("failed to check participant: %w", err)
+	}
+	return exists, nil
 }
 
-func (r *ChatRepository) DeletePublicKey(ctx context.Context, userID uuid.UUID) error {
-	query := `DELETE FROM session_keys WHERE user_id = $1`
-	_, err := r.db.ExecContext(ctx, query, userID)
+// SaveSenderKeys сохраняет обёртки одного ключа отправителя для всех получателей
+func (r *ChatRepository) SaveSenderKeys(ctx context.Context, keys []models.SenderKey) error {
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
-		return fmt.Errorf("failed to delete public key: %w", err)
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO sender_keys (chat_id, sender_id, key_id, recipient_id, recipient_device_id, sender_public_key,
+			recipient_public_key, wrapped_key, signed_prekey_id, one_time_prekey_id, sender_key_signature, history_public_key)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		ON CONFLICT (chat_id, sender_id, key_id, recipient_id, recipient_device_id) DO UPDATE SET
+		sender_public_key = EXCLUDED.sender_public_key,
+		recipient_public_key = EXCLUDED.recipient_public_key,
+		wrapped_key = EXCLUDED.wrapped_key,
+		signed_prekey_id = EXCLUDED.signed_prekey_id,
+		one_time_prekey_id = EXCLUDED.one_time_prekey_id,
+		sender_key_signature = EXCLUDED.sender_key_signature,
+		history_public_key = EXCLUDED.history_public_key
+	`
+	for _, key := range keys {
+		_, err = tx.ExecContext(ctx, query, key.ChatID, key.SenderID, key.KeyID, key.RecipientID, key.RecipientDeviceID,
+			key.SenderPublicKey, key.RecipientPublicKey, key.WrappedKey, int64(key.SignedPrekeyID), int64(key.OneTimePrekeyID), key.SenderKeySignature,
+			key.HistoryPublicKey)
+		if err != nil {
+			return fmt.Errorf("failed to save sender key: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
 	return nil
 }
+
+// GetSenderKey возвращает обёртку ключа отправителя для устройства получателя, а если её нет —
+// обёртку по предварительным ключам (recipient_device_id = ”), сделанную, пока получатель был не в сети,
+// и затем копию для истории (recipient_device_id = historyDeviceID)
+func (r *ChatRepository) GetSenderKey(ctx context.Context, chatID, senderID, keyID, recipientID uuid.UUID, deviceID, historyDeviceID string) (*models.SenderKey, error) {
+	key := models.SenderKey{ChatID: chatID, SenderID: senderID, KeyID: keyID, RecipientID: recipientID}
+	query := `
+		SELECT recipient_device_id, sender_public_key, recipient_public_key, wrapped_key, signed_prekey_id, one_time_prekey_id,
+			sender_key_signature, history_public_key
+		FROM sender_keys
+		WHERE chat_id = $1 AND sender_id = $2 AND key_id = $3 AND recipient_id = $4 AND recipient_device_id IN ($5, '', $6)
+		ORDER BY recipient_device_id = $5 DESC, recipient_device_id = '' DESC
+		LIMIT 1
+	`
+	var signedPrekeyID, oneTimePrekeyID int64
+	err := r.db.QueryRowContext(ctx, query, chatID, senderID, keyID, recipientID, deviceID, historyDeviceID).Scan(
+		&key.RecipientDeviceID,
+		&key.SenderPublicKey,
+		&key.RecipientPublicKey,
+		&key.WrappedKey,
+		&signedPrekeyID,
+		&oneTimePrekeyID,
+		&key.SenderKeySignature,
+		&key.HistoryPublicKey,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("sender key %s not found for user %s", keyID, recipientID)
+		}
+		return nil, fmt.Errorf("failed to get sender key: %w", err)
+	}
+	key.SignedPrekeyID, key.OneTimePrekeyID = uint32(signedPrekeyID), uint32(oneTimePrekeyID)
+	return &key, nil
+}
@@ -3,11 +3,14 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 
 	"Kygram/config"
+	"Kygram/models"
 
 	_ "github.com/lib/pq"
 )
@@ -66,3 +69,222 @@ func (r *UserRepository) GetUserIDByUsername(ctx context.Context, username strin
 	}
 	return userID, nil
 }
+
+// ErrIdentityKeyChanged — ключ личности пользователя не тот, что ожидал вызывающий: его
+// успели зарегистрировать или заменить
+var ErrIdentityKeyChanged = errors.New("identity key has changed")
+
+// SaveIdentityKey сохраняет ключ подписи пользователя. previous — ключ, который заменяется,
+// или пустая строка, если ключа ещё нет; проверка и запись выполняются одним запросом
+func (r *UserRepository) SaveIdentityKey(ctx context.Context, userID uuid.UUID, previous, publicKey string) error {
+	var result sql.Result
+	var err error
+	if previous == "" {
+		query := `INSERT INTO identity_keys (user_id, public_key) VALUES ($1, $2) ON CONFLICT (user_id) DO NOTHING`
+		result, err = r.db.ExecContext(ctx, query, userID, publicKey)
+	} else {
+		query := `
+			UPDATE identity_keys SET public_key = $3, created_at = CURRENT_TIMESTAMP
+			WHERE user_id = $1 AND public_key = $2
+		`
+		result, err = r.db.ExecContext(ctx, query, userID, previous, publicKey)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to save identity key: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to save identity key: %w", err)
+	}
+	if rows == 0 {
+		return ErrIdentityKeyChanged
+	}
+	return nil
+}
+
+// FindIdentityKey возвращает ключ подписи пользователя или пустую строку, если ключа нет
+func (r *UserRepository) FindIdentityKey(ctx context.Context, userID uuid.UUID) (string, error) {
+	var publicKey string
+	query := `SELECT public_key FROM identity_keys WHERE user_id = $1`
+	err := r.db.QueryRowContext(ctx, query, userID).Scan(&publicKey)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get identity key: %w", err)
+	}
+	return publicKey, nil
+}
+
+func (r *UserRepository) GetIdentityKey(ctx context.Context, userID uuid.UUID) (string, time.Time, error) {
+	var publicKey string
+	var createdAt time.Time
+	query := `SELECT public_key, created_at FROM identity_keys WHERE user_id = $1`
+	err := r.db.QueryRowContext(ctx, query, userID).Scan(&publicKey, &createdAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", time.Time{}, fmt.Errorf("identity key not found for user %s", userID)
+		}
+		return "", time.Time{}, fmt.Errorf("failed to get identity key: %w", err)
+	}
+	return publicKey, createdAt, nil
+}
+
+// SaveVerifiedKey запоминает, что пользователь сверил код безопасности с собеседником
+// при ключе личности publicKey
+func (r *UserRepository) SaveVerifiedKey(ctx context.Context, userID, peerID uuid.UUID, publicKey string) error {
+	query := `
+		INSERT INTO verified_keys (user_id, peer_id, public_key) VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, peer_id) DO UPDATE SET public_key = EXCLUDED.public_key, verified_at = CURRENT_TIMESTAMP
+	`
+	if _, err := r.db.ExecContext(ctx, query, userID, peerID, publicKey); err != nil {
+		return fmt.Errorf("failed to save verified key: %w", err)
+	}
+	return nil
+}
+
+func (r *UserRepository) DeleteVerifiedKey(ctx context.Context, userID, peerID uuid.UUID) error {
+	query := `DELETE FROM verified_keys WHERE user_id = $1 AND peer_id = $2`
+	if _, err := r.db.ExecContext(ctx, query, userID, peerID); err != nil {
+		return fmt.Errorf("failed to delete verified key: %w", err)
+	}
+	return nil
+}
+
+// GetVerifiedKeys возвращает ключи собеседников, которые пользователь подтвердил
+func (r *UserRepository) GetVerifiedKeys(ctx context.Context, userID uuid.UUID) (map[uuid.UUID]string, error) {
+	query := `SELECT peer_id, public_key FROM verified_keys WHERE user_id = $1`
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query verified keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := make(map[uuid.UUID]string)
+	for rows.Next() {
+		var peerID uuid.UUID
+		var publicKey string
+		if err := rows.Scan(&peerID, &publicKey); err != nil {
+			return nil, fmt.Errorf("failed to scan verified key: %w", err)
+		}
+		keys[peerID] = publicKey
+	}
+	return keys, rows.Err()
+}
+
+// SaveSignedPrekey заменяет подписанный предварительный ключ пользователя
+func (r *UserRepository) SaveSignedPrekey(ctx context.Context, userID uuid.UUID, key models.Prekey) error {
+	query := `
+		INSERT INTO signed_prekeys (user_id, key_id, public_key, signature) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id) DO UPDATE SET key_id = EXCLUDED.key_id, public_key = EXCLUDED.public_key,
+		signature = EXCLUDED.signature, created_at = CURRENT_TIMESTAMP
+	`
+	if _, err := r.db.ExecContext(ctx, query, userID, int64(key.KeyID), key.PublicKey, key.Signature); err != nil {
+		return fmt.Errorf("failed to save signed prekey: %w", err)
+	}
+	return nil
+}
+
+// GetSignedPrekey возвращает подписанный предварительный ключ пользователя, не трогая запас одноразовых
+func (r *UserRepository) GetSignedPrekey(ctx context.Context, userID uuid.UUID) (*models.Prekey, error) {
+	var signed models.Prekey
+	var signedID int64
+	query := `SELECT key_id, public_key, signature FROM signed_prekeys WHERE user_id = $1`
+	if err := r.db.QueryRowContext(ctx, query, userID).Scan(&signedID, &signed.PublicKey, &signed.Signature); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("signed prekey not found for user %s", userID)
+		}
+		return nil, fmt.Errorf("failed to get signed prekey: %w", err)
+	}
+	signed.KeyID = uint32(signedID)
+	return &signed, nil
+}
+
+// AddOneTimePrekeys пополняет запас одноразовых ключей; replace сначала удаляет прежний запас
+func (r *UserRepository) AddOneTimePrekeys(ctx context.Context, userID uuid.UUID, keys []models.Prekey, replace bool) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if replace {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM one_time_prekeys WHERE user_id = $1`, userID); err != nil {
+			return fmt.Errorf("failed to delete one-time prekeys: %w", err)
+		}
+	}
+
+	query := `
+		INSERT INTO one_time_prekeys (user_id, key_id, public_key) VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, key_id) DO NOTHING
+	`
+	for _, key := range keys {
+		if _, err := tx.ExecContext(ctx, query, userID, int64(key.KeyID), key.PublicKey); err != nil {
+			return fmt.Errorf("failed to save one-time prekey: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// GetPrekeyStatus возвращает номер подписанного ключа (0, если его нет) и запас одноразовых
+func (r *UserRepository) GetPrekeyStatus(ctx context.Context, userID uuid.UUID) (uint32, int, error) {
+	var signedID sql.NullInt64
+	var count int
+	query := `
+		SELECT (SELECT key_id FROM signed_prekeys WHERE user_id = $1),
+		       (SELECT COUNT(*) FROM one_time_prekeys WHERE user_id = $1)
+	`
+	if err := r.db.QueryRowContext(ctx, query, userID).Scan(&signedID, &count); err != nil {
+		return 0, 0, fmt.Errorf("failed to get prekey status: %w", err)
+	}
+	return uint32(signedID.Int64), count, nil
+}
+
+// TakePrekeys возвращает подписанный ключ пользователя и удаляет из запаса один одноразовый;
+// второй результат равен nil, если запас пуст
+func (r *UserRepository) TakePrekeys(ctx context.Context, userID uuid.UUID) (*models.Prekey, *models.Prekey, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var signed models.Prekey
+	var signedID int64
+	query := `SELECT key_id, public_key, signature FROM signed_prekeys WHERE user_id = $1`
+	if err := tx.QueryRowContext(ctx, query, userID).Scan(&signedID, &signed.PublicKey, &signed.Signature); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil, fmt.Errorf("signed prekey not found for user %s", userID)
+		}
+		return nil, nil, fmt.Errorf("failed to get signed prekey: %w", err)
+	}
+	signed.KeyID = uint32(signedID)
+
+	// параллельные запросы получают разные одноразовые ключи
+	var oneTime *models.Prekey
+	var oneTimeID int64
+	var oneTimeKey string
+	query = `
+		DELETE FROM one_time_prekeys WHERE (user_id, key_id) = (
+			SELECT user_id, key_id FROM one_time_prekeys WHERE user_id = $1
+			ORDER BY key_id LIMIT 1 FOR UPDATE SKIP LOCKED
+		)
+		RETURNING key_id, public_key
+	`
+	err = tx.QueryRowContext(ctx, query, userID).Scan(&oneTimeID, &oneTimeKey)
+	switch {
+	case err == nil:
+		oneTime = &models.Prekey{KeyID: uint32(oneTimeID), PublicKey: oneTimeKey}
+	case err != sql.ErrNoRows:
+		return nil, nil, fmt.Errorf("failed to take one-time prekey: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return &signed, oneTime, nil
+}
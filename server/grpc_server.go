@@ -22,16 +22,15 @@ func StartGRPCServer() {
 
 	userRepo := repository.NewUserRepository()
 	chatRepo := repository.NewChatRepository(config.GetDB())
-	secretKey := []byte("qoiewpqvnfj")
-
-	authService := services.NewAuthService(userRepo, secretKey)
-	keyExchangeService := services.NewKeyExchangeService(chatRepo)
+	authService := services.NewAuthService(userRepo, config.JWTKey())
+	keyExchangeService := services.NewKeyExchangeService(chatRepo, userRepo, authService)
 	chatService := services.NewChatService(userRepo, chatRepo)
 
 	grpcServer := grpc.NewServer()
 	protopb.RegisterUserServiceServer(grpcServer, authService)
 	protopb.RegisterKeyExchangeServiceServer(grpcServer, keyExchangeService)
 	protopb.RegisterChatServiceServer(grpcServer, chatService)
+	protopb.RegisterClientServiceServer(grpcServer, services.NewClientService(chatService))
 
 	fmt.Println("gRPC server is running on port 50051")
 	if err := grpcServer.Serve(lis); err != nil {
@@ -99,7 +99,6 @@ func (h *AuthHandlers) LogoutHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	const bearerPrefix = "Bearer "
 	if !strings.HasPrefix(authHeader, bearerPrefix) {
 		http.Error(w, "Invalid authorization header", http.StatusUnauthorized)
 		return
@@ -122,3 +121,14 @@ func (h *AuthHandlers) LogoutHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(resp)
 }
+
+const bearerPrefix = "Bearer "
+
+// bearerToken возвращает токен из заголовка Authorization или пустую строку
+func bearerToken(r *http.Request) string {
+	authHeader := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authHeader, bearerPrefix) {
+		return ""
+	}
+	return strings.TrimPrefix(authHeader, bearerPrefix)
+}
@@ -1,16 +1,23 @@
 package handlers
 
 import (
+	"bytes"
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"log"
-	"math/big"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/gorilla/websocket"
 
+	"Kygram/algos"
+	"Kygram/config"
+	"Kygram/models"
 	"Kygram/proto/protopb"
 	"Kygram/repository"
 	"Kygram/services"
@@ -18,6 +25,7 @@ import (
 	"Kygram/client"
 
 	"github.com/google/uuid"
+	"google.golang.org/grpc"
 )
 
 var upgrader = websocket.Upgrader{
@@ -28,17 +36,63 @@ var upgrader = websocket.Upgrader{
 	},
 }
 
+// fileRelayChunkSize — размер расшифрованных частей файла, отправляемых в WebSocket
+const fileRelayChunkSize = 64 * 1024
+
+// outgoingFile — файл, который пользователь отправляет частями: каждая часть сразу
+// шифруется и уходит в gRPC-поток, целиком файл в памяти не собирается
+type outgoingFile struct {
+	encryptor io.WriteCloser
+	encrypted bytes.Buffer
+	nextChunk int
+	header    client.RatchetHeader
+}
+
+// incomingFile — файл, который приходит частями из gRPC-потока
+type incomingFile struct {
+	pw           *io.PipeWriter
+	verification chunkVerification
+}
+
+// chunkVerification накапливает результат проверки подписей частей файла;
+// пишет цикл приёма, читает горутина расшифровки
+type chunkVerification struct {
+	mu  sync.Mutex
+	err error
+}
+
+func (v *chunkVerification) add(err error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.err == nil {
+		v.err = err
+	}
+}
+
+func (v *chunkVerification) result() error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.err
+}
+
 type ChatHandlers struct {
 	ChatService *services.ChatService
+	AuthService *services.AuthService
 	ChatRepo    *repository.ChatRepository
 	GrpcClient  protopb.ChatServiceClient
+	KeyExchange *client.KeyExchangeClient
+	Users       *client.UserClient
 }
 
-func NewChatHandlers(chatService *services.ChatService, chatRepo *repository.ChatRepository, grpcClient protopb.ChatServiceClient) *ChatHandlers {
+// NewChatHandlers строит все клиенты gRPC поверх одного общего соединения
+func NewChatHandlers(chatService *services.ChatService, authService *services.AuthService, chatRepo *repository.ChatRepository, conn grpc.ClientConnInterface) *ChatHandlers {
 	return &ChatHandlers{
 		ChatService: chatService,
+		AuthService: authService,
 		ChatRepo:    chatRepo,
-		GrpcClient:  grpcClient,
+		GrpcClient:  protopb.NewChatServiceClient(conn),
+		KeyExchange: client.NewKeyExchangeClientWithConn(conn),
+		Users:       client.NewUserClientWithConn(conn),
 	}
 }
 
@@ -58,6 +112,17 @@ func (h *ChatHandlers) ListUsersHandler(w http.ResponseWriter, r *http.Request)
 	json.NewEncoder(w).Encode(resp)
 }
 
+func (h *ChatHandlers) ListAlgorithmsHandler(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.ChatService.ListAlgorithms(r.Context(), &protopb.ListAlgorithmsRequest{})
+	if err != nil {
+		sendJSONError(w, "Failed to list algorithms", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
 func (h *ChatHandlers) CreateChatHandler(w http.ResponseWriter, r *http.Request) {
 
 	var req protopb.CreateChatRequest
@@ -89,6 +154,11 @@ func (h *ChatHandlers) CreateChatHandler(w http.ResponseWriter, r *http.Request)
 		req.ChatId, req.Algorithm, req.Mode, req.Padding, req.Participants)
 
 	resp, err := h.ChatService.CreateChat(ctx, &req)
+	if errors.Is(err, services.ErrInvalidEncryptionParams) {
+		log.Printf("Rejected chat parameters: %v", err)
+		http.Error(w, `{"error": "Unsupported encryption parameters"}`, http.StatusBadRequest)
+		return
+	}
 	if err != nil {
 		log.Printf("Failed to create chat: %v", err)
 		http.Error(w, `{"error": "Failed to create chat"}`, http.StatusInternalServerError)
@@ -99,6 +169,59 @@ func (h *ChatHandlers) CreateChatHandler(w http.ResponseWriter, r *http.Request)
 	json.NewEncoder(w).Encode(resp)
 }
 
+// AddParticipantHandler и RemoveParticipantHandler меняют состав чата; ключи отправителей
+// сменятся у каждого участника при его следующем сообщении. Менять состав может только
+// участник чата, вошедший в систему
+func (h *ChatHandlers) AddParticipantHandler(w http.ResponseWriter, r *http.Request) {
+	h.changeParticipants(w, r, h.ChatService.AddParticipant)
+}
+
+func (h *ChatHandlers) RemoveParticipantHandler(w http.ResponseWriter, r *http.Request) {
+	h.changeParticipants(w, r, h.ChatService.RemoveParticipant)
+}
+
+func (h *ChatHandlers) changeParticipants(w http.ResponseWriter, r *http.Request, change func(context.Context, *protopb.ParticipantRequest) (*protopb.ParticipantResponse, error)) {
+	var req protopb.ParticipantRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendJSONError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.ChatId == "" || req.Username == "" {
+		sendJSONError(w, "Missing required fields", http.StatusBadRequest)
+		return
+	}
+	callerID, err := h.AuthService.Authenticate(bearerToken(r))
+	if err != nil {
+		sendJSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	chatID, err := uuid.Parse(req.ChatId)
+	if err != nil {
+		sendJSONError(w, "Invalid chat ID", http.StatusBadRequest)
+		return
+	}
+	isParticipant, err := h.ChatRepo.IsParticipant(r.Context(), chatID, callerID)
+	if err != nil {
+		log.Printf("Failed to check participant %s of chat %s: %v", callerID, chatID, err)
+		sendJSONError(w, "Failed to change participants", http.StatusInternalServerError)
+		return
+	}
+	if !isParticipant {
+		sendJSONError(w, "User is not a participant of the chat", http.StatusForbidden)
+		return
+	}
+
+	resp, err := change(r.Context(), &req)
+	if err != nil {
+		log.Printf("Failed to change participants of chat %s: %v", req.ChatId, err)
+		sendJSONError(w, "Failed to change participants", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
 func (h *ChatHandlers) ListUserChatsHandler(w http.ResponseWriter, r *http.Request) {
 	userID := r.Header.Get("X-User-ID")
 	if userID == "" {
@@ -189,14 +312,18 @@ func (h *ChatHandlers) GetChatHandler(w http.ResponseWriter, r *http.Request) {
 	response := map[string]interface{}{
 		"success": true,
 		"chat": map[string]interface{}{
-			"id":           chat.ChatID.String(),
-			"name":         chat.Name,
-			"algorithm":    chat.Algorithm,
-			"mode":         chat.Mode,
-			"padding":      chat.Padding,
-			"prime":        chat.Prime,
-			"created_at":   chat.CreatedAt,
-			"participants": participants,
+			"id":            chat.ChatID.String(),
+			"name":          chat.Name,
+			"algorithm":     chat.Algorithm,
+			"mode":          chat.Mode,
+			"padding":       chat.Padding,
+			"prime":         chat.Prime,
+			"key_agreement": services.ChatKeyAgreementName(chat),
+			"dh_group":      chat.DHGroup,
+			"generator":     chat.Generator,
+			"e2e":           chat.E2E,
+			"created_at":    chat.CreatedAt,
+			"participants":  participants,
 		},
 	}
 
@@ -205,6 +332,20 @@ func (h *ChatHandlers) GetChatHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *ChatHandlers) WebSocketHandler(w http.ResponseWriter, r *http.Request) {
+	// пользователь соединения — владелец токена, а не параметр user_id: иначе любой открыл бы
+	// соединение от чужого имени и, закрыв его, отозвал бы ключ чужого устройства
+	token := r.URL.Query().Get("token")
+	callerID, err := h.AuthService.Authenticate(token)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if userID := r.URL.Query().Get("user_id"); userID != "" && userID != callerID.String() {
+		http.Error(w, "user_id doesn't match the token", http.StatusForbidden)
+		return
+	}
+	userIDStr := callerID.String()
+
 	conn, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		log.Println("WebSocket upgrade failed:", err)
@@ -213,13 +354,16 @@ func (h *ChatHandlers) WebSocketHandler(w http.ResponseWriter, r *http.Request)
 	defer conn.Close()
 
 	chatIDStr := r.URL.Query().Get("chat_id")
-	userIDStr := r.URL.Query().Get("user_id")
-	if chatIDStr == "" || userIDStr == "" {
-		log.Println("Missing chat_id or user_id param")
+	if chatIDStr == "" {
+		log.Println("Missing chat_id param")
 		return
 	}
+	deviceID := r.URL.Query().Get("device_id")
+	if deviceID == "" {
+		deviceID = services.DefaultDeviceID
+	}
 
-	log.Printf("WebSocket connection established for chat_id=%s, user_id=%s", chatIDStr, userIDStr)
+	log.Printf("WebSocket connection established for chat_id=%s, user_id=%s, device_id=%s", chatIDStr, userIDStr, deviceID)
 
 	chatUUID, err := uuid.Parse(chatIDStr)
 	if err != nil {
@@ -233,31 +377,108 @@ func (h *ChatHandlers) WebSocketHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	keyExchangeClient, err := client.NewKeyExchangeClient("localhost:50051")
-	if err != nil {
-		log.Println("Failed to create key exchange client:", err)
+	// ключи чата со сквозным шифрованием есть только у браузеров участников
+	if chat.E2E {
+		h.relayEnvelopes(r, conn, token, chatIDStr, userIDStr, deviceID, r.URL.Query().Get("key_id"))
 		return
 	}
 
-	privateKey, err := keyExchangeClient.GenerateAndSendKey(chatIDStr, userIDStr)
+	// gorilla/websocket допускает только одного писателя одновременно
+	var wsMu sync.Mutex
+	writeJSON := func(v interface{}) error {
+		wsMu.Lock()
+		defer wsMu.Unlock()
+		return conn.WriteJSON(v)
+	}
+
+	// ключ личности есть только у браузера: подписи сервер запрашивает у него, в том числе под
+	// ключом подключения и ключами храповика. Кадры браузера
+	// читает одна горутина, потому что ответы на запросы подписи приходят вперемешку с сообщениями
+	// и нужны ещё до того, как начнётся их разбор
+	identity := newBrowserSigner(h.Users, userIDStr, writeJSON)
+	frames := make(chan []byte, 16)
+	readerDone := make(chan struct{})
+	defer close(readerDone)
+	go func() {
+		defer close(frames)
+		for {
+			_, data, err := conn.ReadMessage()
+			if err != nil {
+				log.Println("WebSocket read error:", err)
+				return
+			}
+			if identity.deliver(data) {
+				continue
+			}
+			select {
+			case frames <- data:
+			case <-readerDone:
+				return
+			}
+		}
+	}()
+
+	// ключи устройства сервер публикует и отзывает от имени пользователя, с его токеном
+	keyExchangeClient := h.KeyExchange.WithToken(token)
+
+	var privateKey algos.AgreementKey
+	var keyID string
+	agreement, err := services.ChatKeyAgreement(chat)
 	if err != nil {
+		log.Println("Failed to get key agreement of chat:", err)
+	} else if privateKey, keyID, err = keyExchangeClient.GenerateAndSendKey(chatIDStr, userIDStr, deviceID, agreement, identity); err != nil {
 		log.Println("Failed to generate and send key:", err)
-		log.Println("Using default keys for encryption")
+	}
+	if privateKey == nil {
+		log.Println("Using epoch keys for encryption")
+	} else {
+		// без текущих ключей устройств пользователь считается не в сети, и отправители берут его
+		// предварительные ключи; отозванный ключ остаётся в истории
+		defer func() {
+			if _, err := keyExchangeClient.RevokePublicKeys(chatIDStr, userIDStr, deviceID, keyID); err != nil {
+				log.Println("Failed to revoke public key:", err)
+			}
+		}()
+	}
+
+	// цепочки ключей отправителей; без них сообщения шифруются стандартным ключом
+	var groupKeys *client.GroupKeys
+	if privateKey != nil {
+		if kdf, err := services.ChatKeyDerivation(chat); err != nil {
+			log.Println("Failed to prepare key derivation:", err)
+		} else {
+			groupKeys = keyExchangeClient.NewGroupKeys(chatIDStr, userIDStr, deviceID, chat.Algorithm, agreement, privateKey, kdf)
+			groupKeys.UseIdentity(identity, h.Users)
+		}
 	}
 
-	var peerKeys []*protopb.ClientPublicKey
-	var sharedKeys map[string][]byte
+	verifier := h.Users.NewMessageVerifier()
+	// если браузер не подпишет, сообщения уйдут неподписанными, и получатели пометят их
+	signer, err := h.newMessageSigner(r.Context(), identity, chatUUID, userIDStr)
+	if err != nil {
+		log.Println("Messages will not be signed:", err)
+	}
 
-	peerKeys, err = keyExchangeClient.GetPeerKeys(chatIDStr)
+	// предварительные ключи нужны, чтобы с пользователем можно было согласовать ключ, пока он не в сети;
+	// подписанный ключ подписывает браузер, поэтому публикация не задерживает подключение
+	prekeys, err := client.OpenPrekeyStore(config.GetEnv("PREKEY_DIR", "keys/prekeys"), userIDStr)
 	if err != nil {
-		log.Println("Failed to get peer keys:", err)
+		log.Println("Failed to open prekey store:", err)
 	} else {
-		if prime, ok := new(big.Int).SetString(chat.Prime, 10); ok && privateKey != nil {
-			sharedKeys = keyExchangeClient.ComputeSharedKeys(privateKey, peerKeys, prime)
-		} else {
-			log.Println("Failed to parse prime number or private key is nil, using default keys")
+		go func() {
+			if err := prekeys.Publish(keyExchangeClient, identity); err != nil {
+				log.Println("Failed to publish prekeys:", err)
+			}
+		}()
+		if groupKeys != nil {
+			groupKeys.UsePrekeys(prekeys)
 		}
 	}
+	// секрет эпохи сервер оборачивает для ключа подключения, а без него — для предварительного ключа
+	epochKeys := groupKeys
+	if epochKeys == nil && prekeys != nil {
+		epochKeys = h.readerGroupKeys(chat, chatIDStr, userIDStr, prekeys)
+	}
 
 	stream, err := h.GrpcClient.StreamMessages(r.Context())
 	if err != nil {
@@ -274,22 +495,26 @@ func (h *ChatHandlers) WebSocketHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	fileChunks := make(map[string][][]byte)
+	// предупреждаем о смене ключей собеседников, чей код безопасности пользователь уже сверил
+	warnings := &keyChangeWarnings{keys: keyExchangeClient, userID: userIDStr, chatID: chatIDStr, warned: make(map[string]string)}
+	h.warnKeyChanges(r.Context(), warnings, writeJSON)
+	verifier.OnKeyChange(func(senderID, publicKey string) {
+		log.Printf("[IDENTITY] Ключ личности %s сменился во время сеанса", senderID)
+		h.warnKeyChanges(r.Context(), warnings, writeJSON)
+	})
 
-	go func() {
+	outgoingFiles := make(map[string]*outgoingFile)
 
+	go func() {
+		// браузер ушёл: сервер завершит поток, и обработчик выйдет, освободив ключ подключения
+		defer stream.CloseSend()
 		defer func() {
 			if r := recover(); r != nil {
 				log.Println("Recovered from panic in WebSocket handler:", r)
 			}
 		}()
 
-		for {
-			_, msgBytes, err := conn.ReadMessage()
-			if err != nil {
-				log.Println("WebSocket read error:", err)
-				return
-			}
+		for msgBytes := range frames {
 			var messageData map[string]interface{}
 			if err := json.Unmarshal(msgBytes, &messageData); err != nil {
 				log.Println("Failed to parse WebSocket message:", err)
@@ -308,10 +533,15 @@ func (h *ChatHandlers) WebSocketHandler(w http.ResponseWriter, r *http.Request)
 				log.Printf("Processing text message: %s", messageText)
 
 				var encryptionKey []byte
-				if sharedKeys != nil {
-					if recipientKey, ok := sharedKeys[userIDStr]; ok {
-						encryptionKey = recipientKey
-					}
+				var header client.RatchetHeader
+				if groupKeys != nil {
+					header, encryptionKey, err = groupKeys.NextMessageKey()
+				} else {
+					encryptionKey, header.Epoch, err = h.currentEpochKey(r.Context(), chatUUID, epochKeys)
+				}
+				if err != nil {
+					log.Println("Failed to get message key:", err)
+					continue
 				}
 
 				encryptedMsg, err := h.ChatService.EncryptMessage(&protopb.Message{
@@ -326,7 +556,7 @@ func (h *ChatHandlers) WebSocketHandler(w http.ResponseWriter, r *http.Request)
 					log.Println("Failed to encrypt message:", err)
 					continue
 				}
-				if err := stream.Send(&protopb.Message{
+				outgoing := &protopb.Message{
 					MessageId:        uuid.New().String(),
 					ChatId:           chatIDStr,
 					SenderId:         userIDStr,
@@ -335,7 +565,17 @@ func (h *ChatHandlers) WebSocketHandler(w http.ResponseWriter, r *http.Request)
 					Mode:             chat.Mode,
 					Padding:          chat.Padding,
 					MessageType:      "text",
-				}); err != nil {
+					SenderKeyId:      header.KeyID,
+					RatchetIndex:     header.Index,
+					RatchetKey:       header.RatchetKey,
+					KeyEpoch:         header.Epoch,
+				}
+				if signer != nil {
+					if err := signer.Sign(outgoing); err != nil {
+						log.Println("Message will not be signed:", err)
+					}
+				}
+				if err := stream.Send(outgoing); err != nil {
 					log.Println("Failed to send message via gRPC:", err)
 					continue
 				}
@@ -354,67 +594,98 @@ func (h *ChatHandlers) WebSocketHandler(w http.ResponseWriter, r *http.Request)
 					chunkBytes[i] = byte(v.(float64))
 				}
 
-				if fileChunks[fileName] == nil {
-					fileChunks[fileName] = make([][]byte, totalChunks)
-				}
-				fileChunks[fileName][chunkIndex] = chunkBytes
-
-				log.Printf("Saved chunk %d for file %s", chunkIndex+1, fileName)
-
-				if chunkIndex == totalChunks-1 {
-					var fileData []byte
-					for i, chunk := range fileChunks[fileName] {
-						if chunk == nil {
-							log.Printf("Missing chunk %d for file %s", i+1, fileName)
-							return
-						}
-						fileData = append(fileData, chunk...)
+				file := outgoingFiles[fileName]
+				if file == nil {
+					if chunkIndex != 0 {
+						log.Printf("Missing first chunk for file %s, dropping chunk %d", fileName, chunkIndex+1)
+						continue
 					}
 
-					log.Printf("All chunks received for file %s. Total size: %d bytes", fileName, len(fileData))
-
 					var encryptionKey []byte
-					if sharedKeys != nil {
-						if recipientKey, ok := sharedKeys[userIDStr]; ok {
-							encryptionKey = recipientKey
-						}
+					file = &outgoingFile{}
+					if groupKeys != nil {
+						file.header, encryptionKey, err = groupKeys.NextMessageKey()
+					} else {
+						encryptionKey, file.header.Epoch, err = h.currentEpochKey(r.Context(), chatUUID, epochKeys)
+					}
+					if err != nil {
+						log.Println("Failed to get message key:", err)
+						continue
 					}
 
-					encryptedFile, err := h.ChatService.EncryptMessage(&protopb.Message{
-						ChatId:           chatIDStr,
-						SenderId:         userIDStr,
-						EncryptedMessage: fileData,
-						Algorithm:        chat.Algorithm,
-						Mode:             chat.Mode,
-						Padding:          chat.Padding,
-					}, encryptionKey)
+					file.encryptor, err = h.ChatService.NewFileEncryptor(&file.encrypted, chat.Algorithm, chat.Mode, chat.Padding,
+						encryptionKey, services.MessageAAD(chatIDStr, userIDStr))
 					if err != nil {
+						log.Println("Failed to start file encryption:", err)
+						continue
+					}
+					outgoingFiles[fileName] = file
+				}
+
+				if chunkIndex != file.nextChunk {
+					log.Printf("Chunk %d of file %s arrived out of order, dropping the file", chunkIndex+1, fileName)
+					delete(outgoingFiles, fileName)
+					continue
+				}
+				file.nextChunk++
+
+				if _, err := file.encryptor.Write(chunkBytes); err != nil {
+					log.Println("Failed to encrypt file:", err)
+					delete(outgoingFiles, fileName)
+					continue
+				}
+				lastChunk := chunkIndex == totalChunks-1
+				if lastChunk {
+					if err := file.encryptor.Close(); err != nil {
 						log.Println("Failed to encrypt file:", err)
-						return
+						delete(outgoingFiles, fileName)
+						continue
 					}
+				}
 
-					if err := stream.Send(&protopb.Message{
-						ChatId:           chatIDStr,
-						SenderId:         userIDStr,
-						EncryptedMessage: encryptedFile,
-						Algorithm:        chat.Algorithm,
-						Mode:             chat.Mode,
-						Padding:          chat.Padding,
-						MessageType:      "file",
-						FileName:         fileName,
-						ChunkIndex:       int32(chunkIndex),
-						TotalChunks:      int32(totalChunks),
-					}); err != nil {
-						log.Println("Failed to send file via gRPC:", err)
-						return
+				outgoing := &protopb.Message{
+					ChatId:           chatIDStr,
+					SenderId:         userIDStr,
+					EncryptedMessage: append([]byte(nil), file.encrypted.Bytes()...),
+					Algorithm:        chat.Algorithm,
+					Mode:             chat.Mode,
+					Padding:          chat.Padding,
+					MessageType:      "file",
+					FileName:         fileName,
+					ChunkIndex:       int32(chunkIndex),
+					TotalChunks:      int32(totalChunks),
+					SenderKeyId:      file.header.KeyID,
+					RatchetIndex:     file.header.Index,
+					RatchetKey:       file.header.RatchetKey,
+					KeyEpoch:         file.header.Epoch,
+				}
+				if signer != nil {
+					if err := signer.Sign(outgoing); err != nil {
+						log.Println("Message will not be signed:", err)
 					}
+				}
+				if err := stream.Send(outgoing); err != nil {
+					log.Println("Failed to send file via gRPC:", err)
+					return
+				}
+				file.encrypted.Reset()
 
-					delete(fileChunks, fileName)
+				if lastChunk {
+					log.Printf("All chunks of file %s encrypted and sent", fileName)
+					delete(outgoingFiles, fileName)
 				}
 			}
 		}
 	}()
 
+	// части входящих файлов передаются расшифровывающим горутинам через pipe
+	incomingFiles := make(map[string]*incomingFile)
+	defer func() {
+		for _, file := range incomingFiles {
+			file.pw.CloseWithError(io.ErrUnexpectedEOF)
+		}
+	}()
+
 	for {
 		msg, err := stream.Recv()
 		if err != nil {
@@ -422,70 +693,325 @@ func (h *ChatHandlers) WebSocketHandler(w http.ResponseWriter, r *http.Request)
 			return
 		}
 
-		var decryptionKey []byte
-		if sharedKeys != nil {
-			if senderKey, ok := sharedKeys[msg.SenderId]; ok {
-				decryptionKey = senderKey
+		// ключ сообщения выдаётся один раз, поэтому для файла он запрашивается только по первой части;
+		// ошибка получения ключа обрабатывается как ошибка расшифровки
+		messageKey := func() ([]byte, error) {
+			if msg.SenderKeyId == "" {
+				return epochKey(chat, epochKeys, msg.KeyEpoch)
 			}
+			if groupKeys == nil {
+				return nil, errors.New("no group keys on this connection")
+			}
+			return groupKeys.MessageKey(msg.SenderId, client.RatchetHeader{
+				KeyID:      msg.SenderKeyId,
+				Index:      msg.RatchetIndex,
+				RatchetKey: msg.RatchetKey,
+				Epoch:      msg.KeyEpoch,
+			})
 		}
 
-		decryptedMsg, err := h.ChatService.DecryptMessage(msg.EncryptedMessage, msg.Algorithm, msg.Mode, msg.Padding, decryptionKey)
-		if err != nil {
-			log.Println("Failed to decrypt message:", err)
-			return
-		}
 		userUUID, err := uuid.Parse(msg.SenderId)
 		if err != nil {
 			log.Println("Invalid user_id format:", err)
 			return
 		}
 
+		verifyErr := verifier.Verify(client.MessageEnvelope(msg), msg.Signature)
+		if verifyErr != nil {
+			log.Printf("Unverified %s message from %s: %v", msg.MessageType, msg.SenderId, verifyErr)
+		}
+
+		if msg.MessageType == "file" {
+			fileKey := msg.SenderId + "/" + msg.FileName
+			file := incomingFiles[fileKey]
+			if file == nil {
+				senderName, err := h.ChatService.GetUsernameByID(r.Context(), userUUID)
+				if err != nil {
+					log.Println("Failed to get sender name:", err)
+					return
+				}
+
+				var pr *io.PipeReader
+				file = &incomingFile{}
+				pr, file.pw = io.Pipe()
+				incomingFiles[fileKey] = file
+				decryptionKey, keyErr := messageKey()
+				go h.relayDecryptedFile(pr, writeJSON, msg, userUUID, senderName, decryptionKey, keyErr, &file.verification)
+			}
+			file.verification.add(verifyErr)
+
+			if _, err := file.pw.Write(msg.EncryptedMessage); err != nil {
+				log.Println("Failed to pass file chunk to decryptor:", err)
+				delete(incomingFiles, fileKey)
+				continue
+			}
+			if msg.ChunkIndex == msg.TotalChunks-1 {
+				file.pw.Close()
+				delete(incomingFiles, fileKey)
+			}
+			continue
+		}
+
 		senderName, err := h.ChatService.GetUsernameByID(r.Context(), userUUID)
 		if err != nil {
 			log.Println("Failed to get sender name:", err)
 			return
 		}
 
-		if msg.MessageType == "file" {
-			decryptedFile, err := h.ChatService.DecryptMessage(msg.EncryptedMessage, msg.Algorithm, msg.Mode, msg.Padding, decryptionKey)
-			if err != nil {
-				log.Println("Failed to decrypt file:", err)
+		aad := services.MessageAAD(msg.ChatId, msg.SenderId)
+		var decryptedMsg []byte
+		decryptionKey, err := messageKey()
+		if err == nil {
+			decryptedMsg, err = h.ChatService.DecryptMessage(msg.EncryptedMessage, msg.Algorithm, msg.Mode, msg.Padding, decryptionKey, aad)
+		}
+		if err != nil {
+			logDecryptFailure("text", msg.SenderId, err)
+			if err := writeJSON(withVerification(undecryptableMessage(msg.SenderId, senderName, time.Now().Format(time.RFC3339), "text", ""), verifyErr)); err != nil {
+				log.Println("Failed to send message via WebSocket:", err)
 				return
 			}
+			continue
+		}
 
-			log.Printf("Decrypted file size: %d bytes", len(decryptedFile))
+		response := map[string]interface{}{
+			"sender_id":    userUUID,
+			"sender_name":  senderName,
+			"message":      string(decryptedMsg),
+			"created_at":   time.Now().Format(time.RFC3339),
+			"message_type": "text",
+		}
 
-			base64Data := base64.StdEncoding.EncodeToString(decryptedFile)
+		if err := writeJSON(withVerification(response, verifyErr)); err != nil {
+			log.Println("Failed to send message via WebSocket:", err)
+			return
+		}
+	}
+}
 
-			response := map[string]interface{}{
-				"sender_id":    userUUID,
-				"sender_name":  senderName,
-				"message":      base64Data,
-				"created_at":   time.Now().Format(time.RFC3339),
-				"message_type": "file",
-				"file_name":    msg.FileName,
-				"is_base64":    true,
-			}
+// newMessageSigner продолжает нумерацию сообщений пользователя в чате; подписывает их identity
+func (h *ChatHandlers) newMessageSigner(ctx context.Context, identity client.Signer, chatID uuid.UUID, userIDStr string) (*client.MessageSigner, error) {
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID: %w", err)
+	}
+	lastSequence, err := h.ChatRepo.LastSequence(ctx, chatID, userID)
+	if err != nil {
+		return nil, err
+	}
+	return client.NewMessageSigner(identity, lastSequence), nil
+}
 
-			if err := conn.WriteJSON(response); err != nil {
-				log.Println("Failed to send file via WebSocket:", err)
-				return
-			}
-		} else {
-			response := map[string]interface{}{
-				"sender_id":    userUUID,
-				"sender_name":  senderName,
-				"message":      string(decryptedMsg),
-				"created_at":   time.Now().Format(time.RFC3339),
-				"message_type": "text",
-			}
+// currentEpochKey возвращает ключ текущей эпохи чата для сообщений без ключа отправителя и номер эпохи
+func (h *ChatHandlers) currentEpochKey(ctx context.Context, chatID uuid.UUID, keys *client.GroupKeys) ([]byte, uint32, error) {
+	chat, err := h.ChatService.CurrentKeyEpoch(ctx, chatID)
+	if err != nil {
+		return nil, 0, err
+	}
+	key, err := epochKey(chat, keys, uint32(chat.KeyEpoch))
+	if err != nil {
+		return nil, 0, err
+	}
+	return key, uint32(chat.KeyEpoch), nil
+}
 
-			if err := conn.WriteJSON(response); err != nil {
-				log.Println("Failed to send message via WebSocket:", err)
-				return
+// epochKey выводит ключ эпохи для сообщений без ключа отправителя; случайный секрет эпохи
+// выдаёт обмен ключами, обернув его для ключа подключения или предварительного ключа из keys
+func epochKey(chat *models.Chat, keys *client.GroupKeys, epoch uint32) ([]byte, error) {
+	var secret []byte
+	if services.HasEpochSecret(chat, int(epoch)) {
+		if keys == nil {
+			return nil, errors.New("no key to receive the epoch secret")
+		}
+		var err error
+		if secret, err = keys.EpochSecret(epoch); err != nil {
+			return nil, err
+		}
+	}
+	return services.ChatEpochKey(chat, int(epoch), secret)
+}
+
+// relayDecryptedFile расшифровывает файл по мере поступления частей и отправляет его
+// в WebSocket кусками по fileRelayChunkSize; последний кусок помечается "final".
+// В режиме GCM первый кусок уходит только после проверки тега всего файла.
+func (h *ChatHandlers) relayDecryptedFile(encrypted *io.PipeReader, writeJSON func(interface{}) error, msg *protopb.Message, senderID uuid.UUID, senderName string, key []byte, keyErr error, verification *chunkVerification) {
+	fail := func(err error) {
+		encrypted.CloseWithError(err)
+		logDecryptFailure("file", senderID.String(), err)
+		writeJSON(withVerification(undecryptableMessage(senderID.String(), senderName, time.Now().Format(time.RFC3339), "file", msg.FileName), verification.result()))
+	}
+	if keyErr != nil {
+		fail(keyErr)
+		return
+	}
+
+	decrypted, err := h.ChatService.NewFileDecryptor(encrypted, msg.Algorithm, msg.Mode, msg.Padding, key, services.MessageAAD(msg.ChatId, msg.SenderId))
+	if err != nil {
+		fail(err)
+		return
+	}
+
+	buf := make([]byte, fileRelayChunkSize)
+	total := 0
+	for part := 0; ; part++ {
+		n, err := io.ReadFull(decrypted, buf)
+		final := err == io.EOF || err == io.ErrUnexpectedEOF
+		if err != nil && !final {
+			fail(err)
+			return
+		}
+		total += n
+
+		response := map[string]interface{}{
+			"sender_id":    senderID,
+			"sender_name":  senderName,
+			"message":      base64.StdEncoding.EncodeToString(buf[:n]),
+			"created_at":   time.Now().Format(time.RFC3339),
+			"message_type": "file",
+			"file_name":    msg.FileName,
+			"is_base64":    true,
+			"part":         part,
+			"final":        final,
+		}
+		// все части файла проверены к моменту, когда расшифрована последняя
+		if final {
+			response = withVerification(response, verification.result())
+		}
+		if err := writeJSON(response); err != nil {
+			encrypted.CloseWithError(err)
+			log.Println("Failed to send file via WebSocket:", err)
+			return
+		}
+
+		if final {
+			log.Printf("Decrypted file size: %d bytes", total)
+			return
+		}
+	}
+}
+
+// historyGroupKeys готовит чтение копий цепочек для истории, обёрнутых по подписанным
+// предварительным ключам читателя; закрытого ключа подключения у истории нет
+func (h *ChatHandlers) historyGroupKeys(chat *models.Chat, chatID, readerID string) *client.GroupKeys {
+	prekeys, err := client.OpenPrekeyStore(config.GetEnv("PREKEY_DIR", "keys/prekeys"), readerID)
+	if err != nil {
+		log.Println("Failed to open prekey store:", err)
+		return nil
+	}
+	return h.readerGroupKeys(chat, chatID, readerID, prekeys)
+}
+
+// readerGroupKeys — ключи читателя без ключа подключения: ему доступны только цепочки
+// и секреты эпох, обёрнутые по его предварительным ключам
+func (h *ChatHandlers) readerGroupKeys(chat *models.Chat, chatID, readerID string, prekeys *client.PrekeyStore) *client.GroupKeys {
+	agreement, err := services.ChatKeyAgreement(chat)
+	if err != nil {
+		log.Println("Failed to get key agreement of chat:", err)
+		return nil
+	}
+	kdf, err := services.ChatKeyDerivation(chat)
+	if err != nil {
+		log.Println("Failed to prepare key derivation:", err)
+		return nil
+	}
+	groupKeys := h.KeyExchange.NewGroupKeys(chatID, readerID, "", chat.Algorithm, agreement, nil, kdf)
+	groupKeys.UsePrekeys(prekeys)
+	groupKeys.UseIdentity(nil, h.Users)
+	return groupKeys
+}
+
+// keyChangeWarnings помнит, о каких новых ключах собеседников подключение уже предупредило
+type keyChangeWarnings struct {
+	keys   *client.KeyExchangeClient
+	userID string
+	chatID string
+
+	mu     sync.Mutex
+	warned map[string]string // собеседник -> ключ
+}
+
+// warnKeyChanges отправляет в WebSocket предупреждение о каждом проверенном собеседнике,
+// ключ личности которого с тех пор сменился
+func (h *ChatHandlers) warnKeyChanges(ctx context.Context, warnings *keyChangeWarnings, writeJSON func(interface{}) error) {
+	peers, err := warnings.keys.GetPeerVerification(warnings.userID, warnings.chatID)
+	if err != nil {
+		log.Println("Failed to check peer identity keys:", err)
+		return
+	}
+	for _, peer := range peers {
+		if !peer.KeyChanged {
+			continue
+		}
+		warnings.mu.Lock()
+		seen := warnings.warned[peer.PeerId] == peer.PublicKey
+		warnings.warned[peer.PeerId] = peer.PublicKey
+		warnings.mu.Unlock()
+		if seen {
+			continue
+		}
+
+		peerName := peer.PeerId
+		if peerID, err := uuid.Parse(peer.PeerId); err == nil {
+			if name, err := h.ChatService.GetUsernameByID(ctx, peerID); err == nil {
+				peerName = name
 			}
 		}
+		log.Printf("[IDENTITY] Проверенный ключ %s сменился; предупреждаем %s", peer.PeerId, warnings.userID)
+		if err := writeJSON(map[string]interface{}{
+			"type":      "key_change_warning",
+			"peer_id":   peer.PeerId,
+			"peer_name": peerName,
+			"message":   fmt.Sprintf("identity key of %s has changed since you verified it; compare safety numbers again", peerName),
+		}); err != nil {
+			log.Println("Failed to send key change warning:", err)
+		}
+	}
+}
+
+// withVerification помечает сообщение для браузера: "verified" — подпись отправителя проверена,
+// иначе "verification_error" объясняет почему нет
+func withVerification(response map[string]interface{}, verifyErr error) map[string]interface{} {
+	response["verified"] = verifyErr == nil
+	if verifyErr != nil {
+		response["verification_error"] = verificationErrorText(verifyErr)
+	}
+	return response
+}
+
+func verificationErrorText(err error) string {
+	switch {
+	case errors.Is(err, client.ErrUnsigned):
+		return "message is not signed"
+	case errors.Is(err, client.ErrReplayedMessage):
+		return "message was replayed"
+	case errors.Is(err, client.ErrUnknownIdentity):
+		return "sender has no identity key"
 	}
+	return "signature is invalid"
+}
+
+// decryptFailedText — единственный ответ клиенту на любую ошибку расшифровки
+const decryptFailedText = "message could not be decrypted"
+
+// undecryptableMessage заменяет сообщение, которое не удалось расшифровать. Текст, файл и
+// история получают один и тот же ответ, чтобы по нему нельзя было отличить причину ошибки.
+func undecryptableMessage(senderID, senderName, createdAt, messageType, fileName string) map[string]interface{} {
+	return map[string]interface{}{
+		"sender_id":    senderID,
+		"sender_name":  senderName,
+		"created_at":   createdAt,
+		"message_type": messageType,
+		"file_name":    fileName,
+		"error":        decryptFailedText,
+	}
+}
+
+// logDecryptFailure не пишет подробностей для algos.ErrDecrypt: причина намеренно скрыта
+func logDecryptFailure(messageType, senderID string, err error) {
+	if errors.Is(err, algos.ErrDecrypt) {
+		log.Printf("Failed to decrypt %s message from %s", messageType, senderID)
+		return
+	}
+	log.Printf("Failed to decrypt %s message from %s: %v", messageType, senderID, err)
 }
 
 func (h *ChatHandlers) GetMessagesHandler(w http.ResponseWriter, r *http.Request) {
@@ -514,43 +1040,119 @@ func (h *ChatHandlers) GetMessagesHandler(w http.ResponseWriter, r *http.Request
 		sendJSONError(w, "Failed to get chat info", http.StatusInternalServerError)
 		return
 	}
+	if chat.E2E {
+		writeEnvelopeHistory(w, resp.Messages)
+		return
+	}
+
+	verifier := h.Users.NewMessageVerifier()
+
+	// цепочки отправителей читатель расшифрует по копиям для истории, обёрнутым по его
+	// подписанным предварительным ключам; ключи подключений истории недоступны
+	var groupKeys *client.GroupKeys
+	if readerID := r.Header.Get("X-User-ID"); readerID != "" {
+		groupKeys = h.historyGroupKeys(chat, chatID, readerID)
+	}
+	failedChains := make(map[string]error)
 
 	var messages []map[string]interface{}
+	// части файла лежат отдельными записями и собираются по отправителю и имени файла
+	fileParts := make(map[string][]io.Reader)
+	fileVerification := make(map[string]error)
 	for _, msg := range resp.Messages {
-		// используем nil для customKey, так как для исторических сообщений используем стандартный ключ
-		decryptedMsg, err := h.ChatService.DecryptMessage(msg.EncryptedMessage, chat.Algorithm, chat.Mode, chat.Padding, nil)
-		if err != nil {
-			log.Println("Failed to decrypt message:", err)
+		aad := services.MessageAAD(chatID, msg.SenderId)
+		// стандартный ключ для сообщений с ключом отправителя дал бы в режимах без аутентификации
+		// мусор вместо ошибки
+		messageKey := func() ([]byte, error) {
+			if msg.SenderKeyId == "" {
+				return epochKey(chat, groupKeys, msg.KeyEpoch)
+			}
+			if groupKeys == nil {
+				return nil, algos.ErrMessageKeyUnavailable
+			}
+			chainID := msg.SenderId + "/" + msg.SenderKeyId
+			if err, failed := failedChains[chainID]; failed {
+				return nil, err
+			}
+			key, err := groupKeys.MessageKey(msg.SenderId, client.RatchetHeader{
+				KeyID:      msg.SenderKeyId,
+				Index:      msg.RatchetIndex,
+				RatchetKey: msg.RatchetKey,
+				Epoch:      msg.KeyEpoch,
+			})
+			if err != nil && !errors.Is(err, algos.ErrMessageKeyUnavailable) {
+				failedChains[chainID] = err
+			}
+			return key, err
+		}
+
+		verifyErr := verifier.Verify(client.RecordEnvelope(chatID, msg), msg.Signature)
+		if msg.MessageType == "file" {
+			fileKey := msg.SenderId + "/" + msg.FileName
+			if prev, ok := fileVerification[fileKey]; ok && prev != nil {
+				verifyErr = prev
+			}
+			fileVerification[fileKey] = verifyErr
+			if msg.ChunkIndex == msg.TotalChunks-1 {
+				delete(fileVerification, fileKey)
+			}
+		}
+
+		if msg.MessageType == "file" {
+			fileKey := msg.SenderId + "/" + msg.FileName
+			fileParts[fileKey] = append(fileParts[fileKey], bytes.NewReader(msg.EncryptedMessage))
+			if msg.ChunkIndex != msg.TotalChunks-1 {
+				continue
+			}
+			parts := fileParts[fileKey]
+			delete(fileParts, fileKey)
+
+			var fileData []byte
+			key, err := messageKey()
+			var decryptor io.Reader
+			if err == nil {
+				decryptor, err = h.ChatService.NewFileDecryptor(io.MultiReader(parts...), chat.Algorithm, chat.Mode, chat.Padding, key, aad)
+			}
+			if err == nil {
+				fileData, err = io.ReadAll(decryptor)
+			}
+			if err != nil {
+				logDecryptFailure("file", msg.SenderId, err)
+				messages = append(messages, withVerification(undecryptableMessage(msg.SenderId, msg.SenderName, msg.CreatedAt, "file", msg.FileName), verifyErr))
+				continue
+			}
+
+			messages = append(messages, withVerification(map[string]interface{}{
+				"sender_id":    msg.SenderId,
+				"sender_name":  msg.SenderName,
+				"created_at":   msg.CreatedAt,
+				"message_type": "file",
+				"file_name":    msg.FileName,
+				"message":      base64.StdEncoding.EncodeToString(fileData),
+				"is_base64":    true,
+			}, verifyErr))
 			continue
 		}
 
-		dbMessages, err := h.ChatRepo.GetMessages(chatUUID)
+		// сообщения без ключа отправителя расшифровываются ключом эпохи, в которую они отправлены
+		key, err := messageKey()
+		var decryptedMsg []byte
+		if err == nil {
+			decryptedMsg, err = h.ChatService.DecryptMessage(msg.EncryptedMessage, chat.Algorithm, chat.Mode, chat.Padding, key, aad)
+		}
 		if err != nil {
-			log.Println("Failed to fetch messages from database:", err)
+			logDecryptFailure("text", msg.SenderId, err)
+			messages = append(messages, withVerification(undecryptableMessage(msg.SenderId, msg.SenderName, msg.CreatedAt, "text", ""), verifyErr))
+			continue
 		}
 
-		messageData := map[string]interface{}{
+		messages = append(messages, withVerification(map[string]interface{}{
 			"sender_id":    msg.SenderId,
 			"sender_name":  msg.SenderName,
 			"created_at":   msg.CreatedAt,
 			"message_type": "text",
 			"text":         string(decryptedMsg),
-		}
-
-		for _, dbMsg := range dbMessages {
-			if dbMsg.MessageType == "file" && dbMsg.SenderID.String() == msg.SenderId &&
-				dbMsg.CreatedAt.UTC().Format(time.RFC3339) == msg.CreatedAt {
-				messageData["message_type"] = "file"
-				messageData["file_name"] = dbMsg.FileName
-				base64Data := base64.StdEncoding.EncodeToString(decryptedMsg)
-				messageData["message"] = base64Data
-				messageData["is_base64"] = true
-				delete(messageData, "text")
-				break
-			}
-		}
-
-		messages = append(messages, messageData)
+		}, verifyErr))
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -563,7 +1165,11 @@ func (h *ChatHandlers) ExchangeKeyHandler(w http.ResponseWriter, r *http.Request
 	var req struct {
 		ChatID    string `json:"chat_id"`
 		ClientID  string `json:"client_id"`
+		DeviceID  string `json:"device_id"`
+		KeyID     string `json:"key_id"`
 		PublicKey string `json:"public_key"`
+		// подпись ключом личности: без неё участники не станут оборачивать ключи для этого ключа
+		Signature []byte `json:"signature"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -576,9 +1182,42 @@ func (h *ChatHandlers) ExchangeKeyHandler(w http.ResponseWriter, r *http.Request
 		sendJSONError(w, "Invalid client ID", http.StatusBadRequest)
 		return
 	}
+	// ключ публикует только сам пользователь
+	if callerID, err := h.AuthService.Authenticate(bearerToken(r)); err != nil || callerID != userID {
+		sendJSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
 
-	err = h.ChatRepo.SavePublicKey(r.Context(), userID, req.PublicKey)
+	chatID, err := uuid.Parse(req.ChatID)
 	if err != nil {
+		sendJSONError(w, "Invalid chat ID", http.StatusBadRequest)
+		return
+	}
+
+	chat, err := h.ChatRepo.GetChatByID(chatID)
+	if err != nil {
+		sendJSONError(w, "Chat not found", http.StatusNotFound)
+		return
+	}
+
+	if err := services.ValidatePublicKey(chat, req.PublicKey); err != nil {
+		log.Printf("Rejected public key of user %s: %v", userID, err)
+		sendJSONError(w, "Invalid public key", http.StatusBadRequest)
+		return
+	}
+
+	key, err := services.NewSessionKey(chatID, userID, req.DeviceID, req.KeyID, req.PublicKey)
+	if err != nil {
+		sendJSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	key.Signature = req.Signature
+	if ok, err := h.ChatRepo.IsParticipant(r.Context(), chatID, userID); err != nil || !ok {
+		sendJSONError(w, "User is not a participant of the chat", http.StatusForbidden)
+		return
+	}
+
+	if err := h.ChatRepo.SavePublicKey(r.Context(), key); err != nil {
 		log.Println("Failed to save public key:", err)
 		sendJSONError(w, "Failed to save public key", http.StatusInternalServerError)
 		return
@@ -587,6 +1226,7 @@ func (h *ChatHandlers) ExchangeKeyHandler(w http.ResponseWriter, r *http.Request
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"success": true,
+		"key_id":  key.KeyID.String(),
 	})
 }
 
@@ -617,13 +1257,85 @@ func (h *ChatHandlers) GetPeerKeysHandler(w http.ResponseWriter, r *http.Request
 	response.Success = true
 	response.PublicKeys = make([]map[string]string, 0, len(publicKeys))
 
-	for userID, publicKey := range publicKeys {
+	for _, key := range publicKeys {
 		response.PublicKeys = append(response.PublicKeys, map[string]string{
-			"client_id":  userID.String(),
-			"public_key": publicKey,
+			"client_id":  key.UserID.String(),
+			"device_id":  key.DeviceID,
+			"key_id":     key.KeyID.String(),
+			"public_key": key.PublicKey,
 		})
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 }
+
+// SafetyNumberHandler возвращает код безопасности текущего пользователя (владельца токена)
+// и собеседника peer_id
+func (h *ChatHandlers) SafetyNumberHandler(w http.ResponseWriter, r *http.Request) {
+	token := bearerToken(r)
+	callerID, err := h.AuthService.Authenticate(token)
+	if err != nil {
+		sendJSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	userID := callerID.String()
+	peerID := r.URL.Query().Get("peer_id")
+	if peerID == "" {
+		sendJSONError(w, "Peer ID is required", http.StatusBadRequest)
+		return
+	}
+
+	resp, err := h.KeyExchange.WithToken(token).GetSafetyNumber(userID, peerID)
+	if err != nil {
+		log.Printf("Failed to get safety number of %s and %s: %v", userID, peerID, err)
+		sendJSONError(w, "Failed to get safety number", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":       true,
+		"safety_number": resp.SafetyNumber,
+		"qr_payload":    base64.StdEncoding.EncodeToString(resp.QrPayload),
+		"peer_key":      resp.PeerKey,
+		"verified":      resp.Verified,
+		"key_changed":   resp.KeyChanged,
+	})
+}
+
+// VerifyPeerHandler отмечает ключ собеседника проверенным после сверки кода безопасности;
+// отметка ставится от имени владельца токена, иначе её снял бы кто угодно
+func (h *ChatHandlers) VerifyPeerHandler(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		PeerID    string `json:"peer_id"`
+		PublicKey string `json:"public_key"`
+		Verified  bool   `json:"verified"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendJSONError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	token := bearerToken(r)
+	callerID, err := h.AuthService.Authenticate(token)
+	if err != nil {
+		sendJSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	userID := callerID.String()
+	if req.PeerID == "" {
+		sendJSONError(w, "Missing required fields", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.KeyExchange.WithToken(token).SetPeerVerified(userID, req.PeerID, req.PublicKey, req.Verified); err != nil {
+		log.Printf("Failed to verify peer %s for %s: %v", req.PeerID, userID, err)
+		sendJSONError(w, "Failed to verify peer", http.StatusConflict)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+	})
+}
@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"sync"
+
+	"Kygram/proto/protopb"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// envelopeFrame — сообщение чата со сквозным шифрованием в WebSocket: конверт protobuf в виде
+// JSON, который браузер шифрует и расшифровывает сам (WASM)
+type envelopeFrame struct {
+	Type       string          `json:"type"`
+	SenderName string          `json:"sender_name,omitempty"`
+	Message    json.RawMessage `json:"message"`
+}
+
+// relayEnvelopes пересылает конверты чата со сквозным шифрованием между WebSocket и потоком
+// gRPC. Ключей чата у сервера нет: он проверяет только, от чьего имени и в какой чат отправлен
+// конверт, и что тот зашифрован ключом отправителя. keyID — ключ, который браузер опубликовал
+// для этого соединения; отзывается он с токеном пользователя, поэтому только свой.
+func (h *ChatHandlers) relayEnvelopes(r *http.Request, conn *websocket.Conn, token, chatIDStr, userIDStr, deviceID, keyID string) {
+	keyExchangeClient := h.KeyExchange.WithToken(token)
+	// с обрывом соединения ключ отзывается, как и в обычных чатах, и отправители переходят
+	// на предварительные ключи; переподключаясь, браузер открывает сеанс с новым ключом
+	defer func() {
+		if _, err := keyExchangeClient.RevokePublicKeys(chatIDStr, userIDStr, deviceID, keyID); err != nil {
+			log.Println("Failed to revoke public keys:", err)
+		}
+	}()
+
+	stream, err := h.GrpcClient.StreamMessages(r.Context())
+	if err != nil {
+		log.Println("Failed to create gRPC stream:", err)
+		return
+	}
+	defer stream.CloseSend()
+
+	if err := stream.Send(&protopb.Message{
+		ChatId:   chatIDStr,
+		SenderId: userIDStr,
+	}); err != nil {
+		log.Println("Failed to send initial message:", err)
+		return
+	}
+
+	var wsMu sync.Mutex
+	writeJSON := func(v interface{}) error {
+		wsMu.Lock()
+		defer wsMu.Unlock()
+		return conn.WriteJSON(v)
+	}
+
+	// предупреждения о смене проверенных ключей не требуют ключей чата
+	warnings := &keyChangeWarnings{keys: keyExchangeClient, userID: userIDStr, chatID: chatIDStr, warned: make(map[string]string)}
+	h.warnKeyChanges(r.Context(), warnings, writeJSON)
+
+	go func() {
+		defer stream.CloseSend()
+		for {
+			_, data, err := conn.ReadMessage()
+			if err != nil {
+				log.Println("WebSocket read error:", err)
+				return
+			}
+			var frame envelopeFrame
+			if err := json.Unmarshal(data, &frame); err != nil || frame.Type != "envelope" {
+				log.Println("Invalid envelope frame from", userIDStr)
+				continue
+			}
+			var msg protopb.Message
+			if err := protojson.Unmarshal(frame.Message, &msg); err != nil {
+				log.Println("Invalid envelope:", err)
+				continue
+			}
+			if msg.ChatId != chatIDStr || msg.SenderId != userIDStr {
+				log.Printf("[E2E] Отклонён конверт %s в чат %s от имени %s", userIDStr, msg.ChatId, msg.SenderId)
+				continue
+			}
+			if msg.SenderKeyId == "" {
+				log.Printf("[E2E] Отклонён конверт %s без ключа отправителя", userIDStr)
+				continue
+			}
+			if err := stream.Send(&msg); err != nil {
+				log.Println("Failed to send envelope via gRPC:", err)
+				return
+			}
+		}
+	}()
+
+	for {
+		msg, err := stream.Recv()
+		if err != nil {
+			log.Println("Failed to receive message from gRPC stream:", err)
+			return
+		}
+		senderName := msg.SenderId
+		if senderID, err := uuid.Parse(msg.SenderId); err == nil {
+			if name, err := h.ChatService.GetUsernameByID(r.Context(), senderID); err == nil {
+				senderName = name
+			}
+		}
+		data, err := protojson.Marshal(msg)
+		if err != nil {
+			log.Println("Failed to encode envelope:", err)
+			continue
+		}
+		if err := writeJSON(envelopeFrame{Type: "envelope", SenderName: senderName, Message: data}); err != nil {
+			log.Println("Failed to send envelope via WebSocket:", err)
+			return
+		}
+	}
+}
+
+// writeEnvelopeHistory отдаёт историю чата со сквозным шифрованием как есть: расшифровывает её браузер
+func writeEnvelopeHistory(w http.ResponseWriter, records []*protopb.MessageRecord) {
+	messages := make([]json.RawMessage, 0, len(records))
+	for _, rec := range records {
+		data, err := protojson.Marshal(rec)
+		if err != nil {
+			sendJSONError(w, "Failed to encode messages", http.StatusInternalServerError)
+			return
+		}
+		messages = append(messages, data)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"e2e":      true,
+		"messages": messages,
+	})
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"Kygram/client"
+	"Kygram/proto/protopb"
+	"Kygram/services"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// rpcMaxBody ограничивает тело запроса к мосту: самые большие запросы — пачки предварительных ключей
+const rpcMaxBody = 1 << 20
+
+// rpcMethods — вызовы, которые нужны браузеру, чтобы самому согласовывать ключи и шифровать
+// сообщения чатов со сквозным шифрованием; остальные методы gRPC через мост недоступны
+var rpcMethods = map[string]bool{
+	protopb.ClientService_Connect_FullMethodName:                  true,
+	protopb.ClientService_GetChatHistory_FullMethodName:           true,
+	protopb.UserService_RegisterIdentityKey_FullMethodName:        true,
+	protopb.UserService_GetIdentityKey_FullMethodName:             true,
+	protopb.KeyExchangeService_SendPublicKey_FullMethodName:       true,
+	protopb.KeyExchangeService_ExchangeKeys_FullMethodName:        true,
+	protopb.KeyExchangeService_RevokePublicKeys_FullMethodName:    true,
+	protopb.KeyExchangeService_DistributeSenderKey_FullMethodName: true,
+	protopb.KeyExchangeService_GetSenderKey_FullMethodName:        true,
+	protopb.KeyExchangeService_UploadPrekeys_FullMethodName:       true,
+	protopb.KeyExchangeService_GetPrekeyStatus_FullMethodName:     true,
+	protopb.KeyExchangeService_FetchPrekeyBundle_FullMethodName:   true,
+}
+
+// rawCodec передаёт сообщения protobuf в gRPC и обратно без разбора: мост не знает их типов
+type rawCodec struct{}
+
+func (rawCodec) Marshal(v any) ([]byte, error) {
+	data, ok := v.(*[]byte)
+	if !ok {
+		return nil, fmt.Errorf("raw codec: unexpected type %T", v)
+	}
+	return *data, nil
+}
+
+func (rawCodec) Unmarshal(data []byte, v any) error {
+	out, ok := v.(*[]byte)
+	if !ok {
+		return fmt.Errorf("raw codec: unexpected type %T", v)
+	}
+	*out = append((*out)[:0], data...)
+	return nil
+}
+
+func (rawCodec) Name() string {
+	return "proto"
+}
+
+// RPCHandler — мост для client.HTTPConn: POST /rpc/<сервис>/<метод> с сообщением protobuf
+// в теле передаётся в gRPC как есть, ответ возвращается так же, статус gRPC — в заголовках.
+// Вызовы без действительного токена из Login мост отклоняет.
+type RPCHandler struct {
+	conn grpc.ClientConnInterface
+	auth *services.AuthService
+}
+
+func NewRPCHandler(conn grpc.ClientConnInterface, auth *services.AuthService) *RPCHandler {
+	return &RPCHandler{conn: conn, auth: auth}
+}
+
+func (h *RPCHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	method := strings.TrimPrefix(r.URL.Path, "/rpc")
+	if !rpcMethods[method] {
+		http.Error(w, "Unknown method", http.StatusNotFound)
+		return
+	}
+	if _, err := h.auth.Authenticate(bearerToken(r)); err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, rpcMaxBody))
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// от чьего имени сделан вызов, сервисы сверяют с тем же токеном
+	ctx := metadata.AppendToOutgoingContext(r.Context(), "authorization", r.Header.Get("Authorization"))
+
+	var reply []byte
+	if err := h.conn.Invoke(ctx, method, &body, &reply, grpc.ForceCodec(rawCodec{})); err != nil {
+		st := status.Convert(err)
+		log.Printf("RPC %s failed: %v", method, st.Message())
+		w.Header().Set("Grpc-Status", strconv.Itoa(int(st.Code())))
+		w.Header().Set("Grpc-Message", st.Message())
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	w.Header().Set("Content-Type", client.RPCContentType)
+	w.Header().Set("Grpc-Status", "0")
+	w.Write(reply)
+}
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"Kygram/algos"
+	"Kygram/client"
+)
+
+// signTimeout — сколько сервер ждёт подписи от браузера
+const signTimeout = 10 * time.Second
+
+var errSignTimeout = errors.New("browser did not sign in time")
+
+// signRequestFrame и signatureFrame — запрос подписи у браузера и его ответ в WebSocket
+type signRequestFrame struct {
+	Type      string `json:"type"`
+	RequestID uint64 `json:"request_id"`
+	Payload   []byte `json:"payload"`
+}
+
+type signatureFrame struct {
+	Type      string `json:"type"`
+	RequestID uint64 `json:"request_id"`
+	Signature []byte `json:"signature,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// browserSigner подписывает ключом личности пользователя в обычных чатах: закрытый ключ есть
+// только у браузера (client.SigningGuard в WASM), сервер пересылает ему данные и проверяет
+// подпись по зарегистрированному открытому ключу
+type browserSigner struct {
+	users     *client.UserClient
+	userID    string
+	writeJSON func(interface{}) error
+
+	mu        sync.Mutex
+	next      uint64
+	pending   map[uint64]chan signatureFrame
+	publicKey string
+	// браузер без WASM не ответит ни на один запрос: после первого молчания не ждём
+	silent bool
+}
+
+func newBrowserSigner(users *client.UserClient, userID string, writeJSON func(interface{}) error) *browserSigner {
+	return &browserSigner{users: users, userID: userID, writeJSON: writeJSON, pending: make(map[uint64]chan signatureFrame)}
+}
+
+func (s *browserSigner) Sign(payload []byte) ([]byte, error) {
+	reply := make(chan signatureFrame, 1)
+	s.mu.Lock()
+	if s.silent {
+		s.mu.Unlock()
+		return nil, errSignTimeout
+	}
+	s.next++
+	id := s.next
+	s.pending[id] = reply
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.pending, id)
+		s.mu.Unlock()
+	}()
+
+	if err := s.writeJSON(signRequestFrame{Type: "sign_request", RequestID: id, Payload: payload}); err != nil {
+		return nil, err
+	}
+	var frame signatureFrame
+	select {
+	case frame = <-reply:
+	case <-time.After(signTimeout):
+		s.mu.Lock()
+		s.silent = true
+		s.mu.Unlock()
+		return nil, errSignTimeout
+	}
+	if frame.Error != "" {
+		return nil, fmt.Errorf("browser refused to sign: %s", frame.Error)
+	}
+	if err := s.verify(payload, frame.Signature); err != nil {
+		return nil, err
+	}
+	return frame.Signature, nil
+}
+
+// verify проверяет подпись по ключу личности; браузер мог зарегистрировать ключ уже после
+// подключения, поэтому при несовпадении ключ запрашивается заново
+func (s *browserSigner) verify(payload, signature []byte) error {
+	s.mu.Lock()
+	publicKey := s.publicKey
+	s.mu.Unlock()
+	if publicKey != "" && algos.VerifySignature(publicKey, payload, signature) == nil {
+		return nil
+	}
+	fresh, err := s.users.GetIdentityKey(s.userID)
+	if err != nil {
+		return fmt.Errorf("%w: %v", client.ErrUnknownIdentity, err)
+	}
+	if err := algos.VerifySignature(fresh, payload, signature); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.publicKey = fresh
+	s.mu.Unlock()
+	return nil
+}
+
+// deliver передаёт ответ браузера ожидающему запросу; возвращает false, если кадр — не ответ
+// на запрос подписи
+func (s *browserSigner) deliver(data []byte) bool {
+	var frame signatureFrame
+	if err := json.Unmarshal(data, &frame); err != nil || frame.Type != "signature" {
+		return false
+	}
+	s.mu.Lock()
+	reply := s.pending[frame.RequestID]
+	s.mu.Unlock()
+	if reply != nil {
+		reply <- frame
+	}
+	return true
+}
@@ -3,14 +3,19 @@ package services
 import (
 	"context"
 	"errors"
+	"fmt"
+	"log"
+	"strings"
 	"time"
 
+	"Kygram/algos"
 	"Kygram/proto/protopb"
 	"Kygram/repository"
 
 	"github.com/golang-jwt/jwt"
-	//"github.com/google/uuid"
+	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
+	"google.golang.org/grpc/metadata"
 )
 
 type AuthService struct {
@@ -72,6 +77,25 @@ func (s *AuthService) Login(ctx context.Context, req *protopb.LoginRequest) (*pr
 	}, nil
 }
 
+// Authenticate проверяет токен, выданный Login, и возвращает ID его владельца
+func (s *AuthService) Authenticate(tokenString string) (uuid.UUID, error) {
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return s.jwtKey, nil
+	})
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("invalid token: %w", err)
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return uuid.Nil, errors.New("invalid token")
+	}
+	userID, _ := claims["user_id"].(string)
+	return uuid.Parse(userID)
+}
+
 func (s *AuthService) Logout(ctx context.Context, req *protopb.LogoutRequest) (*protopb.LogoutResponse, error) {
 	// добавить инвалидацию токена через редис
 	return &protopb.LogoutResponse{
@@ -79,3 +103,85 @@ func (s *AuthService) Logout(ctx context.Context, req *protopb.LogoutRequest) (*
 		Message: "Logout successful",
 	}, nil
 }
+
+// RegisterIdentityKey сохраняет долговременный ключ подписи пользователя. Подпись запроса
+// этим же ключом доказывает, что клиент владеет закрытым ключом. Ключ регистрирует только
+// сам пользователь, а прежний ключ заменяется лишь с его подписью: иначе любой, кто знает
+// ID пользователя, подменил бы его ключ своим.
+func (s *AuthService) RegisterIdentityKey(ctx context.Context, req *protopb.RegisterIdentityKeyRequest) (*protopb.RegisterIdentityKeyResponse, error) {
+	userID, err := uuid.Parse(req.UserId)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID: %w", err)
+	}
+	if err := s.Authorize(ctx, userID); err != nil {
+		return &protopb.RegisterIdentityKeyResponse{Success: false, Message: "Unauthorized"}, nil
+	}
+	if err := algos.VerifySignature(req.PublicKey, algos.IdentityRegistrationPayload(req.UserId, req.PublicKey), req.Signature); err != nil {
+		return &protopb.RegisterIdentityKeyResponse{Success: false, Message: err.Error()}, nil
+	}
+
+	previous, err := s.userRepo.FindIdentityKey(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if previous == req.PublicKey {
+		return &protopb.RegisterIdentityKeyResponse{Success: true, Message: "Identity key registered"}, nil
+	}
+	if previous != "" {
+		payload := algos.IdentityReplacementPayload(req.UserId, previous, req.PublicKey)
+		if err := algos.VerifySignature(previous, payload, req.PreviousSignature); err != nil {
+			return &protopb.RegisterIdentityKeyResponse{Success: false, Message: "identity key is already registered; replacing it requires a signature by the previous key"}, nil
+		}
+	}
+
+	if err := s.userRepo.SaveIdentityKey(ctx, userID, previous, req.PublicKey); err != nil {
+		if errors.Is(err, repository.ErrIdentityKeyChanged) {
+			return &protopb.RegisterIdentityKeyResponse{Success: false, Message: err.Error()}, nil
+		}
+		return nil, err
+	}
+	if previous != "" {
+		log.Printf("[IDENTITY] Пользователь %s сменил ключ подписи", userID)
+	}
+	return &protopb.RegisterIdentityKeyResponse{Success: true, Message: "Identity key registered"}, nil
+}
+
+// ErrUnauthorized — вызов без токена или с токеном другого пользователя
+var ErrUnauthorized = errors.New("unauthorized")
+
+// Authorize проверяет, что вызов gRPC сделан с токеном пользователя userID: иначе любой,
+// кто знает ID пользователя, менял бы его ключи
+func (s *AuthService) Authorize(ctx context.Context, userID uuid.UUID) error {
+	callerID, err := s.authenticateContext(ctx)
+	if err != nil || callerID != userID {
+		return ErrUnauthorized
+	}
+	return nil
+}
+
+// authenticateContext проверяет токен из метаданных authorization вызова gRPC
+func (s *AuthService) authenticateContext(ctx context.Context) (uuid.UUID, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, value := range md.Get("authorization") {
+		if token, ok := strings.CutPrefix(value, "Bearer "); ok {
+			return s.Authenticate(token)
+		}
+	}
+	return uuid.Nil, errors.New("missing token")
+}
+
+func (s *AuthService) GetIdentityKey(ctx context.Context, req *protopb.GetIdentityKeyRequest) (*protopb.GetIdentityKeyResponse, error) {
+	userID, err := uuid.Parse(req.UserId)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID: %w", err)
+	}
+	publicKey, createdAt, err := s.userRepo.GetIdentityKey(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &protopb.GetIdentityKeyResponse{
+		UserId:    req.UserId,
+		PublicKey: publicKey,
+		CreatedAt: createdAt.UTC().Format(time.RFC3339),
+	}, nil
+}
@@ -4,7 +4,12 @@ import (
 	"Kygram/config"
 	"Kygram/models"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
+	"strconv"
 	"sync"
 	"time"
 
@@ -18,6 +23,18 @@ import (
 	"github.com/google/uuid"
 )
 
+var ErrInvalidEncryptionParams = errors.New("invalid encryption parameters")
+
+// ErrEndToEnd — сервер отказывается шифровать или расшифровывать сообщения чата со сквозным
+// шифрованием: ключи таких чатов есть только у клиентов
+var ErrEndToEnd = errors.New("chat is end-to-end encrypted")
+
+const (
+	// KeyEpochMaxAge и KeyEpochMaxMessages ограничивают эпоху ключей чата по времени и числу сообщений
+	KeyEpochMaxAge      = 7 * 24 * time.Hour
+	KeyEpochMaxMessages = 1000
+)
+
 type ChatService struct {
 	protopb.UnimplementedChatServiceServer
 	userRepo *repository.UserRepository
@@ -72,11 +89,42 @@ func (s *ChatService) ListUsers(ctx context.Context, req *protopb.ListUsersReque
 }
 
 func (s *ChatService) CreateChat(ctx context.Context, req *protopb.CreateChatRequest) (*protopb.CreateChatResponse, error) {
+	if err := validateChatParams(req.Algorithm, req.Mode, req.Padding); err != nil {
+		return nil, err
+	}
+
+	keyAgreement := req.KeyAgreement
+	if keyAgreement == "" {
+		keyAgreement = algos.DefaultKeyAgreement
+	}
+	if _, ok := algos.LookupKeyAgreement(keyAgreement); !ok {
+		return nil, fmt.Errorf("%w: unsupported key agreement: %s", ErrInvalidEncryptionParams, keyAgreement)
+	}
+
+	// для X25519 группа не нужна, поля группы остаются пустыми
+	var groupName, prime, generator string
+	if keyAgreement == algos.KeyAgreementDH {
+		groupName = req.DhGroup
+		if groupName == "" {
+			groupName = algos.DefaultDHGroup
+		}
+		groupInfo, ok := algos.LookupDHGroup(groupName)
+		if !ok {
+			return nil, fmt.Errorf("%w: unsupported DH group: %s", ErrInvalidEncryptionParams, groupName)
+		}
+
+		group, err := groupInfo.New()
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate DH group: %w", err)
+		}
+		prime, generator = group.Prime.String(), group.Generator.String()
+	}
+
 	chatID := uuid.New()
 
-	prime, err := algos.GeneratePrime(2048)
+	salt, err := algos.NewKDFSalt()
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate prime number: %w", err)
+		return nil, fmt.Errorf("failed to generate KDF salt: %w", err)
 	}
 
 	creatorIDStr, ok := ctx.Value("user_id").(string)
@@ -100,7 +148,8 @@ func (s *ChatService) CreateChat(ctx context.Context, req *protopb.CreateChatReq
 
 	participants = append(participants, creatorID)
 
-	err = s.chatRepo.CreateChat(ctx, chatID, req.Name, req.Algorithm, req.Mode, req.Padding, prime.String(), participants)
+	err = s.chatRepo.CreateChat(ctx, chatID, req.Name, req.Algorithm, req.Mode, req.Padding,
+		keyAgreement, groupName, prime, generator, hex.EncodeToString(salt), req.E2E, participants)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create chat: %w", err)
 	}
@@ -146,6 +195,44 @@ func (s *ChatService) PublishMessage(chatID uuid.UUID, message []byte) error {
 	return nil
 }
 
+// AddParticipant добавляет участника и начинает новую эпоху ключей; его ключ попадёт в набор
+// получателей, и отправители сменят свои ключи при следующем сообщении
+func (s *ChatService) AddParticipant(ctx context.Context, req *protopb.ParticipantRequest) (*protopb.ParticipantResponse, error) {
+	chatID, userID, err := s.participantIDs(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.chatRepo.AddParticipant(ctx, chatID, userID); err != nil {
+		return nil, err
+	}
+	return &protopb.ParticipantResponse{Success: true}, nil
+}
+
+// RemoveParticipant исключает участника и начинает новую эпоху ключей; новые ключи отправителей
+// ему уже не раздаются
+func (s *ChatService) RemoveParticipant(ctx context.Context, req *protopb.ParticipantRequest) (*protopb.ParticipantResponse, error) {
+	chatID, userID, err := s.participantIDs(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.chatRepo.RemoveParticipant(ctx, chatID, userID); err != nil {
+		return nil, err
+	}
+	return &protopb.ParticipantResponse{Success: true}, nil
+}
+
+func (s *ChatService) participantIDs(ctx context.Context, req *protopb.ParticipantRequest) (uuid.UUID, uuid.UUID, error) {
+	chatID, err := uuid.Parse(req.ChatId)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, fmt.Errorf("invalid chat ID: %w", err)
+	}
+	userID, err := s.userRepo.GetUserIDByUsername(ctx, req.Username)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, fmt.Errorf("failed to get user ID for username %s: %w", req.Username, err)
+	}
+	return chatID, userID, nil
+}
+
 func (s *ChatService) CloseChat(ctx context.Context, req *protopb.CloseChatRequest) (*protopb.CloseChatResponse, error) {
 	chatID, err := uuid.Parse(req.ChatId)
 	if err != nil {
@@ -200,10 +287,22 @@ func (s *ChatService) SendMessage(ctx context.Context, req *protopb.SendMessageR
 		return nil, fmt.Errorf("chat does not exist")
 	}
 
-	chat, err := s.chatRepo.GetChatByID(chatID)
+	chat, err := currentKeyEpoch(ctx, s.chatRepo, chatID)
 	if err != nil {
 		return nil, fmt.Errorf("chat not found: %w", err)
 	}
+	// открытый текст сервер может зашифровать только ключом, который знает сам
+	if chat.E2E {
+		return nil, ErrEndToEnd
+	}
+	secret, err := EpochSecret(ctx, s.chatRepo, chat, chat.KeyEpoch)
+	if err != nil {
+		return nil, err
+	}
+	key, err := ChatEpochKey(chat, chat.KeyEpoch, secret)
+	if err != nil {
+		return nil, err
+	}
 
 	cipher, err := initCipher(chat.Algorithm)
 	if err != nil {
@@ -215,7 +314,7 @@ func (s *ChatService) SendMessage(ctx context.Context, req *protopb.SendMessageR
 		return nil, fmt.Errorf("invalid encryption params: %w", err)
 	}
 
-	encrypted, err := encryptMessage(cipher, mode, padding, []byte(req.Message))
+	encrypted, err := encryptMessage(cipher, mode, padding, key, []byte(req.Message), MessageAAD(req.ChatId, req.Sender))
 	if err != nil {
 		return nil, fmt.Errorf("encryption failed: %w", err)
 	}
@@ -230,11 +329,15 @@ func (s *ChatService) SendMessage(ctx context.Context, req *protopb.SendMessageR
 		FileName:         req.FileName,
 		ChunkIndex:       int(req.ChunkIndex),
 		TotalChunks:      int(req.TotalChunks),
+		KeyEpoch:         chat.KeyEpoch,
 	}
 
 	if err := s.chatRepo.SaveMessage(ctx, msg); err != nil {
 		return nil, fmt.Errorf("failed to save message: %w", err)
 	}
+	if err := s.chatRepo.CountEpochMessage(ctx, chatID, chat.KeyEpoch); err != nil {
+		log.Printf("Failed to count epoch message: %v", err)
+	}
 
 	if err := s.PublishMessage(chatID, encrypted); err != nil {
 		return nil, fmt.Errorf("failed to publish message: %w", err)
@@ -247,141 +350,337 @@ func (s *ChatService) SendMessage(ctx context.Context, req *protopb.SendMessageR
 }
 
 func initCipher(algorithm string) (algos.Cipher, error) {
-	switch algorithm {
-	case "twofish":
-		return algos.NewTwofish()
-	case "rc5":
-		return algos.NewRC5()
-	default:
-		return nil, fmt.Errorf("unsupported algorithm: %s", algorithm)
-	}
+	return algos.NewCipher(algorithm)
 }
 
 func validateEncryptionParams(modeStr, paddingStr string) (algos.EncryptionMode, algos.PaddingMode, error) {
-	modes := map[string]algos.EncryptionMode{
-		"ECB":         algos.ECB,
-		"CBC":         algos.CBC,
-		"PCBC":        algos.PCBC,
-		"CFB":         algos.CFB,
-		"OFB":         algos.OFB,
-		"CTR":         algos.CTR,
-		"RandomDelta": algos.RandomDelta,
-	}
-
-	paddings := map[string]algos.PaddingMode{
-		"Zeros":    algos.Zeros,
-		"ANSIX923": algos.ANSI_X_923,
-		"PKCS7":    algos.PKCS7,
-		"ISO10126": algos.ISO_10126,
-	}
-
-	mode, ok := modes[modeStr]
+	mode, ok := algos.LookupMode(modeStr)
 	if !ok {
 		return 0, 0, fmt.Errorf("unsupported mode: %s", modeStr)
 	}
 
-	padding, ok := paddings[paddingStr]
+	padding, ok := algos.LookupPadding(paddingStr)
 	if !ok {
 		return 0, 0, fmt.Errorf("unsupported padding: %s", paddingStr)
 	}
 
-	return mode, padding, nil
+	if !mode.AcceptsPadding(padding) {
+		return 0, 0, fmt.Errorf("mode %s doesn't support padding %s", modeStr, paddingStr)
+	}
+
+	return mode.Mode, padding.Padding, nil
 }
 
-func (s *ChatService) EncryptMessage(msg *protopb.Message, customKey []byte) ([]byte, error) {
-	cipher, err := initCipher(msg.Algorithm)
+// validateChatParams проверяет по реестру algos, что алгоритм, режим и набивка совместимы
+func validateChatParams(algorithm, modeStr, paddingStr string) error {
+	cipher, err := initCipher(algorithm)
 	if err != nil {
-		return nil, fmt.Errorf("cipher init failed: %w", err)
+		return fmt.Errorf("%w: %v", ErrInvalidEncryptionParams, err)
+	}
+
+	if _, _, err := validateEncryptionParams(modeStr, paddingStr); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidEncryptionParams, err)
+	}
+
+	mode, _ := algos.LookupMode(modeStr)
+	if !mode.SupportsBlockSize(cipher.BlockSize()) {
+		return fmt.Errorf("%w: mode %s does not support %d-byte blocks of %s",
+			ErrInvalidEncryptionParams, modeStr, cipher.BlockSize(), algorithm)
+	}
+
+	return nil
+}
+
+func (s *ChatService) ListAlgorithms(ctx context.Context, req *protopb.ListAlgorithmsRequest) (*protopb.ListAlgorithmsResponse, error) {
+	resp := &protopb.ListAlgorithmsResponse{}
+
+	for _, c := range algos.Ciphers() {
+		variants := make([]*protopb.AlgorithmVariant, len(c.Variants))
+		for i, v := range c.Variants {
+			variants[i] = &protopb.AlgorithmVariant{
+				Name:      v.Name,
+				BlockSize: int32(v.BlockSize),
+				KeySizes:  int32Sizes(v.KeySizes),
+			}
+		}
+		resp.Algorithms = append(resp.Algorithms, &protopb.AlgorithmInfo{
+			Name:        c.Name,
+			DisplayName: c.DisplayName,
+			BlockSize:   int32(c.BlockSize),
+			KeySizes:    int32Sizes(c.KeySizes),
+			Variants:    variants,
+		})
 	}
 
-	mode, padding, err := validateEncryptionParams(msg.Mode, msg.Padding)
+	for _, m := range algos.Modes() {
+		resp.Modes = append(resp.Modes, &protopb.ModeInfo{
+			Name:        m.Name,
+			DisplayName: m.DisplayName,
+			BlockSizes:  int32Sizes(m.BlockSizes),
+			Stream:      m.Stream,
+			Aead:        m.AEAD,
+		})
+	}
+
+	for _, p := range algos.Paddings() {
+		resp.Paddings = append(resp.Paddings, &protopb.PaddingInfo{
+			Name:        p.Name,
+			DisplayName: p.DisplayName,
+		})
+	}
+
+	for _, g := range algos.DHGroups() {
+		resp.DhGroups = append(resp.DhGroups, &protopb.DHGroupInfo{
+			Name:        g.Name,
+			DisplayName: g.DisplayName,
+			Bits:        int32(g.Bits),
+			Generated:   g.Generated,
+		})
+	}
+
+	for _, a := range algos.KeyAgreements() {
+		resp.KeyAgreements = append(resp.KeyAgreements, &protopb.KeyAgreementInfo{
+			Name:        a.Name,
+			DisplayName: a.DisplayName,
+		})
+	}
+
+	return resp, nil
+}
+
+func int32Sizes(sizes []int) []int32 {
+	result := make([]int32, len(sizes))
+	for i, size := range sizes {
+		result[i] = int32(size)
+	}
+	return result
+}
+
+func (s *ChatService) EncryptMessage(msg *protopb.Message, customKey []byte) ([]byte, error) {
+	cipher, mode, padding, err := parseEncryptionParams(msg.Algorithm, msg.Mode, msg.Padding)
 	if err != nil {
-		return nil, fmt.Errorf("invalid encryption params: %w", err)
+		return nil, err
 	}
 	if customKey != nil {
 		log.Printf("[ENCRYPTION] Шифрование сообщения с кастомным ключом длиной %d байт", len(customKey))
 	} else {
 		log.Printf("[ENCRYPTION] Шифрование сообщения со стандартным ключом")
 	}
-	key := customKey
-	if key == nil {
-		// для обратной совместимости используем старый фиксированный ключ
-		key = []byte("securekey12345678")
+
+	ctxEnc, err := newEncryptionContext(cipher, mode, padding, customKey)
+	if err != nil {
+		return nil, err
 	}
 
-	iv := []byte("iv1234567890abcd") //todo
+	return ctxEnc.EncryptWithAAD(msg.EncryptedMessage, MessageAAD(msg.ChatId, msg.SenderId))
+}
 
-	expander, ok := cipher.(algos.KeyExpander)
-	if !ok {
-		return nil, fmt.Errorf("cipher doesn't support key expansion")
+// ChatKeyAgreement возвращает алгоритм согласования ключей чата; чаты без него используют Диффи-Хеллмана
+func ChatKeyAgreement(chat *models.Chat) (algos.KeyAgreement, error) {
+	agreement, err := algos.NewKeyAgreement(chat.KeyAgreement, chat.DHGroup, chat.Prime, chat.Generator)
+	if err != nil {
+		return nil, fmt.Errorf("key agreement of chat %s: %w", chat.ChatID, err)
 	}
+	return agreement, nil
+}
 
-	ctxEnc := algos.NewEncryptionContext(
-		key,
-		mode,
-		padding,
-		iv,
-		cipher,
-		expander,
-	)
+// ChatKeyDerivation возвращает контекст вывода ключей чата: соль, идентификатор и длину ключа его шифра;
+// у чатов, созданных до появления соли, она пустая
+func ChatKeyDerivation(chat *models.Chat) (algos.KeyDerivation, error) {
+	salt, err := hex.DecodeString(chat.KDFSalt)
+	if err != nil {
+		return algos.KeyDerivation{}, fmt.Errorf("invalid KDF salt of chat %s: %w", chat.ChatID, err)
+	}
+	return algos.NewKeyDerivation(salt, chat.ChatID.String(), chat.Algorithm)
+}
 
-	return ctxEnc.Encrypt(msg.EncryptedMessage)
+// ChatEpochKey возвращает ключ чата для эпохи epoch, которым шифруются сообщения без ключа
+// отправителя; secret — случайный секрет эпохи (см. EpochSecret и GetEpochKey). Ключ выводится
+// из секрета по HKDF с солью чата и номером эпохи. Эпоха 0 — чаты и сообщения, созданные до
+// появления эпох: для них остаётся старый фиксированный ключ; эпохи до FirstSecretEpoch выводили
+// ключ из него же, и их сообщения по-прежнему читаются. Эти ключи известны серверу, поэтому
+// в чатах со сквозным шифрованием их нет.
+func ChatEpochKey(chat *models.Chat, epoch int, secret []byte) ([]byte, error) {
+	if chat.E2E {
+		return nil, ErrEndToEnd
+	}
+	if epoch == 0 {
+		cipher, err := initCipher(chat.Algorithm)
+		if err != nil {
+			return nil, err
+		}
+		return defaultKey(cipher), nil
+	}
+	if !HasEpochSecret(chat, epoch) {
+		secret = legacySecret()
+	} else if len(secret) == 0 {
+		return nil, fmt.Errorf("no secret for epoch %d of chat %s", epoch, chat.ChatID)
+	}
+	kdf, err := ChatKeyDerivation(chat)
+	if err != nil {
+		return nil, err
+	}
+	// назначение "epoch:N" без участников даёт ту же строку info, что и до появления назначений,
+	// поэтому ключи прежних сообщений не меняются
+	keys, err := kdf.Derive(secret, "epoch:"+strconv.Itoa(epoch))
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive key of epoch %d: %w", epoch, err)
+	}
+	return keys.Encryption, nil
 }
 
-func encryptMessage(cipher algos.Cipher, mode algos.EncryptionMode, padding algos.PaddingMode, message []byte) ([]byte, error) {
-	key := []byte("securekey12345678")
-	iv := []byte("iv1234567890abcd")
+// HasEpochSecret сообщает, начата ли эпоха со случайным секретом
+func HasEpochSecret(chat *models.Chat, epoch int) bool {
+	return epoch > 0 && epoch >= chat.FirstSecretEpoch
+}
 
-	expander, ok := cipher.(algos.KeyExpander)
-	if !ok {
-		return nil, fmt.Errorf("cipher doesn't support key expansion")
+// EpochSecret читает случайный секрет эпохи; у эпох без секрета он nil
+func EpochSecret(ctx context.Context, repo *repository.ChatRepository, chat *models.Chat, epoch int) ([]byte, error) {
+	if !HasEpochSecret(chat, epoch) {
+		return nil, nil
+	}
+	return repo.GetEpochSecret(ctx, chat.ChatID, epoch)
+}
+
+// legacySecret растягивает старый фиксированный ключ до 256 бит
+func legacySecret() []byte {
+	key := sha256.Sum256([]byte("securekey12345678"))
+	return key[:]
+}
+
+// defaultKey возвращает старый фиксированный ключ длиной, которую принимает шифр: RC5 берёт
+// столько байтов, сколько задано в его варианте, остальные шифры — все 256 бит
+func defaultKey(cipher algos.Cipher) []byte {
+	key := legacySecret()
+	if sized, ok := cipher.(interface{ KeySize() int }); ok && sized.KeySize() < len(key) {
+		return key[:sized.KeySize()]
 	}
+	return key
+}
 
-	ctxEnc := algos.NewEncryptionContext(
-		key,
-		mode,
-		padding,
-		iv,
-		cipher,
-		expander,
-	)
+// MessageAAD привязывает шифртекст к чату и отправителю (используется в режиме GCM)
+func MessageAAD(chatID, senderID string) []byte {
+	return algos.MessageAAD(chatID, senderID)
+}
+
+func encryptMessage(cipher algos.Cipher, mode algos.EncryptionMode, padding algos.PaddingMode, key, message, aad []byte) ([]byte, error) {
+	ctxEnc, err := newEncryptionContext(cipher, mode, padding, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return ctxEnc.EncryptWithAAD(message, aad)
+}
+
+func (s *ChatService) DecryptMessage(encryptedMsg []byte, algorithm, modeStr, paddingStr string, customKey, aad []byte) ([]byte, error) {
+	cipher, mode, padding, err := parseEncryptionParams(algorithm, modeStr, paddingStr)
+	if err != nil {
+		return nil, err
+	}
+
+	ctxDec, err := newEncryptionContext(cipher, mode, padding, customKey)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := ctxDec.DecryptWithAAD(encryptedMsg, aad)
+	if err != nil {
+		return nil, opaqueDecryptError(err)
+	}
+	return plaintext, nil
+}
+
+// NewFileEncryptor шифрует файл потоком: открытый текст пишется в результат,
+// конверт по частям уходит в w. Close дописывает последний блок.
+func (s *ChatService) NewFileEncryptor(w io.Writer, algorithm, modeStr, paddingStr string, customKey, aad []byte) (io.WriteCloser, error) {
+	cipher, mode, padding, err := parseEncryptionParams(algorithm, modeStr, paddingStr)
+	if err != nil {
+		return nil, err
+	}
+
+	ctxEnc, err := newEncryptionContext(cipher, mode, padding, customKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return algos.NewEncryptingWriterWithAAD(ctxEnc, w, aad)
+}
+
+// NewFileDecryptor расшифровывает файл потоком, читая конверт из r
+func (s *ChatService) NewFileDecryptor(r io.Reader, algorithm, modeStr, paddingStr string, customKey, aad []byte) (io.Reader, error) {
+	cipher, mode, padding, err := parseEncryptionParams(algorithm, modeStr, paddingStr)
+	if err != nil {
+		return nil, err
+	}
+
+	ctxDec, err := newEncryptionContext(cipher, mode, padding, customKey)
+	if err != nil {
+		return nil, err
+	}
+
+	decrypted, err := algos.NewDecryptingReaderWithAAD(ctxDec, r, aad)
+	if err != nil {
+		return nil, opaqueDecryptError(err)
+	}
+	return opaqueDecryptReader{decrypted}, nil
+}
+
+// opaqueDecryptError сводит все ошибки разбора, проверки тега и набивки к algos.ErrDecrypt,
+// чтобы клиент не мог узнать по ответу, на каком шаге расшифровка не удалась
+func opaqueDecryptError(err error) error {
+	if errors.Is(err, algos.ErrInvalidEnvelope) || errors.Is(err, algos.ErrAuthentication) {
+		return algos.ErrDecrypt
+	}
+	return err
+}
 
-	return ctxEnc.Encrypt(message)
+type opaqueDecryptReader struct {
+	r io.Reader
 }
 
-func (s *ChatService) DecryptMessage(encryptedMsg []byte, algorithm, modeStr, paddingStr string, customKey []byte) ([]byte, error) {
+func (o opaqueDecryptReader) Read(p []byte) (int, error) {
+	n, err := o.r.Read(p)
+	if err != nil && err != io.EOF {
+		err = opaqueDecryptError(err)
+	}
+	return n, err
+}
+
+func parseEncryptionParams(algorithm, modeStr, paddingStr string) (algos.Cipher, algos.EncryptionMode, algos.PaddingMode, error) {
 	cipher, err := initCipher(algorithm)
 	if err != nil {
-		return nil, fmt.Errorf("cipher init failed: %w", err)
+		return nil, 0, 0, fmt.Errorf("cipher init failed: %w", err)
 	}
 
 	mode, padding, err := validateEncryptionParams(modeStr, paddingStr)
 	if err != nil {
-		return nil, fmt.Errorf("invalid encryption params: %w", err)
+		return nil, 0, 0, fmt.Errorf("invalid encryption params: %w", err)
 	}
 
+	return cipher, mode, padding, nil
+}
+
+// newEncryptionContext собирает контекст для ключа чата; nil означает стандартный ключ
+func newEncryptionContext(cipher algos.Cipher, mode algos.EncryptionMode, padding algos.PaddingMode, customKey []byte) (*algos.EncryptionContext, error) {
 	key := customKey
 	if key == nil {
-		key = []byte("securekey12345678")
+		// для обратной совместимости используем старый фиксированный ключ
+		key = defaultKey(cipher)
 	}
 
-	iv := []byte("iv1234567890abcd")
-
 	expander, ok := cipher.(algos.KeyExpander)
 	if !ok {
 		return nil, fmt.Errorf("cipher doesn't support key expansion")
 	}
 
-	ctxDec := algos.NewEncryptionContext(
+	return algos.NewEncryptionContext(
 		key,
 		mode,
 		padding,
-		iv,
+		nil,
 		cipher,
 		expander,
 	)
-
-	return ctxDec.Decrypt(encryptedMsg)
 }
 
 func (s *ChatService) GetChatHistory(ctx context.Context, req *protopb.GetChatHistoryRequest) (*protopb.GetChatHistoryResponse, error) {
@@ -409,6 +708,13 @@ func (s *ChatService) GetChatHistory(ctx context.Context, req *protopb.GetChatHi
 			FileName:         msg.FileName,
 			ChunkIndex:       int32(msg.ChunkIndex),
 			TotalChunks:      int32(msg.TotalChunks),
+			SenderKeyId:      msg.SenderKeyID,
+			RatchetIndex:     msg.RatchetIndex,
+			RatchetKey:       msg.RatchetKey,
+			KeyEpoch:         uint32(msg.KeyEpoch),
+			Signature:        msg.Signature,
+			Sequence:         msg.Sequence,
+			SignedAt:         msg.SignedAt,
 		})
 	}
 
@@ -428,6 +734,17 @@ func (s *ChatService) GetChatHistory(ctx context.Context, req *protopb.GetChatHi
 			SenderName:       username,
 			EncryptedMessage: msg.EncryptedMessage,
 			CreatedAt:        msg.CreatedAt,
+			MessageType:      msg.MessageType,
+			FileName:         msg.FileName,
+			ChunkIndex:       msg.ChunkIndex,
+			TotalChunks:      msg.TotalChunks,
+			SenderKeyId:      msg.SenderKeyId,
+			RatchetIndex:     msg.RatchetIndex,
+			RatchetKey:       msg.RatchetKey,
+			KeyEpoch:         msg.KeyEpoch,
+			Signature:        msg.Signature,
+			Sequence:         msg.Sequence,
+			SignedAt:         msg.SignedAt,
 		})
 	}
 
@@ -436,6 +753,35 @@ func (s *ChatService) GetChatHistory(ctx context.Context, req *protopb.GetChatHi
 	}, nil
 }
 
+// CurrentKeyEpoch возвращает чат с текущей эпохой ключей; её номер ставится в каждое сообщение
+func (s *ChatService) CurrentKeyEpoch(ctx context.Context, chatID uuid.UUID) (*models.Chat, error) {
+	return currentKeyEpoch(ctx, s.chatRepo, chatID)
+}
+
+// currentKeyEpoch начинает новую эпоху, когда текущая длится дольше KeyEpochMaxAge или
+// насчитывает KeyEpochMaxMessages сообщений; смена состава чата начинает эпоху сразу. Эпоха
+// без случайного секрета (её ключ выведен из старого фиксированного) сменяется при первом
+// же сообщении. Эпоху сменяет только один из параллельных запросов, увидевших, что она истекла.
+func currentKeyEpoch(ctx context.Context, repo *repository.ChatRepository, chatID uuid.UUID) (*models.Chat, error) {
+	chat, err := repo.GetChatByID(chatID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chat: %w", err)
+	}
+	legacy := !chat.E2E && !HasEpochSecret(chat, chat.KeyEpoch)
+	if !legacy && chat.EpochMessages < KeyEpochMaxMessages && time.Since(chat.EpochStartedAt) < KeyEpochMaxAge {
+		return chat, nil
+	}
+
+	advanced, err := repo.AdvanceKeyEpoch(ctx, chatID, chat.KeyEpoch)
+	if err != nil {
+		return nil, err
+	}
+	if advanced {
+		log.Printf("[KEY EPOCH] Чат %s: эпоха %d завершена после %d сообщений, начата эпоха %d", chatID, chat.KeyEpoch, chat.EpochMessages, chat.KeyEpoch+1)
+	}
+	return repo.GetChatByID(chatID)
+}
+
 func (s *ChatService) ChatExists(ctx context.Context, chatID uuid.UUID) (bool, error) {
 	exists, err := s.chatRepo.ChatExists(ctx, chatID)
 	if err != nil {
@@ -443,8 +789,10 @@ func (s *ChatService) ChatExists(ctx context.Context, chatID uuid.UUID) (bool, e
 	}
 	return exists, nil
 }
+
+// StreamMessages пересылает сообщения участникам чата. Файлы приходят частями
+// зашифрованного потока: каждая часть сохраняется и рассылается сразу, без сборки.
 func (s *ChatService) StreamMessages(stream protopb.ChatService_StreamMessagesServer) error {
-	fileChunks := make(map[string][][]byte)
 	msg, err := stream.Recv()
 	if err != nil {
 		return err
@@ -455,6 +803,14 @@ func (s *ChatService) StreamMessages(stream protopb.ChatService_StreamMessagesSe
 	if err != nil {
 		return err
 	}
+	chatUUID, err := uuid.Parse(chatID)
+	if err != nil {
+		return fmt.Errorf("invalid chat ID: %w", err)
+	}
+	chat, err := s.chatRepo.GetChatByID(chatUUID)
+	if err != nil {
+		return err
+	}
 
 	s.mu.Lock()
 	if _, ok := s.streams[chatID]; !ok {
@@ -487,25 +843,15 @@ func (s *ChatService) StreamMessages(stream protopb.ChatService_StreamMessagesSe
 		if err != nil {
 			return err
 		}
-
-		if msg.MessageType == "file" {
-			fileName := msg.FileName
-			chunkIndex := int(msg.ChunkIndex)
-			totalChunks := int(msg.TotalChunks)
-
-			if fileChunks[fileName] == nil {
-				fileChunks[fileName] = make([][]byte, totalChunks)
-			}
-			fileChunks[fileName][chunkIndex] = msg.EncryptedMessage
-
-			if chunkIndex == totalChunks-1 {
-				var fileData []byte
-				for _, chunk := range fileChunks[fileName] {
-					fileData = append(fileData, chunk...)
-				}
-
-				delete(fileChunks, fileName)
-			}
+		// поток открыт от имени одного пользователя в одном чате; подпись проверяют получатели
+		if msg.SenderId != userID.String() || msg.ChatId != chatID {
+			log.Printf("Dropped message from %s to chat %s sent over the stream of user %s in chat %s", msg.SenderId, msg.ChatId, userID, chatID)
+			continue
+		}
+		// без цепочки отправителя сообщение зашифровано ключом эпохи, который знает сервер
+		if chat.E2E && msg.SenderKeyId == "" {
+			log.Printf("[E2E] Отклонено сообщение %s в чате %s: нет ключа отправителя", msg.SenderId, chatID)
+			continue
 		}
 
 		message := models.Message{
@@ -518,11 +864,21 @@ func (s *ChatService) StreamMessages(stream protopb.ChatService_StreamMessagesSe
 			FileName:         msg.FileName,
 			ChunkIndex:       int(msg.ChunkIndex),
 			TotalChunks:      int(msg.TotalChunks),
+			SenderKeyID:      msg.SenderKeyId,
+			RatchetIndex:     msg.RatchetIndex,
+			RatchetKey:       msg.RatchetKey,
+			KeyEpoch:         int(msg.KeyEpoch),
+			Signature:        msg.Signature,
+			Sequence:         msg.Sequence,
+			SignedAt:         msg.SignedAt,
 		}
 		if err := s.chatRepo.SaveMessage(context.Background(), message); err != nil {
 			log.Printf("Failed to save message: %v", err)
 			continue
 		}
+		if err := s.chatRepo.CountEpochMessage(context.Background(), message.ChatID, message.KeyEpoch); err != nil {
+			log.Printf("Failed to count epoch message: %v", err)
+		}
 
 		s.mu.Lock()
 		for _, userChan := range s.streams[chatID] {
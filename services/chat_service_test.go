@@ -0,0 +1,193 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"Kygram/algos"
+	"Kygram/models"
+	"Kygram/proto/protopb"
+
+	"github.com/google/uuid"
+)
+
+func testEpochChat() *models.Chat {
+	return &models.Chat{
+		ChatID:           uuid.New(),
+		Algorithm:        "serpent",
+		Mode:             "GCM",
+		Padding:          "None",
+		KeyAgreement:     algos.KeyAgreementX25519,
+		KDFSalt:          "00112233445566778899aabbccddeeff",
+		KeyEpoch:         1,
+		FirstSecretEpoch: 1,
+	}
+}
+
+func testEpochSecret(t *testing.T) []byte {
+	t.Helper()
+	secret, err := algos.NewKDFSalt()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return secret
+}
+
+func TestChatEpochKeyRotates(t *testing.T) {
+	chat := testEpochChat()
+	secret1, secret2 := testEpochSecret(t), testEpochSecret(t)
+
+	key1, err := ChatEpochKey(chat, 1, secret1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := ChatEpochKey(chat, 1, secret1)
+	if err != nil || !bytes.Equal(again, key1) {
+		t.Fatalf("epoch key is not reproducible: %v", err)
+	}
+
+	// смена состава начинает эпоху 2 с новым случайным секретом
+	key2, err := ChatEpochKey(chat, 2, secret2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(key1, key2) {
+		t.Fatal("new epoch kept the old key")
+	}
+	// номер эпохи входит в вывод ключа, даже если секрет совпал бы
+	if same, _ := ChatEpochKey(chat, 2, secret1); bytes.Equal(same, key1) {
+		t.Fatal("epoch number is not bound into the key")
+	}
+	other := *chat
+	other.ChatID = uuid.New()
+	if foreign, _ := ChatEpochKey(&other, 1, secret1); bytes.Equal(foreign, key1) {
+		t.Fatal("epoch key is not bound to the chat")
+	}
+
+	if _, err := ChatEpochKey(chat, 2, nil); err == nil {
+		t.Fatal("epoch with a secret derived a key without it")
+	}
+	e2e := *chat
+	e2e.E2E = true
+	if _, err := ChatEpochKey(&e2e, 1, secret1); !errors.Is(err, ErrEndToEnd) {
+		t.Fatalf("end-to-end chat got a server epoch key: %v", err)
+	}
+}
+
+func TestOldEpochHistoryDecrypts(t *testing.T) {
+	chat := testEpochChat()
+	s := &ChatService{}
+	secret1, secret2 := testEpochSecret(t), testEpochSecret(t)
+	key1, err := ChatEpochKey(chat, 1, secret1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	aad := MessageAAD(chat.ChatID.String(), "alice")
+	msg := &protopb.Message{ChatId: chat.ChatID.String(), SenderId: "alice", EncryptedMessage: []byte("before rotation"),
+		Algorithm: chat.Algorithm, Mode: chat.Mode, Padding: chat.Padding}
+	ciphertext, err := s.EncryptMessage(msg, key1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key2, err := ChatEpochKey(chat, 2, secret2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.DecryptMessage(ciphertext, chat.Algorithm, chat.Mode, chat.Padding, key2, aad); err == nil {
+		t.Fatal("message of epoch 1 decrypted with the key of epoch 2")
+	}
+
+	// история читает сообщение ключом его эпохи
+	key1, err = ChatEpochKey(chat, 1, secret1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plaintext, err := s.DecryptMessage(ciphertext, chat.Algorithm, chat.Mode, chat.Padding, key1, aad)
+	if err != nil || string(plaintext) != "before rotation" {
+		t.Fatalf("history of epoch 1: %q, %v", plaintext, err)
+	}
+
+	// эпохи до появления секретов читаются по старому ключу
+	legacy := *chat
+	legacy.FirstSecretEpoch = 3
+	legacyKey, err := ChatEpochKey(&legacy, 1, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ciphertext, err = s.EncryptMessage(msg, legacyKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.DecryptMessage(ciphertext, chat.Algorithm, chat.Mode, chat.Padding, legacyKey, aad); err != nil {
+		t.Fatalf("legacy epoch: %v", err)
+	}
+}
+
+// openEpochSecret разворачивает секрет так же, как client.GroupKeys.EpochSecret
+func openEpochSecret(chat *models.Chat, resp *protopb.GetEpochKeyResponse, private algos.AgreementKey, userID string, epoch uint32) ([]byte, error) {
+	shared, err := private.SharedSecret(resp.EphemeralPublicKey)
+	if err != nil {
+		return nil, err
+	}
+	kdf, err := ChatKeyDerivation(chat)
+	if err != nil {
+		return nil, err
+	}
+	return algos.UnwrapEpochSecret(kdf, chat.Algorithm, shared, resp.WrappedSecret, userID, resp.EphemeralPublicKey, epoch)
+}
+
+func TestRemovedMemberCannotOpenNewEpoch(t *testing.T) {
+	chat := testEpochChat()
+	agreement := algos.X25519{}
+	alice, err := agreement.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	mallory, err := agreement.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	aliceID, malloryID := uuid.NewString(), uuid.NewString()
+
+	// в эпохе 1 Мэллори участник и получила свой секрет
+	secret1 := testEpochSecret(t)
+	resp := &protopb.GetEpochKeyResponse{RecipientPublicKey: mallory.PublicKey()}
+	if err := sealEpochSecret(resp, chat, agreement, secret1, malloryID, 1); err != nil {
+		t.Fatal(err)
+	}
+	opened, err := openEpochSecret(chat, resp, mallory, malloryID, 1)
+	if err != nil || !bytes.Equal(opened, secret1) {
+		t.Fatalf("member could not open epoch 1: %v", err)
+	}
+
+	// после исключения секрет эпохи 2 выдаётся только оставшимся участникам
+	secret2 := testEpochSecret(t)
+	forAlice := &protopb.GetEpochKeyResponse{RecipientPublicKey: alice.PublicKey()}
+	if err := sealEpochSecret(forAlice, chat, agreement, secret2, aliceID, 2); err != nil {
+		t.Fatal(err)
+	}
+	if opened, err := openEpochSecret(chat, forAlice, alice, aliceID, 2); err != nil || !bytes.Equal(opened, secret2) {
+		t.Fatalf("member could not open epoch 2: %v", err)
+	}
+	if _, err := openEpochSecret(chat, forAlice, mallory, malloryID, 2); !errors.Is(err, algos.ErrDecrypt) {
+		t.Fatalf("removed member opened another member's secret: %v", err)
+	}
+	if _, err := openEpochSecret(chat, forAlice, mallory, aliceID, 2); !errors.Is(err, algos.ErrDecrypt) {
+		t.Fatalf("removed member opened another member's secret under their ID: %v", err)
+	}
+	// обёртку эпохи 1 не выдать за эпоху 2
+	if _, err := openEpochSecret(chat, resp, mallory, malloryID, 2); !errors.Is(err, algos.ErrDecrypt) {
+		t.Fatalf("epoch 1 wrapping opened as epoch 2: %v", err)
+	}
+
+	// прежний секрет не даёт ключа новой эпохи
+	key2, err := ChatEpochKey(chat, 2, secret2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if guess, _ := ChatEpochKey(chat, 2, secret1); bytes.Equal(guess, key2) {
+		t.Fatal("secret of epoch 1 derives the key of epoch 2")
+	}
+}
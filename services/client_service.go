@@ -0,0 +1,60 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"Kygram/proto/protopb"
+
+	"github.com/google/uuid"
+)
+
+// ClientService отдаёт клиентам, которые сами шифруют сообщения, параметры чата и историю
+type ClientService struct {
+	protopb.UnimplementedClientServiceServer
+	chats *ChatService
+}
+
+func NewClientService(chats *ChatService) *ClientService {
+	return &ClientService{chats: chats}
+}
+
+// Connect возвращает параметры шифрования и согласования ключей чата его участнику
+func (s *ClientService) Connect(ctx context.Context, req *protopb.ConnectRequest) (*protopb.ConnectResponse, error) {
+	chatID, err := uuid.Parse(req.ChatId)
+	if err != nil {
+		return nil, fmt.Errorf("invalid chat ID: %w", err)
+	}
+	userID, err := uuid.Parse(req.ClientId)
+	if err != nil {
+		return nil, fmt.Errorf("invalid client ID: %w", err)
+	}
+	ok, err := s.chats.chatRepo.IsParticipant(ctx, chatID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("user %s is not a participant of chat %s", userID, chatID)
+	}
+
+	chat, err := s.chats.chatRepo.GetChatByID(chatID)
+	if err != nil {
+		return nil, fmt.Errorf("chat not found: %w", err)
+	}
+	return &protopb.ConnectResponse{
+		Algorithm:    chat.Algorithm,
+		Mode:         chat.Mode,
+		Padding:      chat.Padding,
+		Prime:        chat.Prime,
+		KeyAgreement: ChatKeyAgreementName(chat),
+		DhGroup:      chat.DHGroup,
+		Generator:    chat.Generator,
+		KdfSalt:      chat.KDFSalt,
+		E2E:          chat.E2E,
+	}, nil
+}
+
+// GetChatHistory отдаёт сохранённые конверты как есть; расшифровывает их клиент
+func (s *ClientService) GetChatHistory(ctx context.Context, req *protopb.GetChatHistoryRequest) (*protopb.GetChatHistoryResponse, error) {
+	return s.chats.GetChatHistory(ctx, req)
+}
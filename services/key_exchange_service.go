@@ -1,25 +1,35 @@
 package services
 
 import (
+	"Kygram/algos"
+	"Kygram/models"
 	"Kygram/proto/protopb"
 	"Kygram/repository"
 	"context"
 	"fmt"
+	"log"
 	"sync"
+	"time"
 
 	"github.com/google/uuid"
 )
 
 type KeyExchangeService struct {
-	repo *repository.ChatRepository
+	repo  *repository.ChatRepository
+	users *repository.UserRepository
+	auth  *AuthService
 	protopb.UnimplementedKeyExchangeServiceServer
 	publicKeys map[string]string
 	mu         sync.Mutex
 }
 
-func NewKeyExchangeService(repo *repository.ChatRepository) *KeyExchangeService {
+// NewKeyExchangeService создаёт сервис ключей; вызовы, меняющие ключи пользователя, auth
+// принимает только с его токеном
+func NewKeyExchangeService(repo *repository.ChatRepository, users *repository.UserRepository, auth *AuthService) *KeyExchangeService {
 	return &KeyExchangeService{
 		repo:       repo,
+		users:      users,
+		auth:       auth,
 		publicKeys: make(map[string]string),
 	}
 }
@@ -29,12 +39,69 @@ func (s *KeyExchangeService) SendPublicKey(ctx context.Context, req *protopb.Sen
 	if err != nil {
 		return nil, fmt.Errorf("invalid client ID: %w", err)
 	}
+	if err := s.auth.Authorize(ctx, userID); err != nil {
+		return &protopb.SendPublicKeyResponse{Success: false, Error: err.Error()}, nil
+	}
+	chatID, err := uuid.Parse(req.ChatId)
+	if err != nil {
+		return nil, fmt.Errorf("invalid chat ID: %w", err)
+	}
 
-	err = s.repo.SavePublicKey(ctx, userID, req.PublicKey)
+	chat, err := s.repo.GetChatByID(chatID)
 	if err != nil {
+		return nil, fmt.Errorf("failed to get chat: %w", err)
+	}
+
+	// ключ проверяется по алгоритму чата до сохранения: вне подгруппы он раскрывал бы секрет
+	if err := ValidatePublicKey(chat, req.PublicKey); err != nil {
 		return &protopb.SendPublicKeyResponse{Success: false, Error: err.Error()}, nil
 	}
-	return &protopb.SendPublicKeyResponse{Success: true}, nil
+
+	key, err := NewSessionKey(chatID, userID, req.DeviceId, req.KeyId, req.PublicKey)
+	if err != nil {
+		return &protopb.SendPublicKeyResponse{Success: false, Error: err.Error()}, nil
+	}
+	// подпись проверяют отправители, которые оборачивают для ключа свои цепочки
+	key.Signature = req.Signature
+	ok, err := s.repo.IsParticipant(ctx, chatID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return &protopb.SendPublicKeyResponse{Success: false, Error: fmt.Sprintf("user %s is not a participant", userID)}, nil
+	}
+
+	if err := s.repo.SavePublicKey(ctx, key); err != nil {
+		return &protopb.SendPublicKeyResponse{Success: false, Error: err.Error()}, nil
+	}
+	return &protopb.SendPublicKeyResponse{Success: true, KeyId: key.KeyID.String()}, nil
+}
+
+// DefaultDeviceID — устройство клиентов, которые не называют своё
+const DefaultDeviceID = "default"
+
+const maxDeviceIDLength = 64
+
+// NewSessionKey собирает ключ подключения из полей запроса; пустой keyID заменяется новым
+func NewSessionKey(chatID, userID uuid.UUID, deviceID, keyID, publicKey string) (models.SessionKey, error) {
+	if deviceID == "" {
+		deviceID = DefaultDeviceID
+	}
+	if len(deviceID) > maxDeviceIDLength {
+		return models.SessionKey{}, fmt.Errorf("device ID is longer than %d bytes", maxDeviceIDLength)
+	}
+	if deviceID == algos.HistoryDeviceID {
+		return models.SessionKey{}, fmt.Errorf("device ID %q is reserved", deviceID)
+	}
+	id := uuid.New()
+	if keyID != "" {
+		parsed, err := uuid.Parse(keyID)
+		if err != nil {
+			return models.SessionKey{}, fmt.Errorf("invalid key ID: %w", err)
+		}
+		id = parsed
+	}
+	return models.SessionKey{ChatID: chatID, UserID: userID, DeviceID: deviceID, KeyID: id, PublicKey: publicKey}, nil
 }
 
 func (s *KeyExchangeService) ExchangeKeys(ctx context.Context, req *protopb.KeyExchangeRequest) (*protopb.KeyExchangeResponse, error) {
@@ -43,18 +110,587 @@ func (s *KeyExchangeService) ExchangeKeys(ctx context.Context, req *protopb.KeyE
 		return nil, fmt.Errorf("invalid chat ID: %w", err)
 	}
 
+	chat, err := currentKeyEpoch(ctx, s.repo, chatID)
+	if err != nil {
+		return nil, err
+	}
+
 	publicKeys, err := s.repo.GetPublicKeysByChatID(ctx, chatID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get public keys: %w", err)
 	}
 
+	// по ключу на каждое подключённое устройство
+	online := make(map[uuid.UUID]bool)
 	var clientPublicKeys []*protopb.ClientPublicKey
-	for userID, publicKey := range publicKeys {
-		clientPublicKeys = append(clientPublicKeys, &protopb.ClientPublicKey{
-			ClientId:  userID.String(),
-			PublicKey: publicKey,
+	for _, key := range publicKeys {
+		online[key.UserID] = true
+		clientPublicKeys = append(clientPublicKeys, sessionKeyProto(key))
+	}
+	// участники без ключа подключения не в сети; отправитель может взять их предварительные ключи
+	participants, err := s.repo.GetChatParticipants(chatID)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range participants {
+		if !online[p.UserID] {
+			clientPublicKeys = append(clientPublicKeys, &protopb.ClientPublicKey{ClientId: p.UserID.String()})
+		}
+	}
+
+	return &protopb.KeyExchangeResponse{PublicKeys: clientPublicKeys, KeyEpoch: uint32(chat.KeyEpoch)}, nil
+}
+
+func sessionKeyProto(key models.SessionKey) *protopb.ClientPublicKey {
+	pb := &protopb.ClientPublicKey{
+		ClientId:  key.UserID.String(),
+		PublicKey: key.PublicKey,
+		DeviceId:  key.DeviceID,
+		KeyId:     key.KeyID.String(),
+		CreatedAt: key.CreatedAt.UTC().Format(time.RFC3339),
+		Signature: key.Signature,
+	}
+	if key.RevokedAt != nil {
+		pb.RevokedAt = key.RevokedAt.UTC().Format(time.RFC3339)
+	}
+	return pb
+}
+
+// RevokePublicKeys отзывает ключи устройства, например потерянного; отозванный ключ остаётся
+// в истории, но отправители больше не оборачивают для него ключи. Отозвать можно только
+// свои ключи.
+func (s *KeyExchangeService) RevokePublicKeys(ctx context.Context, req *protopb.RevokePublicKeysRequest) (*protopb.RevokePublicKeysResponse, error) {
+	userID, err := uuid.Parse(req.ClientId)
+	if err != nil {
+		return nil, fmt.Errorf("invalid client ID: %w", err)
+	}
+	if err := s.auth.Authorize(ctx, userID); err != nil {
+		return &protopb.RevokePublicKeysResponse{Success: false, Error: err.Error()}, nil
+	}
+	if req.DeviceId == "" {
+		return &protopb.RevokePublicKeysResponse{Success: false, Error: "device ID is required"}, nil
+	}
+	var chatID, keyID uuid.NullUUID
+	if req.ChatId != "" {
+		if chatID.UUID, err = uuid.Parse(req.ChatId); err != nil {
+			return nil, fmt.Errorf("invalid chat ID: %w", err)
+		}
+		chatID.Valid = true
+	}
+	if req.KeyId != "" {
+		if keyID.UUID, err = uuid.Parse(req.KeyId); err != nil {
+			return nil, fmt.Errorf("invalid key ID: %w", err)
+		}
+		keyID.Valid = true
+	}
+
+	revoked, err := s.repo.RevokePublicKeys(ctx, chatID, userID, req.DeviceId, keyID)
+	if err != nil {
+		return &protopb.RevokePublicKeysResponse{Success: false, Error: err.Error()}, nil
+	}
+	if revoked > 0 {
+		log.Printf("[KEYS] Отозвано ключей устройства %s пользователя %s: %d", req.DeviceId, userID, revoked)
+	}
+	return &protopb.RevokePublicKeysResponse{Success: true, Revoked: uint32(revoked)}, nil
+}
+
+// GetPublicKeyHistory возвращает все ключи участника в чате, включая заменённые и отозванные
+func (s *KeyExchangeService) GetPublicKeyHistory(ctx context.Context, req *protopb.PublicKeyHistoryRequest) (*protopb.PublicKeyHistoryResponse, error) {
+	chatID, err := uuid.Parse(req.ChatId)
+	if err != nil {
+		return nil, fmt.Errorf("invalid chat ID: %w", err)
+	}
+	userID, err := uuid.Parse(req.ClientId)
+	if err != nil {
+		return nil, fmt.Errorf("invalid client ID: %w", err)
+	}
+
+	keys, err := s.repo.GetPublicKeyHistory(ctx, chatID, userID)
+	if err != nil {
+		return nil, err
+	}
+	resp := &protopb.PublicKeyHistoryResponse{}
+	for _, key := range keys {
+		resp.Keys = append(resp.Keys, sessionKeyProto(key))
+	}
+	return resp, nil
+}
+
+// DistributeSenderKey сохраняет ключ отправителя, обёрнутый для каждого получателя;
+// сервер видит только обёртки и принимает их лишь для участников чата
+func (s *KeyExchangeService) DistributeSenderKey(ctx context.Context, req *protopb.DistributeSenderKeyRequest) (*protopb.DistributeSenderKeyResponse, error) {
+	chatID, err := uuid.Parse(req.ChatId)
+	if err != nil {
+		return nil, fmt.Errorf("invalid chat ID: %w", err)
+	}
+	senderID, err := uuid.Parse(req.SenderId)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sender ID: %w", err)
+	}
+	keyID, err := uuid.Parse(req.KeyId)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key ID: %w", err)
+	}
+	if err := s.auth.Authorize(ctx, senderID); err != nil {
+		return &protopb.DistributeSenderKeyResponse{Success: false, Error: err.Error()}, nil
+	}
+
+	keys := make([]models.SenderKey, 0, len(req.Keys))
+	for _, wrapped := range req.Keys {
+		recipientID, err := uuid.Parse(wrapped.RecipientId)
+		if err != nil {
+			return nil, fmt.Errorf("invalid recipient ID: %w", err)
+		}
+		keys = append(keys, models.SenderKey{
+			ChatID:             chatID,
+			SenderID:           senderID,
+			KeyID:              keyID,
+			RecipientID:        recipientID,
+			RecipientDeviceID:  wrapped.RecipientDeviceId,
+			SenderPublicKey:    req.SenderPublicKey,
+			RecipientPublicKey: wrapped.RecipientPublicKey,
+			WrappedKey:         wrapped.WrappedKey,
+			SignedPrekeyID:     wrapped.SignedPrekeyId,
+			OneTimePrekeyID:    wrapped.OneTimePrekeyId,
+			SenderKeySignature: req.SenderKeySignature,
+			HistoryPublicKey:   req.HistoryPublicKey,
 		})
 	}
 
-	return &protopb.KeyExchangeResponse{PublicKeys: clientPublicKeys}, nil
+	for _, userID := range append([]uuid.UUID{senderID}, recipientIDs(keys)...) {
+		ok, err := s.repo.IsParticipant(ctx, chatID, userID)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return &protopb.DistributeSenderKeyResponse{Success: false, Error: fmt.Sprintf("user %s is not a participant", userID)}, nil
+		}
+	}
+
+	if err := s.repo.SaveSenderKeys(ctx, keys); err != nil {
+		return &protopb.DistributeSenderKeyResponse{Success: false, Error: err.Error()}, nil
+	}
+	return &protopb.DistributeSenderKeyResponse{Success: true}, nil
+}
+
+func recipientIDs(keys []models.SenderKey) []uuid.UUID {
+	ids := make([]uuid.UUID, len(keys))
+	for i, key := range keys {
+		ids[i] = key.RecipientID
+	}
+	return ids
+}
+
+func (s *KeyExchangeService) GetSenderKey(ctx context.Context, req *protopb.GetSenderKeyRequest) (*protopb.GetSenderKeyResponse, error) {
+	var ids [4]uuid.UUID
+	for i, id := range []string{req.ChatId, req.SenderId, req.KeyId, req.RecipientId} {
+		parsed, err := uuid.Parse(id)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ID %q: %w", id, err)
+		}
+		ids[i] = parsed
+	}
+
+	key, err := s.repo.GetSenderKey(ctx, ids[0], ids[1], ids[2], ids[3], req.RecipientDeviceId, algos.HistoryDeviceID)
+	if err != nil {
+		return nil, err
+	}
+	return &protopb.GetSenderKeyResponse{
+		SenderPublicKey:    key.SenderPublicKey,
+		SenderKeySignature: key.SenderKeySignature,
+		HistoryPublicKey:   key.HistoryPublicKey,
+		Key: &protopb.WrappedSenderKey{
+			RecipientId:        key.RecipientID.String(),
+			RecipientDeviceId:  key.RecipientDeviceID,
+			RecipientPublicKey: key.RecipientPublicKey,
+			WrappedKey:         key.WrappedKey,
+			SignedPrekeyId:     key.SignedPrekeyID,
+			OneTimePrekeyId:    key.OneTimePrekeyID,
+		},
+	}, nil
+}
+
+// GetSafetyNumber вычисляет код безопасности пользователя и собеседника по их ключам личности.
+// Ключи возвращаются вместе с кодом, чтобы клиент мог пересчитать код сам, а отметки о
+// проверке — только самому пользователю.
+func (s *KeyExchangeService) GetSafetyNumber(ctx context.Context, req *protopb.SafetyNumberRequest) (*protopb.SafetyNumberResponse, error) {
+	userID, err := uuid.Parse(req.UserId)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID: %w", err)
+	}
+	if err := s.auth.Authorize(ctx, userID); err != nil {
+		return nil, err
+	}
+	peerID, err := uuid.Parse(req.PeerId)
+	if err != nil {
+		return nil, fmt.Errorf("invalid peer ID: %w", err)
+	}
+
+	userKey, _, err := s.users.GetIdentityKey(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	peerKey, _, err := s.users.GetIdentityKey(ctx, peerID)
+	if err != nil {
+		return nil, err
+	}
+	number, err := algos.NewSafetyNumber(req.UserId, userKey, req.PeerId, peerKey)
+	if err != nil {
+		return nil, err
+	}
+
+	verified, err := s.users.GetVerifiedKeys(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	status := peerVerification(peerID, peerKey, verified)
+	return &protopb.SafetyNumberResponse{
+		SafetyNumber: number.Digits,
+		QrPayload:    number.QRPayload(),
+		UserKey:      userKey,
+		PeerKey:      peerKey,
+		Verified:     status.Verified,
+		KeyChanged:   status.KeyChanged,
+	}, nil
+}
+
+// SetPeerVerified отмечает ключ собеседника проверенным или снимает отметку. Отметка ставится
+// только на текущий ключ: если он сменился, пока пользователь сверял код, запрос отклоняется.
+func (s *KeyExchangeService) SetPeerVerified(ctx context.Context, req *protopb.SetPeerVerifiedRequest) (*protopb.SetPeerVerifiedResponse, error) {
+	userID, err := uuid.Parse(req.UserId)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID: %w", err)
+	}
+	if err := s.auth.Authorize(ctx, userID); err != nil {
+		return &protopb.SetPeerVerifiedResponse{Success: false, Error: err.Error()}, nil
+	}
+	peerID, err := uuid.Parse(req.PeerId)
+	if err != nil {
+		return nil, fmt.Errorf("invalid peer ID: %w", err)
+	}
+
+	if !req.Verified {
+		if err := s.users.DeleteVerifiedKey(ctx, userID, peerID); err != nil {
+			return &protopb.SetPeerVerifiedResponse{Success: false, Error: err.Error()}, nil
+		}
+		return &protopb.SetPeerVerifiedResponse{Success: true}, nil
+	}
+
+	current, _, err := s.users.GetIdentityKey(ctx, peerID)
+	if err != nil {
+		return &protopb.SetPeerVerifiedResponse{Success: false, Error: err.Error()}, nil
+	}
+	if current != req.PublicKey {
+		return &protopb.SetPeerVerifiedResponse{Success: false, Error: "peer identity key has changed"}, nil
+	}
+	if err := s.users.SaveVerifiedKey(ctx, userID, peerID, req.PublicKey); err != nil {
+		return &protopb.SetPeerVerifiedResponse{Success: false, Error: err.Error()}, nil
+	}
+	return &protopb.SetPeerVerifiedResponse{Success: true}, nil
+}
+
+// GetPeerVerification возвращает состояние проверки ключей всех собеседников пользователя в чате
+func (s *KeyExchangeService) GetPeerVerification(ctx context.Context, req *protopb.PeerVerificationRequest) (*protopb.PeerVerificationResponse, error) {
+	userID, err := uuid.Parse(req.UserId)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID: %w", err)
+	}
+	if err := s.auth.Authorize(ctx, userID); err != nil {
+		return nil, err
+	}
+	chatID, err := uuid.Parse(req.ChatId)
+	if err != nil {
+		return nil, fmt.Errorf("invalid chat ID: %w", err)
+	}
+
+	participants, err := s.repo.GetChatParticipants(chatID)
+	if err != nil {
+		return nil, err
+	}
+	verified, err := s.users.GetVerifiedKeys(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var peers []*protopb.PeerVerification
+	for _, p := range participants {
+		if p.UserID == userID {
+			continue
+		}
+		// собеседник без ключа личности ещё не подключался с подписью
+		publicKey, _, err := s.users.GetIdentityKey(ctx, p.UserID)
+		if err != nil {
+			publicKey = ""
+		}
+		peers = append(peers, peerVerification(p.UserID, publicKey, verified))
+	}
+	return &protopb.PeerVerificationResponse{Peers: peers}, nil
+}
+
+func peerVerification(peerID uuid.UUID, publicKey string, verified map[uuid.UUID]string) *protopb.PeerVerification {
+	verifiedKey, ok := verified[peerID]
+	return &protopb.PeerVerification{
+		PeerId:     peerID.String(),
+		PublicKey:  publicKey,
+		Verified:   ok && verifiedKey == publicKey,
+		KeyChanged: ok && verifiedKey != publicKey,
+	}
+}
+
+// UploadPrekeys публикует предварительные ключи пользователя. Вызов принимается только с его
+// токеном, подписанный ключ — только с подписью текущим ключом личности.
+func (s *KeyExchangeService) UploadPrekeys(ctx context.Context, req *protopb.UploadPrekeysRequest) (*protopb.UploadPrekeysResponse, error) {
+	userID, err := uuid.Parse(req.UserId)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID: %w", err)
+	}
+	if err := s.auth.Authorize(ctx, userID); err != nil {
+		return &protopb.UploadPrekeysResponse{Success: false, Error: err.Error()}, nil
+	}
+
+	if signed := req.SignedPrekey; signed != nil {
+		identityKey, _, err := s.users.GetIdentityKey(ctx, userID)
+		if err != nil {
+			return &protopb.UploadPrekeysResponse{Success: false, Error: err.Error()}, nil
+		}
+		if err := checkPrekey(signed); err != nil {
+			return &protopb.UploadPrekeysResponse{Success: false, Error: err.Error()}, nil
+		}
+		if err := algos.VerifySignature(identityKey, algos.SignedPrekeyPayload(req.UserId, signed.KeyId, signed.PublicKey), signed.Signature); err != nil {
+			return &protopb.UploadPrekeysResponse{Success: false, Error: "signed prekey: " + err.Error()}, nil
+		}
+		err = s.users.SaveSignedPrekey(ctx, userID, models.Prekey{KeyID: signed.KeyId, PublicKey: signed.PublicKey, Signature: signed.Signature})
+		if err != nil {
+			return &protopb.UploadPrekeysResponse{Success: false, Error: err.Error()}, nil
+		}
+	}
+
+	oneTime := make([]models.Prekey, 0, len(req.OneTimePrekeys))
+	for _, key := range req.OneTimePrekeys {
+		if err := checkPrekey(key); err != nil {
+			return &protopb.UploadPrekeysResponse{Success: false, Error: err.Error()}, nil
+		}
+		oneTime = append(oneTime, models.Prekey{KeyID: key.KeyId, PublicKey: key.PublicKey})
+	}
+	if err := s.users.AddOneTimePrekeys(ctx, userID, oneTime, req.Replace); err != nil {
+		return &protopb.UploadPrekeysResponse{Success: false, Error: err.Error()}, nil
+	}
+
+	_, count, err := s.users.GetPrekeyStatus(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &protopb.UploadPrekeysResponse{Success: true, OneTimeCount: uint32(count)}, nil
+}
+
+func checkPrekey(key *protopb.Prekey) error {
+	if key.KeyId == 0 {
+		return fmt.Errorf("prekey ID must not be zero")
+	}
+	if err := (algos.X25519{}).CheckPublicKey(key.PublicKey); err != nil {
+		return fmt.Errorf("prekey %d: %w", key.KeyId, err)
+	}
+	return nil
+}
+
+func (s *KeyExchangeService) GetPrekeyStatus(ctx context.Context, req *protopb.PrekeyStatusRequest) (*protopb.PrekeyStatusResponse, error) {
+	userID, err := uuid.Parse(req.UserId)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID: %w", err)
+	}
+	signedID, count, err := s.users.GetPrekeyStatus(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &protopb.PrekeyStatusResponse{SignedPrekeyId: signedID, OneTimeCount: uint32(count)}, nil
+}
+
+// FetchPrekeyBundle выдаёт набор ключей пользователя другому участнику того же чата,
+// расходуя один одноразовый ключ; с signed_only — только подписанный ключ
+func (s *KeyExchangeService) FetchPrekeyBundle(ctx context.Context, req *protopb.FetchPrekeyBundleRequest) (*protopb.PrekeyBundle, error) {
+	chatID, err := uuid.Parse(req.ChatId)
+	if err != nil {
+		return nil, fmt.Errorf("invalid chat ID: %w", err)
+	}
+	requesterID, err := uuid.Parse(req.RequesterId)
+	if err != nil {
+		return nil, fmt.Errorf("invalid requester ID: %w", err)
+	}
+	// набор запрашивают только от своего имени
+	if err := s.auth.Authorize(ctx, requesterID); err != nil {
+		return nil, err
+	}
+	userID, err := uuid.Parse(req.UserId)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID: %w", err)
+	}
+
+	// иначе кто угодно мог бы исчерпать запас одноразовых ключей
+	for _, id := range []uuid.UUID{requesterID, userID} {
+		ok, err := s.repo.IsParticipant(ctx, chatID, id)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, fmt.Errorf("user %s is not a participant of chat %s", id, chatID)
+		}
+	}
+
+	identityKey, _, err := s.users.GetIdentityKey(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if req.SignedOnly {
+		signed, err := s.users.GetSignedPrekey(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		return &protopb.PrekeyBundle{
+			UserId:       req.UserId,
+			IdentityKey:  identityKey,
+			SignedPrekey: &protopb.Prekey{KeyId: signed.KeyID, PublicKey: signed.PublicKey, Signature: signed.Signature},
+		}, nil
+	}
+	signed, oneTime, err := s.users.TakePrekeys(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	bundle := &protopb.PrekeyBundle{
+		UserId:       req.UserId,
+		IdentityKey:  identityKey,
+		SignedPrekey: &protopb.Prekey{KeyId: signed.KeyID, PublicKey: signed.PublicKey, Signature: signed.Signature},
+	}
+	if oneTime != nil {
+		bundle.OneTimePrekey = &protopb.Prekey{KeyId: oneTime.KeyID, PublicKey: oneTime.PublicKey}
+	} else {
+		log.Printf("[PREKEYS] У пользователя %s закончились одноразовые ключи", userID)
+	}
+	return bundle, nil
+}
+
+// GetEpochKey выдаёт участнику обычного чата случайный секрет эпохи, обернув его для текущего
+// ключа устройства, подписанного ключом личности участника, а без устройства — для его
+// подписанного предварительного ключа: секрет раскроет только владелец закрытого ключа,
+// а исключённый участник новых секретов не получит
+func (s *KeyExchangeService) GetEpochKey(ctx context.Context, req *protopb.GetEpochKeyRequest) (*protopb.GetEpochKeyResponse, error) {
+	chatID, err := uuid.Parse(req.ChatId)
+	if err != nil {
+		return nil, fmt.Errorf("invalid chat ID: %w", err)
+	}
+	userID, err := uuid.Parse(req.UserId)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID: %w", err)
+	}
+
+	chat, err := s.repo.GetChatByID(chatID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chat: %w", err)
+	}
+	if chat.E2E {
+		return nil, ErrEndToEnd
+	}
+	ok, err := s.repo.IsParticipant(ctx, chatID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("user %s is not a participant of chat %s", userID, chatID)
+	}
+
+	epoch := int(req.Epoch)
+	if !HasEpochSecret(chat, epoch) {
+		return nil, fmt.Errorf("epoch %d of chat %s has no secret", epoch, chatID)
+	}
+	secret, err := s.repo.GetEpochSecret(ctx, chatID, epoch)
+	if err != nil {
+		return nil, err
+	}
+
+	identityKey, _, err := s.users.GetIdentityKey(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	resp := &protopb.GetEpochKeyResponse{}
+	var agreement algos.KeyAgreement
+	if req.DeviceId != "" {
+		key, err := s.currentDeviceKey(ctx, chatID, userID, req.DeviceId)
+		if err != nil {
+			return nil, err
+		}
+		payload := algos.SessionKeyPayload(req.ChatId, req.UserId, key.DeviceID, key.KeyID.String(), key.PublicKey)
+		if err := algos.VerifySignature(identityKey, payload, key.Signature); err != nil {
+			return nil, fmt.Errorf("key of device %s: %w", req.DeviceId, err)
+		}
+		if agreement, err = ChatKeyAgreement(chat); err != nil {
+			return nil, err
+		}
+		resp.RecipientPublicKey = key.PublicKey
+	} else {
+		signed, err := s.users.GetSignedPrekey(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		if err := algos.VerifySignature(identityKey, algos.SignedPrekeyPayload(req.UserId, signed.KeyID, signed.PublicKey), signed.Signature); err != nil {
+			return nil, fmt.Errorf("signed prekey: %w", err)
+		}
+		// предварительные ключи — всегда X25519
+		agreement = algos.X25519{}
+		resp.RecipientPublicKey, resp.SignedPrekeyId = signed.PublicKey, signed.KeyID
+	}
+
+	if err := sealEpochSecret(resp, chat, agreement, secret, req.UserId, req.Epoch); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// sealEpochSecret оборачивает секрет эпохи для resp.RecipientPublicKey через одноразовый ключ
+// согласования agreement
+func sealEpochSecret(resp *protopb.GetEpochKeyResponse, chat *models.Chat, agreement algos.KeyAgreement, secret []byte, userID string, epoch uint32) error {
+	ephemeral, err := agreement.GenerateKey()
+	if err != nil {
+		return err
+	}
+	shared, err := ephemeral.SharedSecret(resp.RecipientPublicKey)
+	if err != nil {
+		return err
+	}
+	kdf, err := ChatKeyDerivation(chat)
+	if err != nil {
+		return err
+	}
+	resp.EphemeralPublicKey = ephemeral.PublicKey()
+	resp.WrappedSecret, err = algos.WrapEpochSecret(kdf, chat.Algorithm, shared, secret, userID, resp.EphemeralPublicKey, epoch)
+	return err
+}
+
+func (s *KeyExchangeService) currentDeviceKey(ctx context.Context, chatID, userID uuid.UUID, deviceID string) (*models.SessionKey, error) {
+	keys, err := s.repo.GetPublicKeysByChatID(ctx, chatID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range keys {
+		if keys[i].UserID == userID && keys[i].DeviceID == deviceID {
+			return &keys[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no current key of device %s of user %s", deviceID, userID)
+}
+
+// ValidatePublicKey проверяет открытый ключ участника по алгоритму согласования чата
+func ValidatePublicKey(chat *models.Chat, publicKey string) error {
+	agreement, err := ChatKeyAgreement(chat)
+	if err != nil {
+		return err
+	}
+	return agreement.CheckPublicKey(publicKey)
+}
+
+// ChatKeyAgreementName возвращает имя алгоритма согласования чата; у старых чатов оно не записано
+func ChatKeyAgreementName(chat *models.Chat) string {
+	if chat.KeyAgreement == "" {
+		return algos.DefaultKeyAgreement
+	}
+	return chat.KeyAgreement
 }
@@ -11,7 +11,8 @@ async function initKeyExchange(chatId, userId) {
   try {
     const keyExchangeClient = new KeyExchangeClient();
     
-    const keyPair = await keyExchangeClient.generateKeyPair(chatId);
+    const chat = await keyExchangeClient.getChat(chatId);
+    const keyPair = await keyExchangeClient.generateKeyPair(chat);
     keyStore.privateKey = keyPair.privateKey;
     
     await keyExchangeClient.sendPublicKey(chatId, userId, keyPair.publicKey);
@@ -36,6 +37,107 @@ async function initKeyExchange(chatId, userId) {
   }
 }
 
+let chatInfoRequest = null;
+
+// getChatInfo запрашивает параметры чата один раз за страницу
+function getChatInfo(chatId) {
+  if (!chatInfoRequest) {
+    chatInfoRequest = fetch(`/get-chat?id=${chatId}`)
+      .then(response => response.json())
+      .then(data => data.success ? data.chat : null)
+      .catch(error => {
+        chatInfoRequest = null;
+        throw error;
+      });
+  }
+  return chatInfoRequest;
+}
+
+// сеанс чата со сквозным шифрованием: ключи и шифрование в браузере (WASM), сервер только
+// пересылает конверты
+let e2eSession = null;
+// конверты шифруются и расшифровываются по очереди: части файла идут одним потоком
+let e2eQueue = Promise.resolve();
+let resolveE2EReady;
+const e2eReady = new Promise(resolve => resolveE2EReady = resolve);
+let wasmLoading = null;
+
+function loadKygramWasm() {
+  if (!wasmLoading) {
+    wasmLoading = new Promise((resolve, reject) => {
+      const script = document.createElement('script');
+      script.src = '/Kygram/static/wasm_exec.js';
+      script.onload = resolve;
+      script.onerror = reject;
+      document.head.appendChild(script);
+    }).then(async () => {
+      const go = new Go();
+      const result = await WebAssembly.instantiateStreaming(fetch('/Kygram/static/kygram.wasm'), go.importObject);
+      go.run(result.instance);
+    }).catch(error => {
+      wasmLoading = null;
+      throw error;
+    });
+  }
+  return wasmLoading;
+}
+
+// openE2ESession публикует новый ключ устройства: прежний сервер отозвал вместе с соединением
+async function openE2ESession(chatId, userId) {
+  await loadKygramWasm();
+  e2eSession = await kygram.openSession({
+    chatId: chatId,
+    userId: userId,
+    deviceId: getDeviceId(),
+    baseUrl: window.location.origin,
+    token: localStorage.getItem('token')
+  });
+  e2eSession.onKeyChange(senderId => appendKeyWarning(`identity key of ${senderId} has changed`));
+  resolveE2EReady(e2eSession);
+  console.log("End-to-end session opened");
+  return true;
+}
+
+// ключ личности обычного чата хранится только в браузере (WASM): сервер присылает данные
+// на подпись, а WASM подписывает лишь предварительные ключи и сообщения этого пользователя
+let identityLoading = null;
+
+function openIdentity() {
+  if (!identityLoading) {
+    identityLoading = loadKygramWasm().then(() => kygram.openIdentity({
+      userId: localStorage.getItem('user_id'),
+      baseUrl: window.location.origin,
+      token: localStorage.getItem('token')
+    })).catch(error => {
+      identityLoading = null;
+      throw error;
+    });
+  }
+  return identityLoading;
+}
+
+function answerSignRequest(data) {
+  openIdentity()
+    .then(identity => identity.sign(data.payload))
+    .then(signature => ws.send(JSON.stringify({ type: 'signature', request_id: data.request_id, signature: signature })))
+    .catch(error => ws.send(JSON.stringify({ type: 'signature', request_id: data.request_id, error: error.message || String(error) })));
+}
+
+// sendFrame отправляет сообщение в WebSocket; в чате со сквозным шифрованием — конвертом,
+// зашифрованным в браузере
+function sendFrame(message) {
+  if (!e2eSession) {
+    ws.send(JSON.stringify(message));
+    return;
+  }
+  e2eQueue = e2eQueue
+    .then(() => message.type === 'file'
+      ? e2eSession.encryptFileChunk(message.file_name, message.chunk_index, message.total_chunks, new Uint8Array(message.data))
+      : e2eSession.encrypt(message.text))
+    .then(envelope => ws.send(JSON.stringify({ type: 'envelope', message: JSON.parse(envelope) })))
+    .catch(error => console.error('Failed to encrypt message:', error));
+}
+
 document.getElementById("send-message").addEventListener("click", function() {
 let messageInput = document.getElementById("message-input");
 let messageText = messageInput.value.trim();
@@ -157,17 +259,28 @@ if (data.success) {
     document.getElementById('chat-name').textContent = chat.name;
     
     const currentUserId = localStorage.getItem('user_id');
-    const friends = chat.participants
+    const friendName = document.getElementById('friend-name');
+    friendName.textContent = '';
+    // по щелчку на имени собеседника показывается код безопасности
+    chat.participants
         .filter(p => p.user_id !== currentUserId)
-        .map(p => p.username);
-    
-    document.getElementById('friend-name').textContent = friends.join(', ');
+        .forEach((p, i) => {
+            if (i > 0) friendName.append(', ');
+            const link = document.createElement('span');
+            link.className = 'peer-name';
+            link.textContent = p.username;
+            link.title = 'Compare safety number';
+            link.onclick = () => verifyPeer(p.user_id, p.username);
+            friendName.appendChild(link);
+        });
 }
 });
 
 });
 
 let ws = null;
+// части входящих файлов: сервер присылает файл кусками, последний помечен final
+const incomingFileParts = {};
 
 function connectWebSocket() {
   const currentChatId = localStorage.getItem('current_chat_id');
@@ -178,9 +291,18 @@ function connectWebSocket() {
   isConnecting = true;
   updateConnectionStatus('connecting');
 
-  initKeyExchange(currentChatId, UserId).then(success => {
-
-    ws = new WebSocket(`ws://localhost:2033/ws?chat_id=${currentChatId}&user_id=${UserId}`);
+  getChatInfo(currentChatId).then(chat => {
+    if (chat && chat.e2e) {
+      return openE2ESession(currentChatId, UserId);
+    }
+    openIdentity().catch(error => console.error('Failed to open identity key:', error));
+    return initKeyExchange(currentChatId, UserId);
+  }).then(success => {
+    // по ключу сервер отзовёт ключ устройства, когда соединение закроется
+    const keyParam = e2eSession ? `&key_id=${e2eSession.keyId}` : '';
+    // заголовков у WebSocket нет: токен, с которым сервер публикует ключи устройства, идёт в адресе
+    const token = encodeURIComponent(localStorage.getItem('token') || '');
+    ws = new WebSocket(`ws://localhost:2033/ws?chat_id=${currentChatId}&user_id=${UserId}&device_id=${getDeviceId()}&token=${token}${keyParam}`);
     
     ws.onopen = () => {
       console.log('WebSocket connected');
@@ -191,7 +313,7 @@ function connectWebSocket() {
         console.log(`Sending ${messageBuffer.length} buffered messages`);
         messageBuffer.forEach(msg => {
           if (ws && ws.readyState === WebSocket.OPEN) {
-            ws.send(JSON.stringify(msg));
+            sendFrame(msg);
           }
         });
         messageBuffer.length = 0;
@@ -203,58 +325,24 @@ function connectWebSocket() {
           const data = JSON.parse(event.data);
           console.log("Received data:", data);
 
-          if (data.message_type === "file") {
-              let fileBytes;
-              
-              if (data.is_base64) {
-                  const binary = atob(data.message);
-                  fileBytes = new Uint8Array(binary.length);
-                  for (let i = 0; i < binary.length; i++) {
-                      fileBytes[i] = binary.charCodeAt(i);
-                  }
-                  //console.log("Decoded Base64 data, size:", fileBytes.length);
-              } else {
-                  fileBytes = Array.isArray(data.message) ? 
-                      new Uint8Array(data.message) : 
-                      new Uint8Array(Object.values(data.message));
-              }
-              
-              const fileExtension = data.file_name.split('.').pop().toLowerCase();
-              const mimeTypes = {
-                  'png': 'image/png',
-                  'jpg': 'image/jpeg',
-                  'jpeg': 'image/jpeg',
-                  'gif': 'image/gif',
-                  'pdf': 'application/pdf',
-                  'txt': 'text/plain',
-                  'doc': 'application/msword',
-                  'docx': 'application/vnd.openxmlformats-officedocument.wordprocessingml.document',
-                  'xls': 'application/vnd.ms-excel',
-                  'xlsx': 'application/vnd.openxmlformats-officedocument.spreadsheetml.sheet',
-                  'zip': 'application/zip',
-                  'rar': 'application/x-rar-compressed'
-              };
-              const mimeType = mimeTypes[fileExtension] || 'application/octet-stream';
-
-              const blob = new Blob([fileBytes], { type: mimeType });
-              const fileUrl = URL.createObjectURL(blob);
-              
-              console.log(`Created blob with size: ${blob.size} bytes and type: ${mimeType}`);
-              
-              appendMessage(
-                  data.sender_name,
-                  {
-                      type: 'file',
-                      url: fileUrl,
-                      fileName: data.file_name,
-                      mimeType: mimeType
-                  },
-                  data.created_at,
-                  data.sender_id
-              );
-          } else {
-              appendMessage(data.sender_name, data.message, data.created_at, data.sender_id);
+          if (data.type === "sign_request") {
+              answerSignRequest(data);
+              return;
+          }
+          // конверт чата со сквозным шифрованием расшифровывает WASM, по порядку поступления
+          if (data.type === "envelope") {
+              e2eQueue = e2eQueue
+                  .then(() => e2eSession.decrypt(JSON.stringify(data.message)))
+                  .then(decrypted => {
+                      if (decrypted) {
+                          decrypted.sender_name = data.sender_name;
+                          showIncomingMessage(decrypted);
+                      }
+                  })
+                  .catch(error => console.error('Failed to decrypt envelope:', error));
+              return;
           }
+          showIncomingMessage(data);
       } catch (e) {
           console.error('Error processing message:', e);
           console.error('Raw message:', event.data);
@@ -290,6 +378,89 @@ function connectWebSocket() {
   });
 }
 
+// showIncomingMessage показывает сообщение, расшифрованное сервером или, в чате со сквозным
+// шифрованием, браузером
+function showIncomingMessage(data) {
+    if (data.type === "key_change_warning") {
+        appendKeyWarning(data.message);
+        return;
+    }
+
+    // сервер отвечает одинаково на любую ошибку расшифровки текста или файла
+    if (data.error) {
+        delete incomingFileParts[`${data.sender_id}/${data.file_name}`];
+        appendMessage(data.sender_name, `[${data.error}]`, data.created_at, data.sender_id, data);
+        return;
+    }
+
+    if (data.message_type === "file") {
+        let fileBytes;
+        const fileKey = `${data.sender_id}/${data.file_name}`;
+
+        if (data.final !== undefined) {
+            const binary = atob(data.message);
+            const part = new Uint8Array(binary.length);
+            for (let i = 0; i < binary.length; i++) {
+                part[i] = binary.charCodeAt(i);
+            }
+            (incomingFileParts[fileKey] = incomingFileParts[fileKey] || []).push(part);
+            if (!data.final) {
+                return;
+            }
+            fileBytes = incomingFileParts[fileKey];
+            delete incomingFileParts[fileKey];
+        } else if (data.is_base64) {
+            const binary = atob(data.message);
+            fileBytes = new Uint8Array(binary.length);
+            for (let i = 0; i < binary.length; i++) {
+                fileBytes[i] = binary.charCodeAt(i);
+            }
+            //console.log("Decoded Base64 data, size:", fileBytes.length);
+        } else {
+            fileBytes = Array.isArray(data.message) ? 
+                new Uint8Array(data.message) : 
+                new Uint8Array(Object.values(data.message));
+        }
+        
+        const fileExtension = data.file_name.split('.').pop().toLowerCase();
+        const mimeTypes = {
+            'png': 'image/png',
+            'jpg': 'image/jpeg',
+            'jpeg': 'image/jpeg',
+            'gif': 'image/gif',
+            'pdf': 'application/pdf',
+            'txt': 'text/plain',
+            'doc': 'application/msword',
+            'docx': 'application/vnd.openxmlformats-officedocument.wordprocessingml.document',
+            'xls': 'application/vnd.ms-excel',
+            'xlsx': 'application/vnd.openxmlformats-officedocument.spreadsheetml.sheet',
+            'zip': 'application/zip',
+            'rar': 'application/x-rar-compressed'
+        };
+        const mimeType = mimeTypes[fileExtension] || 'application/octet-stream';
+
+        const blob = new Blob(Array.isArray(fileBytes) ? fileBytes : [fileBytes], { type: mimeType });
+        const fileUrl = URL.createObjectURL(blob);
+        
+        console.log(`Created blob with size: ${blob.size} bytes and type: ${mimeType}`);
+        
+        appendMessage(
+            data.sender_name,
+            {
+                type: 'file',
+                url: fileUrl,
+                fileName: data.file_name,
+                mimeType: mimeType
+            },
+            data.created_at,
+            data.sender_id,
+            data
+        );
+    } else {
+        appendMessage(data.sender_name, data.message, data.created_at, data.sender_id, data);
+    }
+}
+
 function sendMessageViaWebSocket(messageText) {
   const message = {
     type: "text",
@@ -310,7 +481,7 @@ function sendMessageViaWebSocket(messageText) {
   }
   
   console.log("Sending message via WebSocket:", message);
-  ws.send(JSON.stringify(message));
+  sendFrame(message);
 }
 
 function isWebSocketReady() {
@@ -388,7 +559,7 @@ function sendFile(file) {
       return;
     }
     
-    ws.send(JSON.stringify(message));
+    sendFrame(message);
 
     offset += chunk.byteLength;
     console.log(`New offset: ${offset}, Progress: ${Math.floor((offset / file.size) * 100)}%`);
@@ -418,7 +589,57 @@ function sendFile(file) {
   }
 }
 
-function appendMessage(senderName, messageText, timestamp, senderId) {
+// verifyPeer показывает код безопасности с собеседником и, если пользователь подтвердил,
+// что код совпадает на обоих устройствах, отмечает ключ собеседника проверенным
+async function verifyPeer(peerId, peerName) {
+    const auth = `Bearer ${localStorage.getItem('token')}`;
+    try {
+        const response = await fetch(`/safety-number?peer_id=${peerId}`, {
+            headers: { 'Authorization': auth }
+        });
+        const data = await response.json();
+        if (!data.success) {
+            alert(`Safety number unavailable: ${data.error}`);
+            return;
+        }
+
+        const groups = data.safety_number.match(/.{5}/g);
+        let status = data.verified ? 'Verified.' : 'Not verified.';
+        if (data.key_changed) {
+            status = 'Identity key changed since you verified it!';
+        }
+        const text = `Safety number with ${peerName}:\n\n` +
+            `${groups.slice(0, 6).join(' ')}\n${groups.slice(6).join(' ')}\n\n${status}\n\n` +
+            `Press OK if it matches the number on ${peerName}'s screen.`;
+        if (!confirm(text)) {
+            return;
+        }
+
+        const verify = await fetch('/verify-peer', {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json', 'Authorization': auth },
+            body: JSON.stringify({ peer_id: peerId, public_key: data.peer_key, verified: true })
+        });
+        const result = await verify.json();
+        if (!result.success) {
+            alert(`Failed to verify ${peerName}: ${result.error}`);
+        }
+    } catch (error) {
+        console.error('Safety number error:', error);
+    }
+}
+
+function appendKeyWarning(text) {
+    const messagesDiv = document.getElementById('chat-messages');
+    const warning = document.createElement('div');
+    warning.className = 'key-warning';
+    warning.textContent = `⚠ ${text}`;
+    messagesDiv.appendChild(warning);
+    messagesDiv.scrollTop = messagesDiv.scrollHeight;
+}
+
+// verification — поля verified и verification_error из ответа сервера
+function appendMessage(senderName, messageText, timestamp, senderId, verification) {
     const messagesDiv = document.getElementById('chat-messages');
     const msgElement = document.createElement('div');
     const userId = localStorage.getItem('user_id'); 
@@ -441,6 +662,10 @@ function appendMessage(senderName, messageText, timestamp, senderId) {
     }
 
     const isCurrentUser = senderId === userId;
+    // подпись отправителя не подтверждена: предупреждаем рядом с именем
+    if (verification && verification.verified === false) {
+        senderName += ` <span class="unverified" title="${verification.verification_error}">⚠ unverified</span>`;
+    }
     msgElement.className = isCurrentUser ? 'message my-message' : 'message other-message';
 
     if (typeof messageText === 'object' && messageText.type === 'file') {
@@ -516,13 +741,22 @@ document.getElementById("file-input").addEventListener("change", function(event)
 
 async function loadHistory() {
 try {
-    const response = await fetch(`/messages?chat_id=${localStorage.getItem('current_chat_id')}`);
+    // по идентификатору читателя сервер расшифрует то, что пришло, пока он был не в сети
+    const response = await fetch(`/messages?chat_id=${localStorage.getItem('current_chat_id')}`, {
+        headers: { 'X-User-ID': localStorage.getItem('user_id') }
+    });
     if (!response.ok) {
         throw new Error(`HTTP error! Status: ${response.status}`);
     }
-    const data = await response.json();
+    let data = await response.json();
     //console.log("Loaded messages:", data);
 
+    // историю чата со сквозным шифрованием сервер отдаёт как есть, расшифровывает её WASM
+    if (data.e2e) {
+        const session = await e2eReady;
+        data = { messages: await session.decryptHistory() };
+    }
+
     const chatMessages = document.getElementById('chat-messages');
     chatMessages.innerHTML = '';
 
@@ -535,6 +769,11 @@ try {
            //console.log("Message data:", msg);
            const localTime = new Date(msg.created_at);
            localTime.setHours(localTime.getHours() - 3);
+
+        if (msg.error) {
+            appendMessage(msg.sender_name, `[${msg.error}]`, localTime, msg.sender_id, msg);
+            return;
+        }
            
         if (msg.message_type === 'file') {
             let fileBytes;
@@ -583,14 +822,16 @@ try {
                     mimeType: mimeType
                 },
                 localTime,
-                msg.sender_id
+                msg.sender_id,
+                msg
             );
         } else {
             appendMessage(
                 msg.sender_name,
                 msg.text,
                 localTime,
-                msg.sender_id
+                msg.sender_id,
+                msg
             );
         }
     });
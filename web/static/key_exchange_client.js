@@ -1,37 +1,42 @@
+// Идентификатор устройства: ключи подключений хранятся по устройствам, поэтому вкладки
+// одного браузера делят его, а другой браузер получает свой
+function getDeviceId() {
+  let deviceId = localStorage.getItem('device_id');
+  if (!deviceId) {
+    deviceId = crypto.randomUUID();
+    localStorage.setItem('device_id', deviceId);
+  }
+  return deviceId;
+}
+
 class KeyExchangeClient {
   constructor() {
     // Базовый URL для API
     this.baseUrl = window.location.origin;
   }
   
-  async generateKeyPair(chatId) {
+  async getChat(chatId) {
+    const response = await fetch(`${this.baseUrl}/get-chat?id=${chatId}`, {
+      method: 'GET',
+      headers: {
+        'Authorization': `Bearer ${localStorage.getItem('token')}`,
+      }
+    });
+    if (!response.ok) {
+      throw new Error(`HTTP error! Status: ${response.status}`);
+    }
+    const data = await response.json();
+    return data.chat;
+  }
+
+  // Ключевая пара по алгоритму согласования чата: X25519 (открытый ключ в base64)
+  // или классический Диффи-Хеллман в группе чата (открытый ключ десятичным числом)
+  async generateKeyPair(chat) {
     try {
-      // Реализация генерации ключевой пары по протоколу Диффи-Хеллмана
-      // Используем Web Crypto API
-      const keyPair = await window.crypto.subtle.generateKey(
-        {
-          name: "ECDH",
-          namedCurve: "P-256",
-        },
-        true,
-        ["deriveKey", "deriveBits"]
-      );
-      
-      // Экспортируем публичный ключ
-      const publicKeyBuffer = await window.crypto.subtle.exportKey(
-        "raw",
-        keyPair.publicKey
-      );
-      
-      // Конвертируем в base64 для передачи
-      const publicKeyBase64 = btoa(
-        String.fromCharCode.apply(null, new Uint8Array(publicKeyBuffer))
-      );
-      
-      return {
-        privateKey: keyPair.privateKey,
-        publicKey: publicKeyBase64
-      };
+      if (chat.key_agreement === "x25519") {
+        return await generateX25519KeyPair();
+      }
+      return generateDHKeyPair(BigInt(chat.prime), BigInt(chat.generator));
     } catch (error) {
       console.error("Error generating key pair:", error);
       // Возвращаем заглушку для отладки
@@ -53,6 +58,7 @@ class KeyExchangeClient {
         body: JSON.stringify({
           chat_id: chatId,
           client_id: userId,
+          device_id: getDeviceId(),
           public_key: publicKey
         })
       });
@@ -88,6 +94,8 @@ class KeyExchangeClient {
       // Преобразуем ответ в нужный формат
       return data.public_keys.map(key => ({
         clientId: key.client_id,
+        deviceId: key.device_id,
+        keyId: key.key_id,
         publicKey: key.public_key
       }));
     } catch (error) {
@@ -98,42 +106,107 @@ class KeyExchangeClient {
   }
 }
 
-// Функция для вычисления общего секретного ключа
-async function computeSharedKey(privateKey, publicKeyBase64) {
-  try {
-    // Если мы используем заглушки для отладки
-    if (privateKey === "mock-private-key") {
-      return new Uint8Array(32).fill(1); // Заполняем массив единицами для отладки
-    }
-    
-    // Конвертируем base64 обратно в ArrayBuffer
-    const publicKeyString = atob(publicKeyBase64);
-    const publicKeyBuffer = new Uint8Array(publicKeyString.length);
-    for (let i = 0; i < publicKeyString.length; i++) {
-      publicKeyBuffer[i] = publicKeyString.charCodeAt(i);
+async function generateX25519KeyPair() {
+  const keyPair = await window.crypto.subtle.generateKey(
+    { name: "X25519" },
+    true,
+    ["deriveBits"]
+  );
+
+  const publicKeyBuffer = await window.crypto.subtle.exportKey("raw", keyPair.publicKey);
+
+  return {
+    privateKey: { type: "x25519", key: keyPair.privateKey },
+    publicKey: bytesToBase64(new Uint8Array(publicKeyBuffer))
+  };
+}
+
+// Закрытый ключ выбирается из [2, q-1], где q = (p-1)/2 — порядок подгруппы
+function generateDHKeyPair(prime, generator) {
+  const order = (prime - 1n) / 2n;
+  const x = randomBigInt(order - 2n) + 2n;
+
+  return {
+    privateKey: { type: "dh", x: x, prime: prime },
+    publicKey: modPow(generator, x, prime).toString()
+  };
+}
+
+function modPow(base, exponent, modulus) {
+  let result = 1n;
+  base %= modulus;
+  while (exponent > 0n) {
+    if (exponent & 1n) {
+      result = result * base % modulus;
     }
-    
-    // Импортируем публичный ключ
-    const publicKey = await window.crypto.subtle.importKey(
+    base = base * base % modulus;
+    exponent >>= 1n;
+  }
+  return result;
+}
+
+// Лишние 8 байт делают смещение при взятии остатка пренебрежимо малым
+function randomBigInt(limit) {
+  const bytes = new Uint8Array(Math.ceil(limit.toString(16).length / 2) + 8);
+  window.crypto.getRandomValues(bytes);
+  return BigInt("0x" + bytesToHex(bytes)) % limit;
+}
+
+function bytesToHex(bytes) {
+  return Array.from(bytes, b => b.toString(16).padStart(2, "0")).join("");
+}
+
+function bytesToBase64(bytes) {
+  return btoa(String.fromCharCode.apply(null, bytes));
+}
+
+function base64ToBytes(base64) {
+  const binary = atob(base64);
+  const bytes = new Uint8Array(binary.length);
+  for (let i = 0; i < binary.length; i++) {
+    bytes[i] = binary.charCodeAt(i);
+  }
+  return bytes;
+}
+
+// Общий секрет фиксированной длины: 32 байта для X25519, длина модуля для Диффи-Хеллмана
+async function computeSharedSecret(privateKey, publicKey) {
+  if (privateKey.type === "x25519") {
+    const peerKey = await window.crypto.subtle.importKey(
       "raw",
-      publicKeyBuffer,
-      {
-        name: "ECDH",
-        namedCurve: "P-256",
-      },
+      base64ToBytes(publicKey),
+      { name: "X25519" },
       false,
       []
     );
-    
-    // Вычисляем общий секрет
-    const sharedSecret = await window.crypto.subtle.deriveBits(
-      {
-        name: "ECDH",
-        public: publicKey
-      },
-      privateKey,
+    const secret = await window.crypto.subtle.deriveBits(
+      { name: "X25519", public: peerKey },
+      privateKey.key,
       256
     );
+    return new Uint8Array(secret);
+  }
+
+  const prime = privateKey.prime;
+  const y = BigInt(publicKey);
+  // ключ вне подгруппы порядка q раскрывал бы часть закрытого ключа
+  if (y < 2n || y > prime - 2n || modPow(y, (prime - 1n) / 2n, prime) !== 1n) {
+    throw new Error("invalid Diffie-Hellman public key");
+  }
+  const size = Math.ceil(prime.toString(16).length / 2);
+  const hex = modPow(y, privateKey.x, prime).toString(16).padStart(2 * size, "0");
+  return new Uint8Array(hex.match(/../g).map(h => parseInt(h, 16)));
+}
+
+// Функция для вычисления общего секретного ключа
+async function computeSharedKey(privateKey, publicKey) {
+  try {
+    // Если мы используем заглушки для отладки
+    if (privateKey === "mock-private-key") {
+      return new Uint8Array(32).fill(1); // Заполняем массив единицами для отладки
+    }
+    
+    const sharedSecret = await computeSharedSecret(privateKey, publicKey);
     
     // Хешируем общий секрет для использования в качестве ключа шифрования
     const hashedKey = await window.crypto.subtle.digest("SHA-256", sharedSecret);